@@ -0,0 +1,132 @@
+// Package cube implements cube testers for keyed Xoodyak with a reduced number of rounds of the
+// Xoodoo permutation.
+//
+// A cube is a set of public input bits, taken from the nonce and an optional message. Summing the
+// output over all values of the cube bits, with the other public bits held fixed, gives the
+// superpoly of the cube: a function of the key only. Property testers sample the superpoly on
+// random keys to detect whether it is constant, linear or unbalanced, any of which distinguishes
+// the reduced-round construction from a random function. Cubes of dimension greater than the
+// algebraic degree of the output in the public bits always sum to zero, which EstimateDegree uses
+// to estimate that degree.
+package cube
+
+import (
+	"fmt"
+	"io"
+	"math/bits"
+
+	"github.com/inmcm/xoodoo/xoodoo"
+	"github.com/inmcm/xoodoo/xoodyak"
+)
+
+const (
+	// MaxDimension is the largest cube dimension accepted, bounding the cost of a cube sum to
+	// 2^MaxDimension evaluations
+	MaxDimension = 24
+	// maxKeyNonceLen is the largest combined key and nonce length accepted by Xoodyak
+	maxKeyNonceLen = 43
+)
+
+// Target describes a keyed Xoodyak instance with a reduced number of rounds. An evaluation
+// instantiates Xoodyak with the key and nonce, absorbs the message when MessageLen is not zero and
+// squeezes OutputLen bytes. The public input is the nonce followed by the message, and public bit i
+// is bit i%8 of byte i/8.
+type Target struct {
+	// Rounds is the number of rounds of the Xoodoo permutation, which are its final rounds as done
+	// by xoodoo.NewXoodoo
+	Rounds int
+	// KeyLen is the length of the key in bytes
+	KeyLen int
+	// NonceLen is the length of the nonce in bytes
+	NonceLen int
+	// MessageLen is the length of the absorbed message in bytes
+	MessageLen int
+	// OutputLen is the number of bytes squeezed
+	OutputLen int
+}
+
+// Validate checks that the target describes a valid Xoodyak instance
+func (t Target) Validate() error {
+	if t.Rounds < 1 || t.Rounds > xoodoo.MaxRounds {
+		return fmt.Errorf("cube: invalid number of rounds: %d", t.Rounds)
+	}
+	if t.KeyLen < 1 || t.NonceLen < 0 || t.KeyLen+t.NonceLen > maxKeyNonceLen {
+		return fmt.Errorf("cube: invalid key length (%d bytes) and nonce length (%d bytes)", t.KeyLen, t.NonceLen)
+	}
+	if t.MessageLen < 0 {
+		return fmt.Errorf("cube: invalid message length (%d bytes)", t.MessageLen)
+	}
+	if t.OutputLen < 1 {
+		return fmt.Errorf("cube: invalid output length (%d bytes)", t.OutputLen)
+	}
+	return nil
+}
+
+// PublicBits returns the number of public input bits
+func (t Target) PublicBits() int {
+	return 8 * (t.NonceLen + t.MessageLen)
+}
+
+// Evaluate returns the output of the target for the key and public input, which must have the
+// lengths given by the target
+func (t Target) Evaluate(key, public []byte) []byte {
+	xk := xoodyak.Instantiate(nil, nil, nil)
+	xk.Instance, _ = xoodoo.NewXoodoo(t.Rounds, [xoodoo.StateSizeBytes]byte{})
+	xk.AbsorbKey(key, public[:t.NonceLen], nil)
+	if t.MessageLen > 0 {
+		xk.Absorb(public[t.NonceLen:])
+	}
+	return xk.Squeeze(uint(t.OutputLen))
+}
+
+// Cube is a set of public input bits
+type Cube []int
+
+// Validate checks that the cube holds distinct public bits of the target and that its dimension
+// does not exceed MaxDimension
+func (c Cube) Validate(t Target) error {
+	if len(c) > MaxDimension {
+		return fmt.Errorf("cube: dimension %d exceeds maximum %d", len(c), MaxDimension)
+	}
+	seen := map[int]bool{}
+	for _, v := range c {
+		if v < 0 || v >= t.PublicBits() {
+			return fmt.Errorf("cube: variable %d out of range (%d public bits)", v, t.PublicBits())
+		}
+		if seen[v] {
+			return fmt.Errorf("cube: duplicate variable %d", v)
+		}
+		seen[v] = true
+	}
+	return nil
+}
+
+// Sum returns the sum of the target output over all values of the cube bits, for the key and
+// the other public bits taken from base. The cube must be valid for the target.
+func (t Target) Sum(key, base []byte, c Cube) []byte {
+	public := append([]byte{}, base...)
+	for _, v := range c {
+		public[v>>3] &^= 1 << uint(v&7)
+	}
+	sum := make([]byte, t.OutputLen)
+	for assignment := uint32(0); assignment < 1<<uint(len(c)); assignment++ {
+		// Step through the assignments in Gray code order, flipping a single cube bit each time
+		if assignment > 0 {
+			v := c[bits.TrailingZeros32(assignment)]
+			public[v>>3] ^= 1 << uint(v&7)
+		}
+		for i, b := range t.Evaluate(key, public) {
+			sum[i] ^= b
+		}
+	}
+	return sum
+}
+
+// randomBytes reads n bytes from rnd
+func randomBytes(rnd io.Reader, n int) ([]byte, error) {
+	out := make([]byte, n)
+	if _, err := io.ReadFull(rnd, out); err != nil {
+		return nil, fmt.Errorf("cube: reading random bytes: %s", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,102 @@
+package cube
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/inmcm/xoodoo/xoodoo"
+	"github.com/inmcm/xoodoo/xoodyak"
+	"github.com/stretchr/testify/assert"
+)
+
+func randomBytesFrom(rng *rand.Rand, n int) []byte {
+	out := make([]byte, n)
+	rng.Read(out)
+	return out
+}
+
+func TestEvaluateMatchesXoodyak(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	key, nonce, msg := randomBytesFrom(rng, 16), randomBytesFrom(rng, 16), randomBytesFrom(rng, 30)
+
+	// The full number of rounds matches the standard keyed mode
+	target := Target{Rounds: xoodoo.MaxRounds, KeyLen: 16, NonceLen: 16, MessageLen: 30, OutputLen: 40}
+	assert.NoError(t, target.Validate())
+	xk := xoodyak.Instantiate(key, nonce, nil)
+	xk.Absorb(msg)
+	assert.Equal(t, xk.Squeeze(40), target.Evaluate(key, append(append([]byte{}, nonce...), msg...)))
+
+	// and a reduced number of rounds uses the final rounds of the permutation
+	target = Target{Rounds: 1, KeyLen: 16, NonceLen: 16, OutputLen: 24}
+	xd, _ := xoodoo.NewXoodoo(xoodoo.MaxRounds, [xoodoo.StateSizeBytes]byte{})
+	xd.State.AddBytes(append(append(append([]byte{}, key...), nonce...), 16, 0x01), 0)
+	xd.State.XorByte(0x02, xoodoo.StateSizeBytes-1)
+	xd.State.XorByte(0x40, xoodoo.StateSizeBytes-1)
+	xd.State.Round(xoodoo.MaxRounds - 1)
+	assert.Equal(t, xd.Bytes()[:24], target.Evaluate(key, nonce))
+}
+
+func TestSum(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	target := Target{Rounds: 2, KeyLen: 16, NonceLen: 8, MessageLen: 4, OutputLen: 24}
+	key, base := randomBytesFrom(rng, 16), randomBytesFrom(rng, 12)
+	c := Cube{3, 70, 95}
+	assert.NoError(t, c.Validate(target))
+
+	expected := make([]byte, 24)
+	for a := 0; a < 8; a++ {
+		public := append([]byte{}, base...)
+		for j, v := range c {
+			public[v>>3] &^= 1 << uint(v&7)
+			public[v>>3] |= byte(a>>uint(j)&1) << uint(v&7)
+		}
+		for i, b := range target.Evaluate(key, public) {
+			expected[i] ^= b
+		}
+	}
+	assert.Equal(t, expected, target.Sum(key, base, c))
+
+	// The sum does not depend on the values of the cube bits in the base
+	base[0] ^= 0x08
+	assert.Equal(t, expected, target.Sum(key, base, c))
+}
+
+var targetErrorsTestTable = []struct {
+	target Target
+	err    error
+}{
+	{target: Target{Rounds: 0, KeyLen: 16, NonceLen: 16, OutputLen: 16}, err: errors.New("cube: invalid number of rounds: 0")},
+	{target: Target{Rounds: 13, KeyLen: 16, NonceLen: 16, OutputLen: 16}, err: errors.New("cube: invalid number of rounds: 13")},
+	{target: Target{Rounds: 2, KeyLen: 0, NonceLen: 16, OutputLen: 16}, err: errors.New("cube: invalid key length (0 bytes) and nonce length (16 bytes)")},
+	{target: Target{Rounds: 2, KeyLen: 30, NonceLen: 14, OutputLen: 16}, err: errors.New("cube: invalid key length (30 bytes) and nonce length (14 bytes)")},
+	{target: Target{Rounds: 2, KeyLen: 16, NonceLen: 16, MessageLen: -1, OutputLen: 16}, err: errors.New("cube: invalid message length (-1 bytes)")},
+	{target: Target{Rounds: 2, KeyLen: 16, NonceLen: 16}, err: errors.New("cube: invalid output length (0 bytes)")},
+}
+
+var cubeErrorsTestTable = []struct {
+	cube Cube
+	err  error
+}{
+	{cube: Cube{0, 128}, err: errors.New("cube: variable 128 out of range (128 public bits)")},
+	{cube: Cube{-1}, err: errors.New("cube: variable -1 out of range (128 public bits)")},
+	{cube: Cube{5, 7, 5}, err: errors.New("cube: duplicate variable 5")},
+	{cube: make(Cube, 25), err: errors.New("cube: dimension 25 exceeds maximum 24")},
+}
+
+func TestErrors(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	for _, tt := range targetErrorsTestTable {
+		assert.Equal(t, tt.err, tt.target.Validate())
+		_, err := tt.target.Test(Cube{0}, 1, rng)
+		assert.Equal(t, tt.err, err)
+		_, err = tt.target.EstimateDegree(1, 1, rng)
+		assert.Equal(t, tt.err, err)
+	}
+	target := Target{Rounds: 2, KeyLen: 16, NonceLen: 16, OutputLen: 16}
+	for _, tt := range cubeErrorsTestTable {
+		assert.Equal(t, tt.err, tt.cube.Validate(target))
+		_, err := target.Test(tt.cube, 1, rng)
+		assert.Equal(t, tt.err, err)
+	}
+}
@@ -0,0 +1,217 @@
+package cube
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Superpoly summarizes the samples of the superpoly of one output bit
+type Superpoly struct {
+	// Ones is the number of sampled keys for which the superpoly was 1
+	Ones int
+	// Constant is set when the superpoly took the same value for every sampled key
+	Constant bool
+	// Linear is set when the superpoly passed every linearity test
+	Linear bool
+}
+
+// Report holds the results of testing the superpolys of a cube
+type Report struct {
+	// Cube is the tested cube
+	Cube Cube
+	// Trials is the number of random keys the superpolys were sampled on, and the number of
+	// linearity tests applied to each of them
+	Trials int
+	// Bits holds the results for each output bit, bit i being bit i%8 of output byte i/8
+	Bits []Superpoly
+}
+
+// Balanced reports whether the number of sampled keys for which the superpoly of output bit i was
+// 1 lies within two standard deviations of the count expected for a balanced function
+func (r Report) Balanced(i int) bool {
+	deviation := math.Abs(float64(r.Bits[i].Ones) - float64(r.Trials)/2)
+	return deviation <= math.Sqrt(float64(r.Trials))
+}
+
+// ConstantBits returns the number of output bits whose superpoly was constant
+func (r Report) ConstantBits() int {
+	n := 0
+	for _, b := range r.Bits {
+		if b.Constant {
+			n++
+		}
+	}
+	return n
+}
+
+// LinearBits returns the number of output bits whose superpoly passed every linearity test,
+// including constant superpolys
+func (r Report) LinearBits() int {
+	n := 0
+	for _, b := range r.Bits {
+		if b.Linear {
+			n++
+		}
+	}
+	return n
+}
+
+// BalancedBits returns the number of output bits whose superpoly appeared balanced
+func (r Report) BalancedBits() int {
+	n := 0
+	for i := range r.Bits {
+		if r.Balanced(i) {
+			n++
+		}
+	}
+	return n
+}
+
+// Test samples the superpolys of the cube on random keys read from rnd, with the public bits
+// outside the cube set to zero. Each trial evaluates the superpolys on random keys k1 and k2 and
+// on k1+k2, and applies the linearity test p(k1) + p(k2) + p(k1+k2) + p(0) = 0 satisfied by affine
+// functions.
+func (t Target) Test(c Cube, trials int, rnd io.Reader) (Report, error) {
+	if err := t.Validate(); err != nil {
+		return Report{}, err
+	}
+	if err := c.Validate(t); err != nil {
+		return Report{}, err
+	}
+	if trials < 1 {
+		return Report{}, fmt.Errorf("cube: invalid number of trials: %d", trials)
+	}
+	base := make([]byte, t.NonceLen+t.MessageLen)
+	zero := t.Sum(make([]byte, t.KeyLen), base, c)
+	report := Report{Cube: append(Cube{}, c...), Trials: trials, Bits: make([]Superpoly, 8*t.OutputLen)}
+	for i := range report.Bits {
+		report.Bits[i].Constant = true
+		report.Bits[i].Linear = true
+	}
+	var first []byte
+	for trial := 0; trial < trials; trial++ {
+		k1, err := randomBytes(rnd, t.KeyLen)
+		if err != nil {
+			return Report{}, err
+		}
+		k2, err := randomBytes(rnd, t.KeyLen)
+		if err != nil {
+			return Report{}, err
+		}
+		k12 := make([]byte, t.KeyLen)
+		for i := range k12 {
+			k12[i] = k1[i] ^ k2[i]
+		}
+		p1, p2, p12 := t.Sum(k1, base, c), t.Sum(k2, base, c), t.Sum(k12, base, c)
+		if first == nil {
+			first = p1
+		}
+		for i := range report.Bits {
+			bit := func(p []byte) byte { return p[i>>3] >> uint(i&7) & 1 }
+			b := &report.Bits[i]
+			b.Ones += int(bit(p1))
+			if bit(p1) != bit(first) || bit(p2) != bit(first) || bit(p12) != bit(first) {
+				b.Constant = false
+			}
+			if bit(p1)^bit(p2)^bit(p12)^bit(zero) != 0 {
+				b.Linear = false
+			}
+		}
+	}
+	return report, nil
+}
+
+// Degree is an estimate of the algebraic degree of the target output in the public bits
+type Degree struct {
+	// Rounds is the number of rounds of the target
+	Rounds int
+	// Degree is the largest cube dimension for which a cube with a non-zero sum was found
+	Degree int
+	// Saturated is set when cubes of the largest dimension tried still had non-zero sums, so that
+	// Degree is only a lower bound
+	Saturated bool
+}
+
+// EstimateDegree estimates the degree of the target output in the public bits. For each dimension
+// from 1 up to maxDimension, it sums up to cubes random cubes on random keys and public inputs,
+// stopping at the first dimension for which every sum is zero. As a cube of dimension d has a
+// non-zero sum only if the degree is at least d, the estimate never exceeds the degree.
+func (t Target) EstimateDegree(maxDimension, cubes int, rnd io.Reader) (Degree, error) {
+	if err := t.Validate(); err != nil {
+		return Degree{}, err
+	}
+	limit := MaxDimension
+	if t.PublicBits() < limit {
+		limit = t.PublicBits()
+	}
+	if maxDimension < 1 || maxDimension > limit {
+		return Degree{}, fmt.Errorf("cube: dimension %d out of range (maximum %d)", maxDimension, limit)
+	}
+	if cubes < 1 {
+		return Degree{}, fmt.Errorf("cube: invalid number of cubes: %d", cubes)
+	}
+	estimate := Degree{Rounds: t.Rounds}
+	for d := 1; d <= maxDimension; d++ {
+		found := false
+		for i := 0; i < cubes && !found; i++ {
+			c, err := randomCube(rnd, t.PublicBits(), d)
+			if err != nil {
+				return Degree{}, err
+			}
+			key, err := randomBytes(rnd, t.KeyLen)
+			if err != nil {
+				return Degree{}, err
+			}
+			base, err := randomBytes(rnd, t.NonceLen+t.MessageLen)
+			if err != nil {
+				return Degree{}, err
+			}
+			for _, b := range t.Sum(key, base, c) {
+				if b != 0 {
+					found = true
+				}
+			}
+		}
+		if !found {
+			return estimate, nil
+		}
+		estimate.Degree = d
+	}
+	estimate.Saturated = true
+	return estimate, nil
+}
+
+// randomCube draws a cube of the given dimension at random from the public bits
+func randomCube(rnd io.Reader, publicBits, dimension int) (Cube, error) {
+	c := make(Cube, 0, dimension)
+	seen := map[int]bool{}
+	for len(c) < dimension {
+		b, err := randomBytes(rnd, 4)
+		if err != nil {
+			return nil, err
+		}
+		v := int(binary.LittleEndian.Uint32(b) % uint32(publicBits))
+		if !seen[v] {
+			seen[v] = true
+			c = append(c, v)
+		}
+	}
+	return c, nil
+}
+
+// DegreeByRounds estimates the degree of the target output for each number of rounds from 1 to
+// maxRounds, as done by EstimateDegree
+func (t Target) DegreeByRounds(maxRounds, maxDimension, cubes int, rnd io.Reader) ([]Degree, error) {
+	var degrees []Degree
+	for r := 1; r <= maxRounds; r++ {
+		t.Rounds = r
+		d, err := t.EstimateDegree(maxDimension, cubes, rnd)
+		if err != nil {
+			return nil, err
+		}
+		degrees = append(degrees, d)
+	}
+	return degrees, nil
+}
@@ -0,0 +1,80 @@
+package cube
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuperpolyOneRound(t *testing.T) {
+	// After one round the output has degree 2, so the superpoly of any single nonce bit is affine in
+	// the key, and a cube of dimension 3 sums to zero
+	rng := rand.New(rand.NewSource(4))
+	target := Target{Rounds: 1, KeyLen: 16, NonceLen: 16, OutputLen: 24}
+	report, err := target.Test(Cube{5}, 20, rng)
+	assert.NoError(t, err)
+	assert.Equal(t, Cube{5}, report.Cube)
+	assert.Len(t, report.Bits, 8*24)
+	assert.Equal(t, 8*24, report.LinearBits())
+	assert.True(t, report.ConstantBits() > 0)
+
+	report, err = target.Test(Cube{1, 60, 100}, 10, rng)
+	assert.NoError(t, err)
+	assert.Equal(t, 8*24, report.ConstantBits())
+	for _, b := range report.Bits {
+		assert.Equal(t, 0, b.Ones)
+	}
+	assert.Equal(t, 0, report.BalancedBits())
+}
+
+func TestSuperpolyFourRounds(t *testing.T) {
+	// With more rounds the superpolys of small cubes are neither constant nor linear
+	rng := rand.New(rand.NewSource(5))
+	target := Target{Rounds: 4, KeyLen: 16, NonceLen: 16, OutputLen: 24}
+	report, err := target.Test(Cube{0, 9}, 64, rng)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, report.ConstantBits())
+	assert.True(t, report.LinearBits() < 8)
+	assert.True(t, report.BalancedBits() > 8*24*3/4)
+}
+
+func TestEstimateDegree(t *testing.T) {
+	rng := rand.New(rand.NewSource(6))
+	target := Target{KeyLen: 16, NonceLen: 16, OutputLen: 24}
+	degrees, err := target.DegreeByRounds(3, 10, 32, rng)
+	assert.NoError(t, err)
+	assert.Len(t, degrees, 3)
+	// The degree of r rounds is at most 2^r, and random cubes find it grows past 2^(r-1)
+	for i, d := range degrees {
+		assert.Equal(t, i+1, d.Rounds)
+		assert.False(t, d.Saturated)
+		assert.True(t, d.Degree <= 1<<uint(i+1))
+		assert.True(t, d.Degree > 1<<uint(i))
+	}
+
+	target.Rounds = 4
+	degree, err := target.EstimateDegree(5, 2, rng)
+	assert.NoError(t, err)
+	assert.Equal(t, Degree{Rounds: 4, Degree: 5, Saturated: true}, degree)
+
+	_, err = target.EstimateDegree(25, 2, rng)
+	assert.Equal(t, errors.New("cube: dimension 25 out of range (maximum 24)"), err)
+	_, err = target.EstimateDegree(3, 0, rng)
+	assert.Equal(t, errors.New("cube: invalid number of cubes: 0"), err)
+	_, err = target.EstimateDegree(3, 1, bytes.NewReader(nil))
+	assert.Equal(t, errors.New("cube: reading random bytes: EOF"), err)
+	_, err = target.Test(Cube{1}, 0, rng)
+	assert.Equal(t, errors.New("cube: invalid number of trials: 0"), err)
+}
+
+func BenchmarkSum(b *testing.B) {
+	target := Target{Rounds: 6, KeyLen: 16, NonceLen: 16, OutputLen: 24}
+	key, base := make([]byte, 16), make([]byte, 16)
+	c := Cube{0, 1, 2, 3, 4, 5, 6, 7}
+	for n := 0; n < b.N; n++ {
+		target.Sum(key, base, c)
+	}
+}
@@ -0,0 +1,292 @@
+// Package duplex implements a generic sponge/duplex object over the Xoodoo state in which the rate,
+// capacity, padding rule, domain separation and number of rounds are all parameters. It follows the
+// Up/Down decomposition of the Cyclist mode: Down adds a padded block of input to the state and Up
+// applies the permutation and reads output. Both the classic duplex, where input and output share
+// the rate, and the full-state keyed duplex, where keyed instances absorb input over the capacity as
+// well, are supported. The Xoodyak hash and keyed modes are particular configurations, given by
+// XoodyakHash and XoodyakKeyed.
+package duplex
+
+import (
+	"fmt"
+
+	"github.com/inmcm/xoodoo/xoodoo"
+)
+
+// Padding selects how each absorbed block is padded
+type Padding int
+
+const (
+	// PadSimple appends a single 1 bit (the byte 0x01) directly after the block, as done by the
+	// Cyclist mode. The padding byte may fall outside the absorb rate.
+	PadSimple Padding = iota + 1
+	// PadMultiRate applies the pad10*1 rule within the absorb rate, adding a 1 bit after the block
+	// and another in the final bit of the rate, as done by the Keccak sponges. A block can hold at
+	// most one byte less than the absorb rate.
+	PadMultiRate
+)
+
+// Config describes the parameters of a duplex object. All sizes are in bytes.
+type Config struct {
+	// Rate is the number of bytes output by each call to Up
+	Rate int
+	// Capacity is the number of bytes of the state that are never output. Rate and Capacity must
+	// add up to the Xoodoo state size.
+	Capacity int
+	// AbsorbRate is the number of bytes of input accepted by each call to Down. Zero selects the
+	// Rate. A value larger than the Rate absorbs into the capacity, which is only permitted for
+	// keyed objects (the full-state keyed duplex).
+	AbsorbRate int
+	// Rounds is the number of rounds of the Xoodoo permutation applied by each call to Up
+	Rounds int
+	// Padding is the padding rule applied to each absorbed block
+	Padding Padding
+	// DownDomainMask selects the bits of the domain byte given to Down that are added to the last
+	// byte of the state. Zero disables domain separation on Down calls.
+	DownDomainMask byte
+	// UpDomainMask selects the bits of the domain byte given to Up that are added to the last
+	// byte of the state. Zero disables domain separation on Up calls.
+	UpDomainMask byte
+	// Keyed marks the configuration as intended for keyed use, enabling full-state absorption
+	// and the Crypt method
+	Keyed bool
+}
+
+var (
+	// XoodyakHash is the configuration of Xoodyak in hash mode
+	XoodyakHash = Config{
+		Rate:           16,
+		Capacity:       32,
+		Rounds:         xoodoo.MaxRounds,
+		Padding:        PadSimple,
+		DownDomainMask: 0x01,
+	}
+	// XoodyakKeyed is the configuration of Xoodyak in keyed mode, a full-state keyed duplex
+	XoodyakKeyed = Config{
+		Rate:           24,
+		Capacity:       24,
+		AbsorbRate:     44,
+		Rounds:         xoodoo.MaxRounds,
+		Padding:        PadSimple,
+		DownDomainMask: 0xFF,
+		UpDomainMask:   0xFF,
+		Keyed:          true,
+	}
+)
+
+// absorbRate returns the effective number of bytes absorbed per block
+func (c Config) absorbRate() int {
+	if c.AbsorbRate == 0 {
+		return c.Rate
+	}
+	return c.AbsorbRate
+}
+
+// BlockSize returns the largest block that can be passed to Down
+func (c Config) BlockSize() int {
+	if c.Padding == PadMultiRate {
+		return c.absorbRate() - 1
+	}
+	return c.absorbRate()
+}
+
+// Validate checks that the parameters describe a usable duplex object
+func (c Config) Validate() error {
+	if c.Rate <= 0 || c.Capacity <= 0 || c.Rate+c.Capacity != xoodoo.StateSizeBytes {
+		return fmt.Errorf("duplex: invalid rate (%d bytes) and capacity (%d bytes)", c.Rate, c.Capacity)
+	}
+	if c.Rounds <= 0 || c.Rounds > xoodoo.MaxRounds {
+		return fmt.Errorf("duplex: invalid number of rounds: %d", c.Rounds)
+	}
+	if c.Padding != PadSimple && c.Padding != PadMultiRate {
+		return fmt.Errorf("duplex: invalid padding rule: %d", c.Padding)
+	}
+	absorbRate := c.absorbRate()
+	if absorbRate < 0 || (absorbRate > c.Rate && !c.Keyed) {
+		return fmt.Errorf("duplex: invalid absorb rate (%d bytes) for rate (%d bytes)", absorbRate, c.Rate)
+	}
+	// The padding must fit in the state without reaching the domain separation byte
+	limit := xoodoo.StateSizeBytes
+	if c.DownDomainMask != 0 || c.UpDomainMask != 0 {
+		limit--
+	}
+	padEnd := absorbRate
+	if c.Padding == PadSimple {
+		padEnd++
+	}
+	if c.BlockSize() < 1 || padEnd > limit {
+		return fmt.Errorf("duplex: absorb rate (%d bytes) leaves no room for padding", absorbRate)
+	}
+	return nil
+}
+
+// phase records whether the last call was Up or Down
+type phase int
+
+const (
+	phaseDown phase = iota + 1
+	phaseUp
+)
+
+// Duplex is a sponge/duplex object over the Xoodoo state
+type Duplex struct {
+	xd    *xoodoo.Xoodoo
+	cfg   Config
+	phase phase
+}
+
+// New returns a duplex object with an all-zero state for the given configuration
+func New(cfg Config) (*Duplex, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	xd, _ := xoodoo.NewXoodoo(cfg.Rounds, [xoodoo.StateSizeBytes]byte{})
+	return &Duplex{xd: xd, cfg: cfg, phase: phaseUp}, nil
+}
+
+// NewKeyed returns a duplex object for a keyed configuration whose state is initialized with the
+// key followed by the initialization vector, as done by the full-state keyed duplex. The key and
+// initialization vector must leave at least the capacity of the state free.
+func NewKeyed(cfg Config, key, iv []byte) (*Duplex, error) {
+	if !cfg.Keyed {
+		return nil, fmt.Errorf("duplex: configuration is not keyed")
+	}
+	if len(key)+len(iv) > cfg.Rate {
+		return nil, fmt.Errorf("duplex: key (%d bytes) and iv (%d bytes) exceed rate (%d bytes)", len(key), len(iv), cfg.Rate)
+	}
+	d, err := New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	d.xd.State.OverwriteBytes(key, 0)
+	d.xd.State.OverwriteBytes(iv, len(key))
+	return d, nil
+}
+
+// Config returns the parameters of the duplex object
+func (d *Duplex) Config() Config {
+	return d.cfg
+}
+
+// State returns a copy of the current Xoodoo state
+func (d *Duplex) State() xoodoo.State {
+	return d.xd.State
+}
+
+// Down adds a block of at most BlockSize bytes to the state, followed by the padding and the
+// masked domain byte in the last byte of the state
+func (d *Duplex) Down(block []byte, domain byte) error {
+	if len(block) > d.cfg.BlockSize() {
+		return fmt.Errorf("duplex: block size (%d bytes) exceeds maximum (%d bytes)", len(block), d.cfg.BlockSize())
+	}
+	d.xd.State.AddBytes(block, 0)
+	d.xd.State.XorByte(0x01, len(block))
+	if d.cfg.Padding == PadMultiRate {
+		d.xd.State.XorByte(0x80, d.cfg.absorbRate()-1)
+	}
+	if mask := d.cfg.DownDomainMask; mask != 0 {
+		d.xd.State.XorByte(domain&mask, xoodoo.StateSizeBytes-1)
+	}
+	d.phase = phaseDown
+	return nil
+}
+
+// Up adds the masked domain byte to the last byte of the state, applies the permutation and fills
+// the output buffer, which can be up to Rate bytes, from the start of the state
+func (d *Duplex) Up(domain byte, out []byte) error {
+	if len(out) > d.cfg.Rate {
+		return fmt.Errorf("duplex: output size (%d bytes) exceeds rate (%d bytes)", len(out), d.cfg.Rate)
+	}
+	if mask := d.cfg.UpDomainMask; mask != 0 {
+		d.xd.State.XorByte(domain&mask, xoodoo.StateSizeBytes-1)
+	}
+	d.xd.Permutation()
+	d.xd.State.ExtractBytes(out, 0)
+	d.phase = phaseUp
+	return nil
+}
+
+// Duplexing performs a classic duplex call: the block is absorbed with Down and the permutation is
+// applied with Up to produce the output
+func (d *Duplex) Duplexing(block []byte, domain byte, out []byte) error {
+	if err := d.Down(block, domain); err != nil {
+		return err
+	}
+	return d.Up(0, out)
+}
+
+// Absorb ingests input of any length, one block per Down call, applying Up between blocks. The
+// domain byte is used for the first block only; an empty input is absorbed as a single empty block.
+func (d *Duplex) Absorb(in []byte, domain byte) {
+	blockSize := d.cfg.BlockSize()
+	for {
+		if d.phase != phaseUp {
+			d.Up(0, nil)
+		}
+		n := len(in)
+		if n > blockSize {
+			n = blockSize
+		}
+		d.Down(in[:n], domain)
+		domain = 0
+		in = in[n:]
+		if len(in) == 0 {
+			return
+		}
+	}
+}
+
+// Squeeze fills the output buffer, one Up call per Rate bytes, applying an empty Down between
+// calls. The domain byte is used for the first Up call only.
+func (d *Duplex) Squeeze(out []byte, domain byte) {
+	for {
+		n := len(out)
+		if n > d.cfg.Rate {
+			n = d.cfg.Rate
+		}
+		d.Up(domain, out[:n])
+		domain = 0
+		out = out[n:]
+		if len(out) == 0 {
+			return
+		}
+		d.Down(nil, 0)
+	}
+}
+
+// Crypt encrypts or decrypts the input into the output buffer, which must be at least as long, by
+// adding keystream taken from the rate. Each Rate bytes of plaintext are absorbed back into the state
+// with Down so that later output depends on them. The domain byte is used for the first Up call only.
+// It is only available for keyed configurations.
+func (d *Duplex) Crypt(in, out []byte, decrypt bool, domain byte) error {
+	if !d.cfg.Keyed {
+		return fmt.Errorf("duplex: crypt only available for keyed configurations")
+	}
+	if len(out) < len(in) {
+		return fmt.Errorf("duplex: output (%d bytes) shorter than input (%d bytes)", len(out), len(in))
+	}
+	rate := d.cfg.Rate
+	if bs := d.cfg.BlockSize(); bs < rate {
+		rate = bs
+	}
+	for {
+		n := len(in)
+		if n > rate {
+			n = rate
+		}
+		d.Up(domain, nil)
+		domain = 0
+		block := make([]byte, n)
+		d.xd.State.ExtractAndAddBytes(in[:n], block, 0)
+		if decrypt {
+			d.Down(block, 0)
+		} else {
+			d.Down(in[:n], 0)
+		}
+		copy(out, block)
+		in, out = in[n:], out[n:]
+		if len(in) == 0 {
+			return nil
+		}
+	}
+}
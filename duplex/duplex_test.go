@@ -0,0 +1,191 @@
+package duplex
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/inmcm/xoodoo/xoodoo"
+	"github.com/inmcm/xoodoo/xoodyak"
+	"github.com/stretchr/testify/assert"
+)
+
+func randomBytes(rng *rand.Rand, n int) []byte {
+	out := make([]byte, n)
+	rng.Read(out)
+	return out
+}
+
+func TestXoodyakHashIsSpecialCase(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, msgLen := range []int{0, 1, 15, 16, 17, 100, 1000} {
+		msg := randomBytes(rng, msgLen)
+		for _, outLen := range []int{16, 32, 33, 100} {
+			d, err := New(XoodyakHash)
+			assert.NoError(t, err)
+			d.Absorb(msg, 0x03)
+			got := make([]byte, outLen)
+			d.Squeeze(got, 0x40)
+			assert.Equal(t, xoodyak.HashXoodyakLen(msg, uint(outLen)), got)
+		}
+	}
+}
+
+func TestXoodyakKeyedIsSpecialCase(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for _, tt := range []struct{ adLen, ptLen int }{{0, 0}, {16, 0}, {0, 24}, {44, 25}, {45, 100}, {200, 1000}} {
+		key, nonce := randomBytes(rng, xoodyak.KeyLen), randomBytes(rng, xoodyak.NonceLen)
+		ad, pt := randomBytes(rng, tt.adLen), randomBytes(rng, tt.ptLen)
+		expectedCt, expectedTag, _ := xoodyak.CryptoEncryptAEAD(pt, key, nonce, ad)
+
+		d, err := New(XoodyakKeyed)
+		assert.NoError(t, err)
+		keyID := append(append(append([]byte{}, key...), nonce...), byte(len(nonce)))
+		d.Absorb(keyID, 0x02)
+		d.Absorb(ad, 0x03)
+		ct := make([]byte, len(pt))
+		assert.NoError(t, d.Crypt(pt, ct, false, 0x80))
+		tag := make([]byte, xoodyak.TagLen)
+		d.Squeeze(tag, 0x40)
+		assert.Equal(t, expectedCt, ct)
+		assert.Equal(t, expectedTag, tag)
+
+		// and back again
+		d, _ = New(XoodyakKeyed)
+		d.Absorb(keyID, 0x02)
+		d.Absorb(ad, 0x03)
+		decrypted := make([]byte, len(ct))
+		assert.NoError(t, d.Crypt(ct, decrypted, true, 0x80))
+		d.Squeeze(tag, 0x40)
+		assert.Equal(t, pt, decrypted)
+		assert.Equal(t, expectedTag, tag)
+	}
+}
+
+func TestMultiRatePadding(t *testing.T) {
+	cfg := Config{Rate: 20, Capacity: 28, Rounds: 6, Padding: PadMultiRate}
+	assert.Equal(t, 19, cfg.BlockSize())
+	d, err := New(cfg)
+	assert.NoError(t, err)
+
+	// A full block gets its first and final padding bits in the same byte
+	block := make([]byte, 19)
+	assert.NoError(t, d.Down(block, 0xFF))
+	var expected xoodoo.State
+	expected.XorByte(0x81, 19)
+	assert.Equal(t, expected, d.State())
+
+	d, _ = New(cfg)
+	assert.NoError(t, d.Down([]byte{0xAA}, 0))
+	expected = xoodoo.State{}
+	expected.XorByte(0xAA, 0)
+	expected.XorByte(0x01, 1)
+	expected.XorByte(0x80, 19)
+	assert.Equal(t, expected, d.State())
+}
+
+func TestDuplexing(t *testing.T) {
+	cfg := Config{Rate: 24, Capacity: 24, Rounds: 12, Padding: PadSimple, DownDomainMask: 0x0F}
+	d, _ := New(cfg)
+	out := make([]byte, 24)
+	assert.NoError(t, d.Duplexing([]byte{0x01, 0x02}, 0x35, out))
+
+	xd, _ := xoodoo.NewXoodoo(12, [xoodoo.StateSizeBytes]byte{})
+	xd.State.AddBytes([]byte{0x01, 0x02, 0x01}, 0)
+	xd.State.XorByte(0x05, xoodoo.StateSizeBytes-1)
+	xd.Permutation()
+	assert.Equal(t, xd.Bytes()[:24], out)
+	assert.Equal(t, xd.State, d.State())
+}
+
+func TestFullStateKeyedDuplex(t *testing.T) {
+	cfg := Config{Rate: 32, Capacity: 16, AbsorbRate: 46, Rounds: 6, Padding: PadSimple, Keyed: true}
+	key, iv := []byte("0123456789abcdef"), []byte("initial vector!!")
+	sender, err := NewKeyed(cfg, key, iv)
+	assert.NoError(t, err)
+	var expected xoodoo.State
+	expected.OverwriteBytes(append(append([]byte{}, key...), iv...), 0)
+	assert.Equal(t, expected, sender.State())
+
+	msg := []byte("a message longer than a single rate of the full-state keyed duplex configuration")
+	ct := make([]byte, len(msg))
+	assert.NoError(t, sender.Crypt(msg, ct, false, 0))
+	senderTag := make([]byte, 16)
+	sender.Squeeze(senderTag, 0)
+
+	receiver, _ := NewKeyed(cfg, key, iv)
+	pt := make([]byte, len(ct))
+	assert.NoError(t, receiver.Crypt(ct, pt, true, 0))
+	receiverTag := make([]byte, 16)
+	receiver.Squeeze(receiverTag, 0)
+	assert.Equal(t, msg, pt)
+	assert.Equal(t, senderTag, receiverTag)
+
+	// Full-state absorption reaches into the capacity
+	d, _ := NewKeyed(cfg, key, iv)
+	assert.NoError(t, d.Down(make([]byte, 46), 0))
+	_, err = NewKeyed(cfg, make([]byte, 20), make([]byte, 13))
+	assert.Equal(t, errors.New("duplex: key (20 bytes) and iv (13 bytes) exceed rate (32 bytes)"), err)
+	_, err = NewKeyed(XoodyakHash, key, iv)
+	assert.Equal(t, errors.New("duplex: configuration is not keyed"), err)
+}
+
+var configErrorsTestTable = []struct {
+	cfg Config
+	err error
+}{
+	{
+		cfg: Config{Rate: 16, Capacity: 16, Rounds: 12, Padding: PadSimple},
+		err: errors.New("duplex: invalid rate (16 bytes) and capacity (16 bytes)"),
+	},
+	{
+		cfg: Config{Rate: 16, Capacity: 32, Rounds: 0, Padding: PadSimple},
+		err: errors.New("duplex: invalid number of rounds: 0"),
+	},
+	{
+		cfg: Config{Rate: 16, Capacity: 32, Rounds: 12},
+		err: errors.New("duplex: invalid padding rule: 0"),
+	},
+	{
+		cfg: Config{Rate: 16, Capacity: 32, AbsorbRate: 44, Rounds: 12, Padding: PadSimple},
+		err: errors.New("duplex: invalid absorb rate (44 bytes) for rate (16 bytes)"),
+	},
+	{
+		cfg: Config{Rate: 24, Capacity: 24, AbsorbRate: 47, Rounds: 12, Padding: PadSimple, UpDomainMask: 0xFF, Keyed: true},
+		err: errors.New("duplex: absorb rate (47 bytes) leaves no room for padding"),
+	},
+	{
+		cfg: Config{Rate: 47, Capacity: 1, Rounds: 12, Padding: PadSimple, DownDomainMask: 0x01},
+		err: errors.New("duplex: absorb rate (47 bytes) leaves no room for padding"),
+	},
+}
+
+func TestConfigErrors(t *testing.T) {
+	for _, tt := range configErrorsTestTable {
+		d, err := New(tt.cfg)
+		assert.Nil(t, d)
+		assert.Equal(t, tt.err, err)
+	}
+	assert.NoError(t, XoodyakHash.Validate())
+	assert.NoError(t, XoodyakKeyed.Validate())
+}
+
+func TestDuplexErrors(t *testing.T) {
+	d, _ := New(XoodyakHash)
+	assert.Equal(t, XoodyakHash, d.Config())
+	assert.Equal(t, errors.New("duplex: block size (17 bytes) exceeds maximum (16 bytes)"), d.Down(make([]byte, 17), 0))
+	assert.Equal(t, errors.New("duplex: output size (17 bytes) exceeds rate (16 bytes)"), d.Up(0, make([]byte, 17)))
+	assert.Equal(t, errors.New("duplex: crypt only available for keyed configurations"), d.Crypt(nil, nil, false, 0))
+
+	k, _ := New(XoodyakKeyed)
+	assert.Equal(t, errors.New("duplex: output (1 bytes) shorter than input (2 bytes)"), k.Crypt(make([]byte, 2), make([]byte, 1), false, 0))
+}
+
+func BenchmarkAbsorbXoodyakHash(b *testing.B) {
+	d, _ := New(XoodyakHash)
+	data := make([]byte, 1024)
+	b.SetBytes(int64(len(data)))
+	for n := 0; n < b.N; n++ {
+		d.Absorb(data, 0x03)
+	}
+}
@@ -0,0 +1,281 @@
+package equations
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/inmcm/xoodoo/xoodoo"
+)
+
+// Monomial is a product of distinct variables, listed in increasing order. The empty monomial is
+// the constant 1.
+type Monomial []int
+
+// Polynomial is a sum of distinct monomials over GF(2)
+type Polynomial []Monomial
+
+// Evaluate returns the value (0 or 1) of the polynomial for the assignment, indexed by variable
+func (p Polynomial) Evaluate(assignment []bool) uint8 {
+	var sum uint8
+	for _, m := range p {
+		term := uint8(1)
+		for _, v := range m {
+			if !assignment[v] {
+				term = 0
+				break
+			}
+		}
+		sum ^= term
+	}
+	return sum
+}
+
+// String returns the polynomial in the usual notation, with variable v written xv, e.g.
+// "x0*x5 + x384 + 1"
+func (p Polynomial) String() string {
+	if len(p) == 0 {
+		return "0"
+	}
+	terms := make([]string, len(p))
+	for i, m := range p {
+		if len(m) == 0 {
+			terms[i] = "1"
+			continue
+		}
+		vars := make([]string, len(m))
+		for j, v := range m {
+			vars[j] = fmt.Sprintf("x%d", v)
+		}
+		terms[i] = strings.Join(vars, "*")
+	}
+	return strings.Join(terms, " + ")
+}
+
+// ANF is a system of polynomial equations over GF(2), each stating that a polynomial is zero, whose
+// solutions are the evaluations of reduced-round Xoodoo. Variables are numbered from 0.
+//
+// Bit i of the permutation input is variable i. Round r (counting from 0) adds the variables
+// StateSizeBits*(r+1) + i for bit i of the state after χ, and one quadratic equation defining each
+// of them in terms of the variables of the previous round. The linear steps are folded into the
+// equations.
+type ANF struct {
+	// NumVars is the number of variables in the system
+	NumVars int
+	// Polynomials holds the equations of the system, each stating that the polynomial is zero
+	Polynomials []Polynomial
+	// Input holds the variable of each bit of the permutation input
+	Input [StateSizeBits]int
+	// Output holds the variable of each bit of the permutation output
+	Output [StateSizeBits]int
+	rounds int
+}
+
+// linear is a linear form: a sum of distinct variables and a constant
+type linear struct {
+	vars     []int
+	constant uint8
+}
+
+var (
+	// firstRound maps the permutation input to the input of χ in the first round
+	firstRound = linearMap(func(s *xoodoo.State) {
+		s.Theta()
+		s.RhoWest()
+	})
+	// laterRound maps the output of χ to the input of χ in the next round
+	laterRound = linearMap(func(s *xoodoo.State) {
+		s.RhoEast()
+		s.Theta()
+		s.RhoWest()
+	})
+)
+
+// NewANF returns the system of equations of the permutation described by the options
+func NewANF(opts Options) (*ANF, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	a := &ANF{NumVars: StateSizeBits * (opts.Rounds + 1), rounds: opts.Rounds}
+	for i := range a.Input {
+		a.Input[i] = i
+		if bit(opts.InputMask, i) == 1 {
+			a.Polynomials = append(a.Polynomials, newPolynomial(map[[2]int]bool{
+				{-1, i}:  true,
+				{-1, -1}: bit(opts.Input, i) == 1,
+			}))
+		}
+	}
+	for r := 0; r < opts.Rounds; r++ {
+		rc := xoodoo.RoundConstants[xoodoo.MaxRounds-opts.Rounds+r]
+		rows := &laterRound
+		if r == 0 {
+			rows = &firstRound
+		}
+		// Variables of the previous round and of this one
+		prev, next := StateSizeBits*r, StateSizeBits*(r+1)
+		var forms [StateSizeBits]linear
+		for i, row := range rows {
+			forms[i].vars = make([]int, len(row))
+			for j, v := range row {
+				forms[i].vars[j] = prev + v
+			}
+			if i < 32 {
+				forms[i].constant = uint8(rc >> uint(i) & 1)
+			}
+		}
+		for y := 0; y < 3; y++ {
+			for x := 0; x < 4; x++ {
+				for z := 0; z < 32; z++ {
+					i := bitIndex(x, y, z)
+					a.Polynomials = append(a.Polynomials, chiEquation(next+i,
+						forms[i], forms[bitIndex(x, y+1, z)], forms[bitIndex(x, y+2, z)]))
+				}
+			}
+		}
+	}
+	last := StateSizeBits * opts.Rounds
+	for i, j := range rhoEast {
+		a.Output[i] = last + j
+		if bit(opts.OutputMask, i) == 1 {
+			a.Polynomials = append(a.Polynomials, newPolynomial(map[[2]int]bool{
+				{-1, a.Output[i]}: true,
+				{-1, -1}:          bit(opts.Output, i) == 1,
+			}))
+		}
+	}
+	return a, nil
+}
+
+// chiEquation returns the polynomial b + a0 + (a1+1)·a2, expanded from the linear forms
+func chiEquation(b int, a0, a1, a2 linear) Polynomial {
+	// Monomials of degree at most two are keyed by their variables, with -1 standing for an
+	// absent variable
+	terms := map[[2]int]bool{}
+	toggle := func(u, v int) {
+		if u == v {
+			u = -1
+		}
+		if u > v {
+			u, v = v, u
+		}
+		terms[[2]int{u, v}] = !terms[[2]int{u, v}]
+	}
+	addLinear := func(l linear) {
+		for _, v := range l.vars {
+			toggle(-1, v)
+		}
+		if l.constant == 1 {
+			toggle(-1, -1)
+		}
+	}
+	toggle(-1, b)
+	addLinear(a0)
+	addLinear(a2)
+	// a1·a2, using x·x = x
+	for _, u := range a1.vars {
+		for _, v := range a2.vars {
+			toggle(u, v)
+		}
+	}
+	if a1.constant == 1 {
+		addLinear(linear{vars: a2.vars})
+	}
+	if a2.constant == 1 {
+		addLinear(linear{vars: a1.vars})
+	}
+	if a1.constant == 1 && a2.constant == 1 {
+		toggle(-1, -1)
+	}
+	return newPolynomial(terms)
+}
+
+// newPolynomial returns the polynomial with the monomials marked in terms, with higher degree
+// monomials first and in increasing order of variables otherwise
+func newPolynomial(terms map[[2]int]bool) Polynomial {
+	keys := make([][2]int, 0, len(terms))
+	for k, present := range terms {
+		if present {
+			keys = append(keys, k)
+		}
+	}
+	degree := func(k [2]int) int {
+		d := 0
+		for _, v := range k {
+			if v >= 0 {
+				d++
+			}
+		}
+		return d
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		di, dj := degree(keys[i]), degree(keys[j])
+		if di != dj {
+			return di > dj
+		}
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+	p := make(Polynomial, len(keys))
+	for i, k := range keys {
+		m := Monomial{}
+		for _, v := range k {
+			if v >= 0 {
+				m = append(m, v)
+			}
+		}
+		p[i] = m
+	}
+	return p
+}
+
+// Assignment returns the values of all variables when the permutation is evaluated on the given
+// input. The assignment solves the system unless the input or output differ from the fixed bits.
+func (a *ANF) Assignment(in xoodoo.State) []bool {
+	values := make([]bool, a.NumVars)
+	s := in
+	record := func(base int) {
+		for i := 0; i < StateSizeBits; i++ {
+			values[base+i] = bit(s, i) == 1
+		}
+	}
+	record(0)
+	for r := 0; r < a.rounds; r++ {
+		s.Theta()
+		s.RhoWest()
+		s.Iota(xoodoo.MaxRounds - a.rounds + r)
+		s.Chi()
+		record(StateSizeBits * (r + 1))
+		s.RhoEast()
+	}
+	return values
+}
+
+// Solved reports whether the assignment, indexed by variable, makes every polynomial zero
+func (a *ANF) Solved(assignment []bool) bool {
+	for _, p := range a.Polynomials {
+		if p.Evaluate(assignment) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// WriteTo writes the system to w, one polynomial per line
+func (a *ANF) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	for _, p := range a.Polynomials {
+		cw.printf("%s\n", p)
+	}
+	return cw.n, cw.err
+}
+
+// String returns the system as produced by WriteTo
+func (a *ANF) String() string {
+	var b strings.Builder
+	a.WriteTo(&b)
+	return b.String()
+}
@@ -0,0 +1,108 @@
+package equations
+
+import (
+	"errors"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/inmcm/xoodoo/xoodoo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestANFMatchesPermutation(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	for _, rounds := range []int{1, 2, 4, 12} {
+		a, err := NewANF(Options{Rounds: rounds})
+		assert.NoError(t, err)
+		assert.Equal(t, StateSizeBits*(rounds+1), a.NumVars)
+		assert.Len(t, a.Polynomials, StateSizeBits*rounds)
+		for i := 0; i < 5; i++ {
+			in := randomState(rng)
+			assignment := a.Assignment(in)
+			assert.True(t, a.Solved(assignment))
+			var out xoodoo.State
+			for i, v := range a.Output {
+				if assignment[v] {
+					out[i>>5] |= 1 << uint(i&31)
+				}
+			}
+			assert.Equal(t, permute(in, rounds), out)
+
+			v := rng.Intn(a.NumVars - StateSizeBits)
+			assignment[v] = !assignment[v]
+			assert.False(t, a.Solved(assignment))
+		}
+	}
+}
+
+func TestANFDegree(t *testing.T) {
+	a, _ := NewANF(Options{Rounds: 2})
+	for _, p := range a.Polynomials {
+		assert.Len(t, p[0], 2)
+		for _, m := range p {
+			assert.True(t, len(m) <= 2)
+		}
+	}
+}
+
+func TestANFFixedBits(t *testing.T) {
+	rng := rand.New(rand.NewSource(4))
+	in := randomState(rng)
+	out := permute(in, 2)
+	a, err := NewANF(Options{
+		Rounds:     2,
+		InputMask:  xoodoo.State{0x0000000F},
+		Input:      in,
+		OutputMask: xoodoo.State{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0xF0000000},
+		Output:     out,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, a.Polynomials, StateSizeBits*2+8)
+	assert.True(t, a.Solved(a.Assignment(in)))
+	in[0] ^= 0x4
+	assert.False(t, a.Solved(a.Assignment(in)))
+}
+
+var polynomialStringTestTable = []struct {
+	p   Polynomial
+	str string
+}{
+	{p: Polynomial{}, str: "0"},
+	{p: Polynomial{{}}, str: "1"},
+	{p: Polynomial{{0, 5}, {384}, {}}, str: "x0*x5 + x384 + 1"},
+}
+
+func TestPolynomialString(t *testing.T) {
+	for _, tt := range polynomialStringTestTable {
+		assert.Equal(t, tt.str, tt.p.String())
+	}
+}
+
+func TestChiEquation(t *testing.T) {
+	// x0 + (x1 + x2 + 1) + ((x1 + 1) + 1)·(x1 + x3 + 1), which simplifies as x1·x1 = x1
+	p := chiEquation(0, linear{vars: []int{1, 2}, constant: 1}, linear{vars: []int{1}, constant: 1}, linear{vars: []int{1, 3}, constant: 1})
+	assert.Equal(t, "x1*x3 + x0 + x1 + x2 + 1", p.String())
+	for v := 0; v < 16; v++ {
+		assignment := []bool{v&1 == 1, v&2 == 2, v&4 == 4, v&8 == 8}
+		x := func(i int) uint8 {
+			if assignment[i] {
+				return 1
+			}
+			return 0
+		}
+		expected := x(0) ^ x(1) ^ x(2) ^ 1 ^ x(1)&(x(1)^x(3)^1)
+		assert.Equal(t, expected, p.Evaluate(assignment))
+	}
+}
+
+func TestANFWrite(t *testing.T) {
+	a, _ := NewANF(Options{Rounds: 1})
+	text := a.String()
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	assert.Len(t, lines, StateSizeBits)
+	assert.Equal(t, a.Polynomials[0].String(), lines[0])
+	assert.Contains(t, lines[0], "x384")
+	_, err := a.WriteTo(failingWriter{})
+	assert.Equal(t, errors.New("write failed"), err)
+}
@@ -0,0 +1,260 @@
+package equations
+
+import (
+	"io"
+	"strings"
+
+	"github.com/inmcm/xoodoo/xoodoo"
+)
+
+// CNF is a formula in conjunctive normal form whose satisfying assignments are the evaluations of
+// reduced-round Xoodoo. Variables are numbered from 1 and literals follow the DIMACS convention: a
+// positive literal v is variable v and a negative literal -v its negation.
+//
+// Bit i of the permutation input is variable i+1. Each round adds one variable per column parity
+// and one per bit after θ, followed by one variable per bit after χ. The ρ and ι steps only rename
+// and negate literals.
+type CNF struct {
+	// NumVars is the number of variables in the formula
+	NumVars int
+	// Clauses holds the clauses of the formula, each a disjunction of literals
+	Clauses [][]int
+	// Input holds the variable of each bit of the permutation input
+	Input [StateSizeBits]int
+	// Output holds the variable of each bit of the permutation output
+	Output [StateSizeBits]int
+	// gates holds the definition of each variable after the input bits, used to compute the
+	// assignment for a given input
+	gates []gate
+}
+
+// gate defines a variable as the sum of literals, or as χ of three literals a[0] + (a[1]+1)·a[2]
+type gate struct {
+	chi bool
+	in  []int
+}
+
+// NewCNF returns the CNF formula of the permutation described by the options
+func NewCNF(opts Options) (*CNF, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	c := &CNF{}
+	var lits [StateSizeBits]int
+	for i := range lits {
+		lits[i] = c.newVar(gate{})
+		c.Input[i] = lits[i]
+		if bit(opts.InputMask, i) == 1 {
+			c.Clauses = append(c.Clauses, []int{signed(lits[i], bit(opts.Input, i))})
+		}
+	}
+	for round := xoodoo.MaxRounds - opts.Rounds; round < xoodoo.MaxRounds; round++ {
+		lits = c.theta(lits)
+		lits = permuteLiterals(lits, rhoWest)
+		for i := 0; i < 32; i++ {
+			if xoodoo.RoundConstants[round]>>uint(i)&1 == 1 {
+				lits[i] = -lits[i]
+			}
+		}
+		lits = c.chi(lits)
+		lits = permuteLiterals(lits, rhoEast)
+	}
+	for i := range lits {
+		c.Output[i] = lits[i]
+		if bit(opts.OutputMask, i) == 1 {
+			c.Clauses = append(c.Clauses, []int{signed(lits[i], bit(opts.Output, i))})
+		}
+	}
+	return c, nil
+}
+
+// signed returns the literal asserting that the variable has the given value
+func signed(v int, value uint8) int {
+	if value == 0 {
+		return -v
+	}
+	return v
+}
+
+func (c *CNF) newVar(g gate) int {
+	c.NumVars++
+	if c.NumVars > StateSizeBits {
+		c.gates = append(c.gates, g)
+	}
+	return c.NumVars
+}
+
+// addXor defines a new variable as the sum of the literals, adding the clauses that exclude every
+// assignment in which the variable and the literals have odd parity
+func (c *CNF) addXor(in ...int) int {
+	v := c.newVar(gate{in: in})
+	lits := append([]int{v}, in...)
+	for assignment := 0; assignment < 1<<uint(len(lits)); assignment++ {
+		parity := 0
+		clause := make([]int, len(lits))
+		for j, l := range lits {
+			if assignment>>uint(j)&1 == 1 {
+				parity ^= 1
+				clause[j] = -l
+			} else {
+				clause[j] = l
+			}
+		}
+		if parity == 1 {
+			c.Clauses = append(c.Clauses, clause)
+		}
+	}
+	return v
+}
+
+// addChi defines a new variable as a[0] + (a[1]+1)·a[2], adding the clauses that exclude every
+// inconsistent assignment
+func (c *CNF) addChi(a0, a1, a2 int) int {
+	v := c.newVar(gate{chi: true, in: []int{a0, a1, a2}})
+	lits := []int{v, a0, a1, a2}
+	for assignment := 0; assignment < 16; assignment++ {
+		b, x0, x1, x2 := assignment&1, assignment>>1&1, assignment>>2&1, assignment>>3&1
+		if b == x0^(x1^1)&x2 {
+			continue
+		}
+		clause := make([]int, len(lits))
+		for j, l := range lits {
+			if assignment>>uint(j)&1 == 1 {
+				clause[j] = -l
+			} else {
+				clause[j] = l
+			}
+		}
+		c.Clauses = append(c.Clauses, clause)
+	}
+	return v
+}
+
+func (c *CNF) theta(lits [StateSizeBits]int) [StateSizeBits]int {
+	var parity [4][32]int
+	for x := 0; x < 4; x++ {
+		for z := 0; z < 32; z++ {
+			parity[x][z] = c.addXor(lits[bitIndex(x, 0, z)], lits[bitIndex(x, 1, z)], lits[bitIndex(x, 2, z)])
+		}
+	}
+	var out [StateSizeBits]int
+	for y := 0; y < 3; y++ {
+		for x := 0; x < 4; x++ {
+			for z := 0; z < 32; z++ {
+				in := []int{lits[bitIndex(x, y, z)]}
+				for _, o := range xoodoo.DefaultThetaOffsets {
+					in = append(in, parity[(x-o.X)&3][(z-o.Z)&31])
+				}
+				out[bitIndex(x, y, z)] = c.addXor(in...)
+			}
+		}
+	}
+	return out
+}
+
+func (c *CNF) chi(lits [StateSizeBits]int) [StateSizeBits]int {
+	var out [StateSizeBits]int
+	for y := 0; y < 3; y++ {
+		for x := 0; x < 4; x++ {
+			for z := 0; z < 32; z++ {
+				out[bitIndex(x, y, z)] = c.addChi(lits[bitIndex(x, y, z)], lits[bitIndex(x, y+1, z)], lits[bitIndex(x, y+2, z)])
+			}
+		}
+	}
+	return out
+}
+
+func permuteLiterals(lits [StateSizeBits]int, perm [StateSizeBits]int) [StateSizeBits]int {
+	var out [StateSizeBits]int
+	for i, j := range perm {
+		out[i] = lits[j]
+	}
+	return out
+}
+
+// Assignment returns the values of all variables, indexed by variable number, when the permutation
+// is evaluated on the given input. Index 0 is unused. The assignment satisfies the formula unless
+// the input or output differ from the fixed bits.
+func (c *CNF) Assignment(in xoodoo.State) []bool {
+	values := make([]bool, c.NumVars+1)
+	value := func(l int) bool {
+		if l < 0 {
+			return !values[-l]
+		}
+		return values[l]
+	}
+	for i, v := range c.Input {
+		values[v] = bit(in, i) == 1
+	}
+	for i, g := range c.gates {
+		var b bool
+		if g.chi {
+			b = value(g.in[0]) != (!value(g.in[1]) && value(g.in[2]))
+		} else {
+			for _, l := range g.in {
+				b = b != value(l)
+			}
+		}
+		values[StateSizeBits+1+i] = b
+	}
+	return values
+}
+
+// Satisfied reports whether the assignment, indexed by variable number, satisfies every clause
+func (c *CNF) Satisfied(assignment []bool) bool {
+	for _, clause := range c.Clauses {
+		ok := false
+		for _, l := range clause {
+			if l < 0 && !assignment[-l] || l > 0 && assignment[l] {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// OutputState returns the permutation output given by the assignment
+func (c *CNF) OutputState(assignment []bool) xoodoo.State {
+	var s xoodoo.State
+	for i, v := range c.Output {
+		if assignment[v] {
+			s[i>>5] |= 1 << uint(i&31)
+		}
+	}
+	return s
+}
+
+// WriteTo writes the formula to w in DIMACS format. Comment lines before the header list the
+// variables of the input and output bits in bit order.
+func (c *CNF) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	for _, vars := range []struct {
+		name string
+		vars *[StateSizeBits]int
+	}{{"input", &c.Input}, {"output", &c.Output}} {
+		cw.printf("c %s", vars.name)
+		for _, v := range vars.vars {
+			cw.printf(" %d", v)
+		}
+		cw.printf("\n")
+	}
+	cw.printf("p cnf %d %d\n", c.NumVars, len(c.Clauses))
+	for _, clause := range c.Clauses {
+		for _, l := range clause {
+			cw.printf("%d ", l)
+		}
+		cw.printf("0\n")
+	}
+	return cw.n, cw.err
+}
+
+// String returns the formula in DIMACS format as produced by WriteTo
+func (c *CNF) String() string {
+	var b strings.Builder
+	c.WriteTo(&b)
+	return b.String()
+}
@@ -0,0 +1,106 @@
+package equations
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/inmcm/xoodoo/xoodoo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCNFMatchesPermutation(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, rounds := range []int{1, 2, 3, 6, 12} {
+		c, err := NewCNF(Options{Rounds: rounds})
+		assert.NoError(t, err)
+		assert.Equal(t, StateSizeBits+rounds*(128+2*StateSizeBits), c.NumVars)
+		assert.Equal(t, rounds*(128*8+StateSizeBits*8+StateSizeBits*8), len(c.Clauses))
+		for i := 0; i < 5; i++ {
+			in := randomState(rng)
+			assignment := c.Assignment(in)
+			assert.True(t, c.Satisfied(assignment))
+			assert.Equal(t, permute(in, rounds), c.OutputState(assignment))
+
+			// Changing any single variable breaks the formula
+			v := 1 + rng.Intn(c.NumVars)
+			assignment[v] = !assignment[v]
+			assert.False(t, c.Satisfied(assignment))
+		}
+	}
+}
+
+func TestCNFFixedBits(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	in := randomState(rng)
+	out := permute(in, 3)
+	opts := Options{
+		Rounds:     3,
+		InputMask:  xoodoo.State{0xFFFFFFFF, 0x0000FFFF},
+		Input:      in,
+		OutputMask: xoodoo.State{0, 0, 0, 0, 0xFFFFFFFF},
+		Output:     out,
+	}
+	c, err := NewCNF(opts)
+	assert.NoError(t, err)
+	assert.Equal(t, 48+32, len(c.Clauses)-3*(128+2*StateSizeBits)*8)
+	assert.True(t, c.Satisfied(c.Assignment(in)))
+
+	// An input with a different fixed bit, or leading to a different fixed output bit, fails
+	other := in
+	other[1] ^= 0x00000100
+	assert.False(t, c.Satisfied(c.Assignment(other)))
+	other = in
+	other[11] ^= 0x00000001
+	assert.NotEqual(t, out[4], permute(other, 3)[4])
+	assert.False(t, c.Satisfied(c.Assignment(other)))
+}
+
+func TestCNFDIMACS(t *testing.T) {
+	in := xoodoo.State{0x00000001}
+	c, _ := NewCNF(Options{Rounds: 1, InputMask: in, Input: in})
+	text := c.String()
+	assert.True(t, strings.HasPrefix(text, "c input 1 2 3 "))
+	assert.Contains(t, text, fmt.Sprintf("\np cnf %d %d\n1 0\n", c.NumVars, len(c.Clauses)))
+
+	// Parse the formula back
+	var clauses [][]int
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "c ") || strings.HasPrefix(line, "p ") {
+			continue
+		}
+		var clause []int
+		for _, field := range strings.Fields(line) {
+			var l int
+			fmt.Sscan(field, &l)
+			if l != 0 {
+				clause = append(clause, l)
+			}
+		}
+		clauses = append(clauses, clause)
+	}
+	assert.Equal(t, c.Clauses, clauses)
+
+	n, err := c.WriteTo(&strings.Builder{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(text)), n)
+	_, err = c.WriteTo(failingWriter{})
+	assert.Equal(t, errors.New("write failed"), err)
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func BenchmarkNewCNF(b *testing.B) {
+	for n := 0; n < b.N; n++ {
+		NewCNF(Options{Rounds: 6})
+	}
+}
@@ -0,0 +1,99 @@
+// Package equations generates systems of equations describing reduced-round Xoodoo, for use with
+// SAT solvers and algebraic solvers in preimage and collision experiments. The permutation can be
+// emitted as a CNF formula in DIMACS format or as a system of polynomials over GF(2) in algebraic
+// normal form, optionally with some input and output bits fixed to known values.
+//
+// Bits of the state are numbered as in its serialization: bit i is bit i%32 of State[i/32], which
+// is bit i%8 of byte i/8 of the output of MarshalBinary, and lies at coordinates (x, y, z) =
+// ((i/32)%4, i/128, i%32).
+package equations
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/inmcm/xoodoo/xoodoo"
+)
+
+// StateSizeBits is the number of bits in the Xoodoo state
+const StateSizeBits = xoodoo.StateSizeBytes * 8
+
+// Options selects the permutation described by the equations and the bits fixed to known values
+type Options struct {
+	// Rounds is the number of rounds of the permutation, which are its final rounds as done by
+	// xoodoo.NewXoodoo
+	Rounds int
+	// InputMask selects the bits of the permutation input fixed to their value in Input
+	InputMask xoodoo.State
+	// Input holds the values of the fixed input bits
+	Input xoodoo.State
+	// OutputMask selects the bits of the permutation output fixed to their value in Output
+	OutputMask xoodoo.State
+	// Output holds the values of the fixed output bits
+	Output xoodoo.State
+}
+
+func (o Options) validate() error {
+	if o.Rounds < 1 || o.Rounds > xoodoo.MaxRounds {
+		return fmt.Errorf("equations: invalid number of rounds: %d", o.Rounds)
+	}
+	return nil
+}
+
+// bit returns bit i of the state
+func bit(s xoodoo.State, i int) uint8 {
+	return uint8(s[i>>5] >> uint(i&31) & 1)
+}
+
+// bitIndex returns the number of the bit at coordinates (x, y, z)
+func bitIndex(x, y, z int) int {
+	return 32*(4*(y%3)+(x&3)) + (z & 31)
+}
+
+// linearMap returns, for each bit of the output of the linear step mapping f, the input bits it is
+// the sum of in increasing order. The map is found by applying f to each unit vector.
+func linearMap(f func(s *xoodoo.State)) [StateSizeBits][]int {
+	var rows [StateSizeBits][]int
+	for j := 0; j < StateSizeBits; j++ {
+		var s xoodoo.State
+		s[j>>5] = 1 << uint(j&31)
+		f(&s)
+		for i := 0; i < StateSizeBits; i++ {
+			if bit(s, i) == 1 {
+				rows[i] = append(rows[i], j)
+			}
+		}
+	}
+	return rows
+}
+
+// bitPermutation returns, for each output bit of a step mapping that only moves bits, the input
+// bit it comes from
+func bitPermutation(f func(s *xoodoo.State)) [StateSizeBits]int {
+	var perm [StateSizeBits]int
+	for i, row := range linearMap(f) {
+		perm[i] = row[0]
+	}
+	return perm
+}
+
+var (
+	rhoWest = bitPermutation((*xoodoo.State).RhoWest)
+	rhoEast = bitPermutation((*xoodoo.State).RhoEast)
+)
+
+// countingWriter tracks the bytes written for the WriteTo methods
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (cw *countingWriter) printf(format string, args ...interface{}) {
+	if cw.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(cw.w, format, args...)
+	cw.n += int64(n)
+	cw.err = err
+}
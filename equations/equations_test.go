@@ -0,0 +1,64 @@
+package equations
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/inmcm/xoodoo/xoodoo"
+	"github.com/stretchr/testify/assert"
+)
+
+func randomState(rng *rand.Rand) xoodoo.State {
+	var s xoodoo.State
+	for i := range s {
+		s[i] = rng.Uint32()
+	}
+	return s
+}
+
+// permute returns the output of the given number of rounds of Xoodoo
+func permute(in xoodoo.State, rounds int) xoodoo.State {
+	xd, _ := xoodoo.NewXoodoo(rounds, [xoodoo.StateSizeBytes]byte{})
+	xd.State = in
+	xd.Permutation()
+	return xd.State
+}
+
+func TestBitNumbering(t *testing.T) {
+	var s xoodoo.State
+	s.SetBit(1, 2, 7, 1)
+	assert.Equal(t, uint8(1), bit(s, bitIndex(1, 2, 7)))
+	assert.Equal(t, 32*9+7, bitIndex(1, 2, 7))
+	assert.Equal(t, bitIndex(1, 0, 7), bitIndex(1, 3, 7))
+	data, _ := s.MarshalBinary()
+	i := bitIndex(1, 2, 7)
+	assert.Equal(t, byte(1), data[i/8]>>uint(i%8)&1)
+
+	for _, perm := range [][StateSizeBits]int{rhoWest, rhoEast} {
+		seen := map[int]bool{}
+		for _, j := range perm {
+			seen[j] = true
+		}
+		assert.Len(t, seen, StateSizeBits)
+	}
+}
+
+var optionsErrorsTestTable = []struct {
+	rounds int
+	err    error
+}{
+	{rounds: 0, err: errors.New("equations: invalid number of rounds: 0")},
+	{rounds: 13, err: errors.New("equations: invalid number of rounds: 13")},
+}
+
+func TestOptionsErrors(t *testing.T) {
+	for _, tt := range optionsErrorsTestTable {
+		c, err := NewCNF(Options{Rounds: tt.rounds})
+		assert.Nil(t, c)
+		assert.Equal(t, tt.err, err)
+		a, err := NewANF(Options{Rounds: tt.rounds})
+		assert.Nil(t, a)
+		assert.Equal(t, tt.err, err)
+	}
+}
@@ -0,0 +1,147 @@
+package evenmansour
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/inmcm/xoodoo/xoodoo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestZeroKeyIsPermutation(t *testing.T) {
+	// With an all-zero key the cipher reduces to the bare permutation
+	block, err := NewCipher(make([]byte, KeySize))
+	assert.NoError(t, err)
+	out := make([]byte, BlockSize)
+	block.Encrypt(out, make([]byte, BlockSize))
+	assert.Equal(t, []byte{
+		0x8d, 0xd8, 0xd5, 0x89, 0xbf, 0xfc, 0x63, 0xa9, 0x19, 0x2d, 0x23, 0x1b, 0x14, 0xa0, 0xa5, 0xff,
+		0x06, 0x81, 0xb1, 0x36, 0xfe, 0xc1, 0xc7, 0xaf, 0xbe, 0x7c, 0xe5, 0xae, 0xbd, 0x40, 0x75, 0xa7,
+		0x70, 0xe8, 0x86, 0x2e, 0xc9, 0xb7, 0xf5, 0xfe, 0xf2, 0xad, 0x4f, 0x8b, 0x62, 0x40, 0x4f, 0x5e,
+	}, out)
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for rounds := 1; rounds <= xoodoo.MaxRounds; rounds++ {
+		key := make([]byte, KeySize)
+		rng.Read(key)
+		block, err := NewCipherWithRounds(key, rounds)
+		assert.NoError(t, err)
+		assert.Equal(t, BlockSize, block.BlockSize())
+
+		plaintext := make([]byte, BlockSize)
+		rng.Read(plaintext)
+
+		// E_K(P) = π(P + K) + K
+		var expected xoodoo.State
+		var keyState xoodoo.State
+		expected.UnmarshalBinary(plaintext)
+		keyState.UnmarshalBinary(key)
+		xd, _ := xoodoo.NewXoodoo(rounds, [xoodoo.StateSizeBytes]byte{})
+		xd.State = xoodoo.XorState(expected, keyState)
+		xd.Permutation()
+		expectedState := xoodoo.XorState(xd.State, keyState)
+		expectedBytes, _ := expectedState.MarshalBinary()
+
+		ciphertext := make([]byte, BlockSize)
+		block.Encrypt(ciphertext, plaintext)
+		assert.Equal(t, expectedBytes, ciphertext)
+
+		decrypted := make([]byte, BlockSize)
+		block.Decrypt(decrypted, ciphertext)
+		assert.Equal(t, plaintext, decrypted)
+
+		// in place operation
+		buf := append([]byte{}, plaintext...)
+		block.Encrypt(buf, buf)
+		assert.Equal(t, ciphertext, buf)
+		block.Decrypt(buf, buf)
+		assert.Equal(t, plaintext, buf)
+	}
+}
+
+func TestStandardModes(t *testing.T) {
+	key := bytes.Repeat([]byte{0x5A}, KeySize)
+	iv := bytes.Repeat([]byte{0xA5}, BlockSize)
+	block, _ := NewCipher(key)
+
+	message := []byte("Even-Mansour over Xoodoo used in counter mode from the standard library")
+	ct := make([]byte, len(message))
+	cipher.NewCTR(block, iv).XORKeyStream(ct, message)
+	pt := make([]byte, len(ct))
+	cipher.NewCTR(block, iv).XORKeyStream(pt, ct)
+	assert.Equal(t, message, pt)
+
+	padded := make([]byte, 3*BlockSize)
+	copy(padded, message)
+	cbcCt := make([]byte, len(padded))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(cbcCt, padded)
+	cbcPt := make([]byte, len(padded))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(cbcPt, cbcCt)
+	assert.Equal(t, padded, cbcPt)
+}
+
+var newCipherErrorsTestTable = []struct {
+	key    []byte
+	rounds int
+	err    error
+}{
+	{
+		key:    make([]byte, 16),
+		rounds: 12,
+		err:    errors.New("evenmansour: given key length (16 bytes) incorrect (48 bytes)"),
+	},
+	{
+		key:    make([]byte, KeySize),
+		rounds: 0,
+		err:    errors.New("evenmansour: invalid number of rounds: 0"),
+	},
+	{
+		key:    make([]byte, KeySize),
+		rounds: 13,
+		err:    errors.New("evenmansour: invalid number of rounds: 13"),
+	},
+}
+
+func TestNewCipherErrors(t *testing.T) {
+	for _, tt := range newCipherErrorsTestTable {
+		block, err := NewCipherWithRounds(tt.key, tt.rounds)
+		assert.Nil(t, block)
+		assert.Equal(t, tt.err, err)
+	}
+	block, _ := NewCipher(make([]byte, KeySize))
+	assert.Panics(t, func() { block.Encrypt(make([]byte, BlockSize), make([]byte, BlockSize-1)) })
+	assert.Panics(t, func() { block.Decrypt(make([]byte, BlockSize-1), make([]byte, BlockSize)) })
+}
+
+func TestNoAllocations(t *testing.T) {
+	block, _ := NewCipher(make([]byte, KeySize))
+	buf := make([]byte, BlockSize)
+	allocs := testing.AllocsPerRun(100, func() {
+		block.Encrypt(buf, buf)
+		block.Decrypt(buf, buf)
+	})
+	assert.Equal(t, float64(0), allocs)
+}
+
+func BenchmarkEncrypt(b *testing.B) {
+	block, _ := NewCipher(make([]byte, KeySize))
+	buf := make([]byte, BlockSize)
+	b.SetBytes(BlockSize)
+	for n := 0; n < b.N; n++ {
+		block.Encrypt(buf, buf)
+	}
+}
+
+func BenchmarkDecrypt(b *testing.B) {
+	block, _ := NewCipher(make([]byte, KeySize))
+	buf := make([]byte, BlockSize)
+	b.SetBytes(BlockSize)
+	for n := 0; n < b.N; n++ {
+		block.Decrypt(buf, buf)
+	}
+}
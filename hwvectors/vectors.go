@@ -0,0 +1,183 @@
+// Package hwvectors generates test vectors for verifying hardware implementations of Xoodoo and
+// Xoodyak against this package. Vectors follow the LWC KAT generator: the key, nonce, associated
+// data and message of each vector hold the bytes 0, 1, 2, … and every combination of message and
+// associated data length up to the configured maxima is covered, message length first.
+//
+// Along with the LWC KAT fields, each vector records the full Cyclist transcript of its computation
+// (every Down and Up call with its Cd or Cu byte, absorbed or squeezed block and resulting state)
+// and the state after each round of every permutation call. The writers emit the transcripts and
+// round states as hex files readable by the Verilog $readmemh task, or as CSV.
+package hwvectors
+
+import (
+	"fmt"
+
+	"github.com/inmcm/xoodoo/xoodoo"
+	"github.com/inmcm/xoodoo/xoodyak"
+)
+
+// maxKeyNonceLen is the largest combined key and nonce length accepted by Xoodyak
+const maxKeyNonceLen = 43
+
+// Config holds the parameters of the generated vectors
+type Config struct {
+	// KeyLen is the length of the AEAD key in bytes
+	KeyLen int
+	// NonceLen is the length of the AEAD nonce in bytes
+	NonceLen int
+	// TagLen is the length of the AEAD tag in bytes
+	TagLen int
+	// DigestLen is the length of the hash digest in bytes
+	DigestLen int
+	// MaxMessageLen is the largest message (plaintext or hash input) length in bytes
+	MaxMessageLen int
+	// MaxADLen is the largest associated data length in bytes
+	MaxADLen int
+}
+
+// DefaultAEADConfig describes the vectors of the LWC AEAD KAT file LWC_AEAD_KAT_128_128.txt
+var DefaultAEADConfig = Config{KeyLen: 16, NonceLen: 16, TagLen: 16, MaxMessageLen: 32, MaxADLen: 32}
+
+// DefaultHashConfig describes the vectors of the LWC hash KAT file LWC_HASH_KAT_256.txt
+var DefaultHashConfig = Config{DigestLen: 32, MaxMessageLen: 1024}
+
+func (c Config) validateAEAD() error {
+	if c.KeyLen < 1 || c.NonceLen < 0 || c.KeyLen+c.NonceLen > maxKeyNonceLen {
+		return fmt.Errorf("hwvectors: invalid key length (%d bytes) and nonce length (%d bytes)", c.KeyLen, c.NonceLen)
+	}
+	if c.TagLen < 1 {
+		return fmt.Errorf("hwvectors: invalid tag length (%d bytes)", c.TagLen)
+	}
+	if c.MaxADLen < 0 {
+		return fmt.Errorf("hwvectors: invalid associated data length (%d bytes)", c.MaxADLen)
+	}
+	return c.validateMessage()
+}
+
+func (c Config) validateHash() error {
+	if c.DigestLen < 1 {
+		return fmt.Errorf("hwvectors: invalid digest length (%d bytes)", c.DigestLen)
+	}
+	return c.validateMessage()
+}
+
+func (c Config) validateMessage() error {
+	if c.MaxMessageLen < 0 {
+		return fmt.Errorf("hwvectors: invalid message length (%d bytes)", c.MaxMessageLen)
+	}
+	return nil
+}
+
+// Entry is a single Down or Up call of a Cyclist transcript
+type Entry struct {
+	// Phase is Down or Up
+	Phase xoodyak.CyclistPhase
+	// Control is the Cd or Cu byte added to the last byte of the state, which is zero for Up and
+	// only the low bit of Cd for Down in hash mode
+	Control byte
+	// Block is the block absorbed by Down or squeezed by Up
+	Block []byte
+	// State is the state after the call
+	State xoodoo.State
+	// Rounds holds, for Up, the input of the permutation followed by the state after each of its
+	// rounds. It is nil for Down.
+	Rounds []xoodoo.State
+}
+
+// Transcript records the Down and Up calls of a Xoodyak instance
+type Transcript struct {
+	Entries []Entry
+	// rounds collects the states of the permutation call in progress
+	rounds []xoodoo.State
+}
+
+// Attach installs tracers on xk that record its subsequent Down and Up calls and the round states
+// of its permutation calls in the transcript
+func (t *Transcript) Attach(xk *xoodyak.Xoodyak) {
+	xk.Instance.SetTracer(func(round int, step xoodoo.Step, state xoodoo.State) {
+		if step == xoodoo.StepInput || step == xoodoo.StepRhoEast {
+			t.rounds = append(t.rounds, state)
+		}
+	})
+	xk.SetTracer(func(phase xoodyak.CyclistPhase, control byte, block []byte, state xoodoo.State) {
+		e := Entry{Phase: phase, Control: control, Block: block, State: state}
+		if phase == xoodyak.Up {
+			e.Rounds, t.rounds = t.rounds, nil
+		}
+		t.Entries = append(t.Entries, e)
+	})
+}
+
+// Vector is a single test vector
+type Vector struct {
+	// Count numbers the vectors from 1, as in the LWC KAT files
+	Count int
+	// Mode is xoodyak.Keyed for AEAD vectors and xoodyak.Hash for hash vectors
+	Mode xoodyak.CyclistMode
+	// Key and Nonce are empty for hash vectors
+	Key, Nonce []byte
+	// AD is the associated data, empty for hash vectors
+	AD []byte
+	// Message is the plaintext or hash input
+	Message []byte
+	// Output is the ciphertext followed by the tag, or the digest
+	Output []byte
+	// Transcript holds the Cyclist calls made to compute the output
+	Transcript Transcript
+}
+
+// sequence returns n bytes holding 0, 1, 2, …
+func sequence(n int) []byte {
+	out := make([]byte, n)
+	for i := range out {
+		out[i] = byte(i)
+	}
+	return out
+}
+
+// AEADVectors returns the AEAD vectors described by the configuration, encrypting each message with
+// the LWC Xoodyak AEAD scheme for every associated data length
+func AEADVectors(cfg Config) ([]Vector, error) {
+	if err := cfg.validateAEAD(); err != nil {
+		return nil, err
+	}
+	var vectors []Vector
+	for mLen := 0; mLen <= cfg.MaxMessageLen; mLen++ {
+		for adLen := 0; adLen <= cfg.MaxADLen; adLen++ {
+			v := Vector{
+				Count:   len(vectors) + 1,
+				Mode:    xoodyak.Keyed,
+				Key:     sequence(cfg.KeyLen),
+				Nonce:   sequence(cfg.NonceLen),
+				AD:      sequence(adLen),
+				Message: sequence(mLen),
+			}
+			xk := xoodyak.Instantiate(nil, nil, nil)
+			v.Transcript.Attach(xk)
+			xk.AbsorbKey(v.Key, v.Nonce, nil)
+			xk.Absorb(v.AD)
+			v.Output = xk.Encrypt(v.Message)
+			v.Output = append(v.Output, xk.Squeeze(uint(cfg.TagLen))...)
+			vectors = append(vectors, v)
+		}
+	}
+	return vectors, nil
+}
+
+// HashVectors returns the hash vectors described by the configuration, hashing each message with
+// the LWC Xoodyak hash scheme
+func HashVectors(cfg Config) ([]Vector, error) {
+	if err := cfg.validateHash(); err != nil {
+		return nil, err
+	}
+	var vectors []Vector
+	for mLen := 0; mLen <= cfg.MaxMessageLen; mLen++ {
+		v := Vector{Count: mLen + 1, Mode: xoodyak.Hash, Message: sequence(mLen)}
+		xk := xoodyak.Instantiate(nil, nil, nil)
+		v.Transcript.Attach(xk)
+		xk.Absorb(v.Message)
+		v.Output = xk.Squeeze(uint(cfg.DigestLen))
+		vectors = append(vectors, v)
+	}
+	return vectors, nil
+}
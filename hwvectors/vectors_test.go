@@ -0,0 +1,136 @@
+package hwvectors
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/inmcm/xoodoo/xoodoo"
+	"github.com/inmcm/xoodoo/xoodyak"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		hash    bool
+		wantErr error
+	}{
+		{name: "DefaultAEAD", cfg: DefaultAEADConfig},
+		{name: "DefaultHash", cfg: DefaultHashConfig, hash: true},
+		{name: "NoKey", cfg: Config{NonceLen: 16, TagLen: 16},
+			wantErr: errors.New("hwvectors: invalid key length (0 bytes) and nonce length (16 bytes)")},
+		{name: "LongNonce", cfg: Config{KeyLen: 16, NonceLen: 28, TagLen: 16},
+			wantErr: errors.New("hwvectors: invalid key length (16 bytes) and nonce length (28 bytes)")},
+		{name: "NoTag", cfg: Config{KeyLen: 16, NonceLen: 16},
+			wantErr: errors.New("hwvectors: invalid tag length (0 bytes)")},
+		{name: "NegativeAD", cfg: Config{KeyLen: 16, NonceLen: 16, TagLen: 16, MaxADLen: -1},
+			wantErr: errors.New("hwvectors: invalid associated data length (-1 bytes)")},
+		{name: "NegativeMessage", cfg: Config{KeyLen: 16, NonceLen: 16, TagLen: 16, MaxMessageLen: -1},
+			wantErr: errors.New("hwvectors: invalid message length (-1 bytes)")},
+		{name: "NoDigest", cfg: Config{MaxMessageLen: 4}, hash: true,
+			wantErr: errors.New("hwvectors: invalid digest length (0 bytes)")},
+		{name: "NegativeHashMessage", cfg: Config{DigestLen: 32, MaxMessageLen: -1}, hash: true,
+			wantErr: errors.New("hwvectors: invalid message length (-1 bytes)")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			if tt.hash {
+				err = tt.cfg.validateHash()
+			} else {
+				err = tt.cfg.validateAEAD()
+			}
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestAEADVectors(t *testing.T) {
+	cfg := Config{KeyLen: 16, NonceLen: 16, TagLen: 16, MaxMessageLen: 50, MaxADLen: 50}
+	vectors, err := AEADVectors(cfg)
+	assert.NoError(t, err)
+	assert.Len(t, vectors, 51*51)
+	for i, v := range vectors {
+		assert.Equal(t, i+1, v.Count)
+		assert.Equal(t, xoodyak.Keyed, v.Mode)
+		assert.Len(t, v.Message, i/51)
+		assert.Len(t, v.AD, i%51)
+		ct, tag, err := xoodyak.CryptoEncryptAEAD(v.Message, v.Key, v.Nonce, v.AD)
+		assert.NoError(t, err)
+		assert.Equal(t, append(ct, tag...), v.Output)
+		checkTranscript(t, v)
+	}
+
+	_, err = AEADVectors(Config{})
+	assert.Error(t, err)
+}
+
+func TestHashVectors(t *testing.T) {
+	vectors, err := HashVectors(Config{DigestLen: 32, MaxMessageLen: 100})
+	assert.NoError(t, err)
+	assert.Len(t, vectors, 101)
+	for i, v := range vectors {
+		assert.Equal(t, i+1, v.Count)
+		assert.Equal(t, xoodyak.Hash, v.Mode)
+		assert.Empty(t, v.Key)
+		assert.Equal(t, xoodyak.HashXoodyak(v.Message), v.Output)
+		checkTranscript(t, v)
+	}
+
+	_, err = HashVectors(Config{})
+	assert.Error(t, err)
+}
+
+// checkTranscript replays the transcript of the vector, checking that every recorded state follows
+// from the previous one and that the output ends with the last squeezed block
+func checkTranscript(t *testing.T, v Vector) {
+	t.Helper()
+	xd, _ := xoodoo.NewXoodoo(xoodoo.MaxRounds, [xoodoo.StateSizeBytes]byte{})
+	for i, e := range v.Transcript.Entries {
+		switch e.Phase {
+		case xoodyak.Down:
+			fill := make([]byte, xoodoo.StateSizeBytes)
+			copy(fill, e.Block)
+			fill[len(e.Block)] = 0x01
+			fill[len(fill)-1] = e.Control
+			xd.State.XorStateBytes(fill)
+			assert.Nil(t, e.Rounds)
+		case xoodyak.Up:
+			xd.State.XorByte(e.Control, xoodoo.StateSizeBytes-1)
+			assert.Len(t, e.Rounds, xoodoo.MaxRounds+1)
+			assert.Equal(t, xd.State, e.Rounds[0])
+			for r := 1; r < len(e.Rounds); r++ {
+				s := e.Rounds[r-1]
+				s.Round(xoodoo.MaxRounds - len(e.Rounds) + r)
+				assert.Equal(t, e.Rounds[r], s)
+			}
+			xd.Permutation()
+			assert.Equal(t, stateBytes(xd.State)[:len(e.Block)], e.Block)
+		}
+		assert.Equal(t, xd.State, e.State, "vector %d entry %d", v.Count, i)
+	}
+	last := v.Transcript.Entries[len(v.Transcript.Entries)-1]
+	assert.Equal(t, xoodyak.Up, last.Phase)
+	assert.Equal(t, last.Block, v.Output[len(v.Output)-len(last.Block):])
+}
+
+func TestTranscriptAttach(t *testing.T) {
+	var tr Transcript
+	xk := xoodyak.Instantiate(nil, nil, nil)
+	tr.Attach(xk)
+	xk.AbsorbKey([]byte{1, 2, 3, 4}, nil, nil)
+	xk.Squeeze(4)
+	assert.Len(t, tr.Entries, 2)
+	assert.Equal(t, Entry{
+		Phase:   xoodyak.Down,
+		Control: 0x02,
+		Block:   []byte{1, 2, 3, 4, 0},
+		State:   xoodoo.State{0x04030201, 0x0100, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x02000000},
+	}, tr.Entries[0])
+	assert.Equal(t, xoodyak.SqueezeCuInit, tr.Entries[1].Control)
+	assert.Len(t, tr.Entries[1].Rounds, xoodoo.MaxRounds+1)
+	input := tr.Entries[0].State
+	input[11] ^= 0x40000000
+	assert.Equal(t, input, tr.Entries[1].Rounds[0])
+}
@@ -0,0 +1,169 @@
+package hwvectors
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/inmcm/xoodoo/xoodoo"
+	"github.com/inmcm/xoodoo/xoodyak"
+)
+
+// errWriter formats to an underlying writer, keeping the first error
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (ew *errWriter) printf(format string, args ...interface{}) {
+	if ew.err != nil {
+		return
+	}
+	_, ew.err = fmt.Fprintf(ew.w, format, args...)
+}
+
+// WriteKAT writes the vectors to w in the format of the LWC KAT files
+func WriteKAT(w io.Writer, vectors []Vector) error {
+	ew := &errWriter{w: w}
+	for _, v := range vectors {
+		ew.printf("Count = %d\n", v.Count)
+		if v.Mode == xoodyak.Hash {
+			ew.printf("Msg = %X\n", v.Message)
+			ew.printf("MD = %X\n\n", v.Output)
+			continue
+		}
+		ew.printf("Key = %X\n", v.Key)
+		ew.printf("Nonce = %X\n", v.Nonce)
+		ew.printf("PT = %X\n", v.Message)
+		ew.printf("AD = %X\n", v.AD)
+		ew.printf("CT = %X\n\n", v.Output)
+	}
+	return ew.err
+}
+
+// stateBytes returns the state as bytes, in the order used by the Cyclist interface
+func stateBytes(s xoodoo.State) []byte {
+	buf, _ := s.MarshalBinary()
+	return buf
+}
+
+// memhState returns the state as a 384-bit hex word, with byte i of the state in bits 8i+7 to 8i
+func memhState(s xoodoo.State) string {
+	return memhBlock(stateBytes(s))
+}
+
+// memhBlock returns the block zero-padded to a 384-bit hex word, with byte i of the block in bits
+// 8i+7 to 8i
+func memhBlock(block []byte) string {
+	var b strings.Builder
+	for i := xoodoo.StateSizeBytes - 1; i >= 0; i-- {
+		var v byte
+		if i < len(block) {
+			v = block[i]
+		}
+		fmt.Fprintf(&b, "%02X", v)
+	}
+	return b.String()
+}
+
+// WriteTranscriptMemh writes the Cyclist transcripts of the vectors to w as a $readmemh file with
+// one 792-bit word per Down or Up call, preceded by a comment line giving the vector count. From
+// the most significant end, a word holds the phase (8 bits, 1 for Down and 2 for Up), the Cd or Cu
+// byte (8 bits), the block length in bytes (8 bits), the zero-padded block (384 bits) and the
+// resulting state (384 bits). Bytes of the block and state are numbered from the least significant
+// end, so that byte i of the state occupies bits 8i+7 to 8i of its field.
+func WriteTranscriptMemh(w io.Writer, vectors []Vector) error {
+	ew := &errWriter{w: w}
+	for _, v := range vectors {
+		ew.printf("// Count = %d\n", v.Count)
+		for _, e := range v.Transcript.Entries {
+			ew.printf("%02X%02X%02X%s%s\n", int(e.Phase), e.Control, len(e.Block), memhBlock(e.Block), memhState(e.State))
+		}
+	}
+	return ew.err
+}
+
+// WriteRoundsMemh writes the round states of the vectors to w as a $readmemh file with one 384-bit
+// word per state, laid out as in WriteTranscriptMemh. Each permutation call is preceded by a
+// comment line giving the vector count and the index of the call within the vector, and gives its
+// input followed by the state after each round.
+func WriteRoundsMemh(w io.Writer, vectors []Vector) error {
+	ew := &errWriter{w: w}
+	for _, v := range vectors {
+		for p, rounds := range v.permutations() {
+			ew.printf("// Count = %d, Permutation = %d\n", v.Count, p)
+			for _, s := range rounds {
+				ew.printf("%s\n", memhState(s))
+			}
+		}
+	}
+	return ew.err
+}
+
+// permutations returns the round states of each permutation call of the vector
+func (v Vector) permutations() [][]xoodoo.State {
+	var out [][]xoodoo.State
+	for _, e := range v.Transcript.Entries {
+		if e.Phase == xoodyak.Up {
+			out = append(out, e.Rounds)
+		}
+	}
+	return out
+}
+
+// phaseName returns the name of the Cyclist phase
+func phaseName(p xoodyak.CyclistPhase) string {
+	switch p {
+	case xoodyak.Down:
+		return "Down"
+	case xoodyak.Up:
+		return "Up"
+	}
+	return strconv.Itoa(int(p))
+}
+
+// WriteTranscriptCSV writes the Cyclist transcripts of the vectors to w as CSV, with a header line
+// followed by one record per Down or Up call. Blocks and states are given in hex in byte order.
+func WriteTranscriptCSV(w io.Writer, vectors []Vector) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"count", "index", "phase", "control", "length", "block", "state"})
+	for _, v := range vectors {
+		for i, e := range v.Transcript.Entries {
+			cw.Write([]string{
+				strconv.Itoa(v.Count),
+				strconv.Itoa(i),
+				phaseName(e.Phase),
+				fmt.Sprintf("%02X", e.Control),
+				strconv.Itoa(len(e.Block)),
+				fmt.Sprintf("%X", e.Block),
+				fmt.Sprintf("%X", stateBytes(e.State)),
+			})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteRoundsCSV writes the round states of the vectors to w as CSV, with a header line followed by
+// one record per state. Round 0 is the input of the permutation call and round r the state after
+// r rounds. States are given in hex in byte order.
+func WriteRoundsCSV(w io.Writer, vectors []Vector) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"count", "permutation", "round", "state"})
+	for _, v := range vectors {
+		for p, rounds := range v.permutations() {
+			for r, s := range rounds {
+				cw.Write([]string{
+					strconv.Itoa(v.Count),
+					strconv.Itoa(p),
+					strconv.Itoa(r),
+					fmt.Sprintf("%X", stateBytes(s)),
+				})
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
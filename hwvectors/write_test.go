@@ -0,0 +1,158 @@
+package hwvectors
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/inmcm/xoodoo/xoodoo"
+	"github.com/inmcm/xoodoo/xoodyak"
+	"github.com/stretchr/testify/assert"
+)
+
+// failingWriter fails every write
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestWriteKAT(t *testing.T) {
+	tests := []struct {
+		name     string
+		generate func(Config) ([]Vector, error)
+		cfg      Config
+		file     string
+	}{
+		{name: "AEAD", generate: AEADVectors, cfg: DefaultAEADConfig, file: "../xoodyak/LWC_AEAD_KAT_128_128.txt"},
+		{name: "Hash", generate: HashVectors, cfg: DefaultHashConfig, file: "../xoodyak/LWC_HASH_KAT_256.txt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if testing.Short() && tt.name == "Hash" {
+				t.Skip("skipping full hash KAT in short mode")
+			}
+			want, err := ioutil.ReadFile(tt.file)
+			assert.NoError(t, err)
+			vectors, err := tt.generate(tt.cfg)
+			assert.NoError(t, err)
+			var got bytes.Buffer
+			assert.NoError(t, WriteKAT(&got, vectors))
+			assert.Equal(t, string(want), got.String())
+		})
+	}
+}
+
+func TestMemhWords(t *testing.T) {
+	var s xoodoo.State
+	s[0] = 0x03020100
+	s[11] = 0x2F2E2D2C
+	assert.Equal(t, "2F2E2D2C"+strings.Repeat("0", 80)+"03020100", memhState(s))
+	assert.Equal(t, strings.Repeat("0", 90)+"CCBBAA", memhBlock([]byte{0xAA, 0xBB, 0xCC}))
+	assert.Equal(t, strings.Repeat("0", 96), memhBlock(nil))
+}
+
+func TestWriteTranscriptMemh(t *testing.T) {
+	vectors, err := HashVectors(Config{DigestLen: 32, MaxMessageLen: 1})
+	assert.NoError(t, err)
+	var b bytes.Buffer
+	assert.NoError(t, WriteTranscriptMemh(&b, vectors))
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	// Each vector absorbs one block and squeezes two
+	assert.Len(t, lines, 2*5)
+	assert.Equal(t, "// Count = 1", lines[0])
+	assert.Equal(t, "// Count = 2", lines[5])
+	for i, line := range append(lines[1:5:5], lines[6:]...) {
+		e := vectors[i/4].Transcript.Entries[i%4]
+		assert.Len(t, line, 198)
+		assert.Equal(t, memhBlock(e.Block)+memhState(e.State), line[6:])
+	}
+	// The second vector absorbs the single byte 00 with Cd = 0x03 masked to 0x01, then squeezes
+	// the digest 16 bytes at a time with Cu omitted
+	assert.Equal(t, []string{"010101", "020010", "010000", "020010"},
+		[]string{lines[6][:6], lines[7][:6], lines[8][:6], lines[9][:6]})
+
+	assert.Equal(t, errors.New("write failed"), WriteTranscriptMemh(failingWriter{}, vectors))
+}
+
+func TestWriteRoundsMemh(t *testing.T) {
+	vectors, err := AEADVectors(Config{KeyLen: 16, NonceLen: 16, TagLen: 16})
+	assert.NoError(t, err)
+	var b bytes.Buffer
+	assert.NoError(t, WriteRoundsMemh(&b, vectors))
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	perms := vectors[0].permutations()
+	assert.Len(t, lines, len(perms)*(xoodoo.MaxRounds+2))
+	for p, rounds := range perms {
+		block := lines[p*(xoodoo.MaxRounds+2):]
+		assert.Equal(t, fmt.Sprintf("// Count = 1, Permutation = %d", p), block[0])
+		for r, s := range rounds {
+			assert.Equal(t, memhState(s), block[r+1])
+		}
+	}
+
+	assert.Equal(t, errors.New("write failed"), WriteRoundsMemh(failingWriter{}, vectors))
+}
+
+func TestWriteTranscriptCSV(t *testing.T) {
+	vectors, err := AEADVectors(Config{KeyLen: 16, NonceLen: 16, TagLen: 16, MaxMessageLen: 1, MaxADLen: 1})
+	assert.NoError(t, err)
+	var b bytes.Buffer
+	assert.NoError(t, WriteTranscriptCSV(&b, vectors))
+	records, err := csv.NewReader(&b).ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"count", "index", "phase", "control", "length", "block", "state"}, records[0])
+	records = records[1:]
+	for _, v := range vectors {
+		for i, e := range v.Transcript.Entries {
+			assert.Equal(t, []string{
+				strconv.Itoa(v.Count), strconv.Itoa(i), phaseName(e.Phase),
+				fmt.Sprintf("%02X", e.Control), strconv.Itoa(len(e.Block)), fmt.Sprintf("%X", e.Block),
+			}, records[0][:6])
+			assert.Equal(t, memhState(e.State), reverseHex(records[0][6]))
+			records = records[1:]
+		}
+	}
+	assert.Empty(t, records)
+
+	assert.Error(t, WriteTranscriptCSV(failingWriter{}, vectors))
+}
+
+func TestWriteRoundsCSV(t *testing.T) {
+	vectors, err := HashVectors(Config{DigestLen: 16})
+	assert.NoError(t, err)
+	var b bytes.Buffer
+	assert.NoError(t, WriteRoundsCSV(&b, vectors))
+	records, err := csv.NewReader(&b).ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"count", "permutation", "round", "state"}, records[0])
+	rounds := vectors[0].permutations()[0]
+	assert.Len(t, records, 1+len(rounds))
+	for r, s := range rounds {
+		assert.Equal(t, "1", records[1+r][0])
+		assert.Equal(t, "0", records[1+r][1])
+		assert.Equal(t, memhState(s), reverseHex(records[1+r][3]))
+	}
+
+	assert.Error(t, WriteRoundsCSV(failingWriter{}, vectors))
+}
+
+// reverseHex reverses the order of the bytes of a hex string
+func reverseHex(s string) string {
+	var b strings.Builder
+	for i := len(s) - 2; i >= 0; i -= 2 {
+		b.WriteString(s[i : i+2])
+	}
+	return b.String()
+}
+
+func TestPhaseName(t *testing.T) {
+	assert.Equal(t, "Down", phaseName(xoodyak.Down))
+	assert.Equal(t, "Up", phaseName(xoodyak.Up))
+	assert.Equal(t, "7", phaseName(7))
+}
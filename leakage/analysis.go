@@ -0,0 +1,176 @@
+package leakage
+
+import (
+	"fmt"
+	"math"
+)
+
+// TVLAThreshold is the absolute t value above which TVLA considers a sample to leak
+const TVLAThreshold = 4.5
+
+// Correlation returns the Pearson correlation between the predictions, one per trace, and each
+// sample of the traces. Samples or predictions with no variance have a correlation of zero.
+func Correlation(traces [][]float64, predictions []float64) ([]float64, error) {
+	if len(traces) != len(predictions) {
+		return nil, fmt.Errorf("leakage: %d predictions for %d traces", len(predictions), len(traces))
+	}
+	if len(traces) < 2 {
+		return nil, fmt.Errorf("leakage: correlation needs at least 2 traces, got %d", len(traces))
+	}
+	n := float64(len(traces))
+	samples := len(traces[0])
+	var sumP, sumPP float64
+	for _, p := range predictions {
+		sumP += p
+		sumPP += p * p
+	}
+	varP := sumPP - sumP*sumP/n
+	sumT := make([]float64, samples)
+	sumTT := make([]float64, samples)
+	sumPT := make([]float64, samples)
+	for i, trace := range traces {
+		for j, v := range trace {
+			sumT[j] += v
+			sumTT[j] += v * v
+			sumPT[j] += predictions[i] * v
+		}
+	}
+	out := make([]float64, samples)
+	for j := range out {
+		varT := sumTT[j] - sumT[j]*sumT[j]/n
+		if varP <= 0 || varT <= 0 {
+			continue
+		}
+		out[j] = (sumPT[j] - sumP*sumT[j]/n) / math.Sqrt(varP*varT)
+	}
+	return out, nil
+}
+
+// CPAResult holds the outcome of a correlation power analysis
+type CPAResult struct {
+	// Peaks holds, for each key hypothesis, the largest correlation over all samples
+	Peaks []float64
+	// Samples holds, for each key hypothesis, the sample at which its peak was found
+	Samples []int
+}
+
+// Best returns the key hypothesis with the largest peak correlation
+func (r CPAResult) Best() int {
+	best := 0
+	for h, p := range r.Peaks {
+		if p > r.Peaks[best] {
+			best = h
+		}
+	}
+	return best
+}
+
+// Rank returns the number of key hypotheses whose peak correlation exceeds that of hypothesis h,
+// so that the best hypothesis has rank 0
+func (r CPAResult) Rank(h int) int {
+	rank := 0
+	for _, p := range r.Peaks {
+		if p > r.Peaks[h] {
+			rank++
+		}
+	}
+	return rank
+}
+
+// CPA applies correlation power analysis to the trace set. For each of the key hypotheses from 0
+// to hypotheses-1, predict gives the leakage expected for the input of each trace, and the
+// hypothesis is scored by the largest correlation between its predictions and a sample. The
+// correlation is signed, as leakage increasing with the Hamming weight of the predicted value
+// correlates positively with the correct hypothesis and negatively with its complement.
+func CPA(ts *TraceSet, hypotheses int, predict func(input []byte, hypothesis int) float64) (CPAResult, error) {
+	if hypotheses < 1 {
+		return CPAResult{}, fmt.Errorf("leakage: invalid number of hypotheses: %d", hypotheses)
+	}
+	result := CPAResult{Peaks: make([]float64, hypotheses), Samples: make([]int, hypotheses)}
+	predictions := make([]float64, ts.Len())
+	for h := 0; h < hypotheses; h++ {
+		for i, input := range ts.Inputs {
+			predictions[i] = predict(input, h)
+		}
+		corr, err := Correlation(ts.Traces, predictions)
+		if err != nil {
+			return CPAResult{}, err
+		}
+		result.Peaks[h] = math.Inf(-1)
+		for j, c := range corr {
+			if c > result.Peaks[h] {
+				result.Peaks[h], result.Samples[h] = c, j
+			}
+		}
+	}
+	return result, nil
+}
+
+// WelchT returns Welch's t statistic comparing each sample of two groups of traces
+func WelchT(a, b [][]float64) ([]float64, error) {
+	if len(a) < 2 || len(b) < 2 {
+		return nil, fmt.Errorf("leakage: t-test needs at least 2 traces per group, got %d and %d", len(a), len(b))
+	}
+	meanVar := func(traces [][]float64, j int) (float64, float64) {
+		var sum, sumSq float64
+		for _, trace := range traces {
+			sum += trace[j]
+			sumSq += trace[j] * trace[j]
+		}
+		n := float64(len(traces))
+		mean := sum / n
+		return mean, (sumSq - n*mean*mean) / (n - 1)
+	}
+	out := make([]float64, len(a[0]))
+	for j := range out {
+		meanA, varA := meanVar(a, j)
+		meanB, varB := meanVar(b, j)
+		den := math.Sqrt(varA/float64(len(a)) + varB/float64(len(b)))
+		if den == 0 {
+			continue
+		}
+		out[j] = (meanA - meanB) / den
+	}
+	return out, nil
+}
+
+// TVLAResult holds the t statistic of each sample of a fixed versus random test
+type TVLAResult struct {
+	T []float64
+}
+
+// MaxAbs returns the largest absolute t value and the sample at which it was found
+func (r TVLAResult) MaxAbs() (float64, int) {
+	max, at := 0.0, 0
+	for j, t := range r.T {
+		if math.Abs(t) > max {
+			max, at = math.Abs(t), j
+		}
+	}
+	return max, at
+}
+
+// Leaks returns the samples whose absolute t value exceeds the threshold
+func (r TVLAResult) Leaks(threshold float64) []int {
+	var out []int
+	for j, t := range r.T {
+		if math.Abs(t) > threshold {
+			out = append(out, j)
+		}
+	}
+	return out
+}
+
+// TVLA applies the first-order fixed versus random t-test of the Test Vector Leakage Assessment
+// methodology to trace sets acquired on a fixed input and on random inputs. A device leaks when
+// some sample has an absolute t value above TVLAThreshold.
+func TVLA(fixed, random *TraceSet) (TVLAResult, error) {
+	if fixed.Samples() != random.Samples() {
+		return TVLAResult{}, fmt.Errorf("leakage: trace lengths %d and %d differ", fixed.Samples(), random.Samples())
+	}
+	t, err := WelchT(fixed.Traces, random.Traces)
+	if err != nil {
+		return TVLAResult{}, err
+	}
+	return TVLAResult{T: t}, nil
+}
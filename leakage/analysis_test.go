@@ -0,0 +1,109 @@
+package leakage
+
+import (
+	"errors"
+	"math"
+	"math/bits"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCorrelation(t *testing.T) {
+	traces := [][]float64{{1, 4, 2}, {2, 3, 2}, {3, 2, 2}, {4, 1, 2}}
+	corr, err := Correlation(traces, []float64{10, 20, 30, 40})
+	assert.NoError(t, err)
+	assert.InDelta(t, 1, corr[0], 1e-12)
+	assert.InDelta(t, -1, corr[1], 1e-12)
+	assert.Equal(t, 0.0, corr[2])
+
+	corr, err = Correlation(traces, []float64{5, 5, 5, 5})
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{0, 0, 0}, corr)
+
+	_, err = Correlation(traces, []float64{1})
+	assert.Equal(t, errors.New("leakage: 1 predictions for 4 traces"), err)
+	_, err = Correlation(traces[:1], []float64{1})
+	assert.Equal(t, errors.New("leakage: correlation needs at least 2 traces, got 1"), err)
+}
+
+func TestCPA(t *testing.T) {
+	// A synthetic device leaking the Hamming weight of key ^ input among samples of pure noise
+	const key = 0x3C
+	rnd := rand.New(rand.NewSource(1))
+	ts := &TraceSet{}
+	for i := 0; i < 300; i++ {
+		input := []byte{byte(rnd.Intn(256))}
+		ts.Add(input, []float64{rnd.NormFloat64(), float64(bits.OnesCount8(key^input[0])) + rnd.NormFloat64(), rnd.NormFloat64()})
+	}
+	predict := func(input []byte, h int) float64 {
+		return float64(bits.OnesCount8(byte(h) ^ input[0]))
+	}
+	result, err := CPA(ts, 256, predict)
+	assert.NoError(t, err)
+	assert.Equal(t, key, result.Best())
+	assert.Equal(t, 0, result.Rank(key))
+	assert.Equal(t, 1, result.Samples[key])
+	assert.True(t, result.Peaks[key] > 0.5)
+	// The complement of the key correlates negatively with the leaking sample, so its peak is found
+	// among the noise
+	assert.True(t, result.Peaks[key^0xFF] < 0.3)
+
+	_, err = CPA(ts, 0, predict)
+	assert.Equal(t, errors.New("leakage: invalid number of hypotheses: 0"), err)
+	_, err = CPA(&TraceSet{}, 1, predict)
+	assert.Error(t, err)
+}
+
+func TestWelchT(t *testing.T) {
+	a := [][]float64{{1, 0}, {2, 0}, {3, 0}, {4, 0}}
+	b := [][]float64{{2, 0}, {4, 0}, {6, 0}, {8, 0}}
+	got, err := WelchT(a, b)
+	assert.NoError(t, err)
+	assert.InDelta(t, -math.Sqrt(3), got[0], 1e-12)
+	assert.Equal(t, 0.0, got[1])
+
+	_, err = WelchT(a[:1], b)
+	assert.Equal(t, errors.New("leakage: t-test needs at least 2 traces per group, got 1 and 4"), err)
+}
+
+func TestTVLAResult(t *testing.T) {
+	r := TVLAResult{T: []float64{1, -6, 4.5, 5}}
+	max, at := r.MaxAbs()
+	assert.Equal(t, 6.0, max)
+	assert.Equal(t, 1, at)
+	assert.Equal(t, []int{1, 3}, r.Leaks(TVLAThreshold))
+}
+
+func TestTVLA(t *testing.T) {
+	key := make([]byte, 16)
+	rand.New(rand.NewSource(1)).Read(key)
+	tests := []struct {
+		name   string
+		target Target
+		leaks  bool
+	}{
+		{name: "Unmasked", target: XoodyakTarget(key), leaks: true},
+		{name: "Masked", target: MaskedTarget(key, 1, rand.New(rand.NewSource(2)))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if testing.Short() && !tt.leaks {
+				t.Skip("skipping full masked assessment in short mode")
+			}
+			sim, _ := NewSimulator(HammingWeight, 2, 3)
+			fixed, err := Acquire(tt.target, sim, make([][]byte, 500))
+			assert.NoError(t, err)
+			random, err := Acquire(tt.target, sim, randomInputs(rand.New(rand.NewSource(4)), 500, 16))
+			assert.NoError(t, err)
+			result, err := TVLA(fixed, random)
+			assert.NoError(t, err)
+			max, _ := result.MaxAbs()
+			assert.Equal(t, tt.leaks, max > TVLAThreshold, "max |t| = %g", max)
+		})
+	}
+
+	_, err := TVLA(&TraceSet{Traces: [][]float64{{1}}}, &TraceSet{Traces: [][]float64{{1, 2}}})
+	assert.Equal(t, errors.New("leakage: trace lengths 1 and 2 differ"), err)
+}
@@ -0,0 +1,165 @@
+// Package leakage simulates the power consumption of Xoodoo and Xoodyak implementations and
+// analyses the simulated traces, to evaluate whether an implementation strategy leaks its key.
+//
+// A Simulator turns each intermediate 32-bit word of a computation into one sample of a trace,
+// following a Hamming weight or Hamming distance leakage model with optional Gaussian noise. It
+// observes the Xoodoo permutation through its Tracer, the Xoodyak Cyclist Down and Up calls through
+// the CyclistTracer and the masked permutation of package masked through its Tracer. Every step of
+// the permutation leaks the twelve lanes of the state, each share of a masked state separately, and
+// θ also leaks the four lanes of the column parity plane it computes.
+//
+// Traces are collected in a TraceSet by running a Target on many public inputs. CPA applies
+// correlation power analysis to recover key bytes from a trace set, and TVLA applies the Welch
+// t-test of the Test Vector Leakage Assessment methodology to a fixed and a random trace set.
+package leakage
+
+import (
+	"fmt"
+	"math/bits"
+	"math/rand"
+
+	"github.com/inmcm/xoodoo/masked"
+	"github.com/inmcm/xoodoo/xoodoo"
+	"github.com/inmcm/xoodoo/xoodyak"
+)
+
+// Model selects how an intermediate word leaks
+type Model int
+
+const (
+	// HammingWeight leaks the number of bits set in each word
+	HammingWeight Model = iota + 1
+	// HammingDistance leaks the number of bits that differ between each word and the previous
+	// value of the register holding it, as in CMOS circuits
+	HammingDistance
+)
+
+func (m Model) String() string {
+	switch m {
+	case HammingWeight:
+		return "HammingWeight"
+	case HammingDistance:
+		return "HammingDistance"
+	}
+	return fmt.Sprintf("Model(%d)", int(m))
+}
+
+const (
+	// registersPerShare is the number of registers of each share: the twelve lanes of the state
+	// followed by the four lanes of the column parity plane
+	registersPerShare = 16
+	// outputRegisters is the first of the registers holding the words of an Up output block
+	outputRegisters = 1 << 16
+)
+
+// Simulator records the leakage of intermediate words into a trace
+type Simulator struct {
+	// Model is the leakage model
+	Model Model
+	// Noise is the standard deviation of the Gaussian noise added to every sample
+	Noise float64
+	// MaxSamples limits the length of each trace, as the capture window of an oscilloscope. Zero
+	// records every sample.
+	MaxSamples int
+	rnd        *rand.Rand
+	registers  map[int]uint32
+	// previous holds the shares of the state before the last reported step
+	previous []xoodoo.State
+	trace    []float64
+}
+
+// NewSimulator returns a Simulator using the given leakage model and noise level, drawing the noise
+// from a source seeded with seed
+func NewSimulator(model Model, noise float64, seed int64) (*Simulator, error) {
+	if model != HammingWeight && model != HammingDistance {
+		return nil, fmt.Errorf("leakage: invalid model: %s", model)
+	}
+	if noise < 0 {
+		return nil, fmt.Errorf("leakage: invalid noise level: %g", noise)
+	}
+	return &Simulator{
+		Model:     model,
+		Noise:     noise,
+		rnd:       rand.New(rand.NewSource(seed)),
+		registers: map[int]uint32{},
+	}, nil
+}
+
+// Reset starts a new trace, clearing every register
+func (sim *Simulator) Reset() {
+	sim.registers = map[int]uint32{}
+	sim.previous = nil
+	sim.trace = nil
+}
+
+// Trace returns the samples recorded since the last call to Reset
+func (sim *Simulator) Trace() []float64 {
+	return append([]float64{}, sim.trace...)
+}
+
+// Leak records the leakage of a word written to a register. Registers are identified by arbitrary
+// integers and hold zero after Reset.
+func (sim *Simulator) Leak(register int, word uint32) {
+	leaked := word
+	if sim.Model == HammingDistance {
+		leaked ^= sim.registers[register]
+	}
+	sim.registers[register] = word
+	if sim.MaxSamples > 0 && len(sim.trace) >= sim.MaxSamples {
+		return
+	}
+	sample := float64(bits.OnesCount32(leaked))
+	if sim.Noise > 0 {
+		sample += sim.rnd.NormFloat64() * sim.Noise
+	}
+	sim.trace = append(sim.trace, sample)
+}
+
+// leakShares records the leakage of each share of a state reported after a step, preceded for θ by
+// the leakage of the column parity plane of each share before the step
+func (sim *Simulator) leakShares(step xoodoo.Step, shares []xoodoo.State) {
+	for s, share := range shares {
+		if step == xoodoo.StepTheta && s < len(sim.previous) {
+			for x, p := range sim.previous[s].ParityPlane() {
+				sim.Leak(s*registersPerShare+len(share)+x, p)
+			}
+		}
+		for i, lane := range share {
+			sim.Leak(s*registersPerShare+i, lane)
+		}
+	}
+	sim.previous = append(sim.previous[:0], shares...)
+}
+
+// PermutationTracer returns a xoodoo.Tracer recording the leakage of the permutation steps
+func (sim *Simulator) PermutationTracer() xoodoo.Tracer {
+	return func(round int, step xoodoo.Step, state xoodoo.State) {
+		sim.leakShares(step, []xoodoo.State{state})
+	}
+}
+
+// MaskedTracer returns a tracer for the masked permutation of package masked recording the leakage
+// of the permutation steps, each share leaking separately
+func (sim *Simulator) MaskedTracer() masked.Tracer {
+	return func(round int, step xoodoo.Step, shares []xoodoo.State) {
+		sim.leakShares(step, shares)
+	}
+}
+
+// CyclistTracer returns a xoodyak.CyclistTracer recording the leakage of the state after each Down
+// call and of the words of the block returned by each Up call
+func (sim *Simulator) CyclistTracer() xoodyak.CyclistTracer {
+	return func(phase xoodyak.CyclistPhase, control byte, block []byte, state xoodoo.State) {
+		if phase == xoodyak.Down {
+			sim.leakShares(xoodoo.StepInput, []xoodoo.State{state})
+			return
+		}
+		var buf [xoodoo.StateSizeBytes]byte
+		copy(buf[:], block)
+		var words xoodoo.State
+		words.UnmarshalBinary(buf[:])
+		for i := 0; i < (len(block)+3)/4; i++ {
+			sim.Leak(outputRegisters+i, words[i])
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package leakage
+
+import (
+	"errors"
+	"math/bits"
+	"testing"
+
+	"github.com/inmcm/xoodoo/masked"
+	"github.com/inmcm/xoodoo/xoodoo"
+	"github.com/inmcm/xoodoo/xoodyak"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSimulator(t *testing.T) {
+	tests := []struct {
+		name    string
+		model   Model
+		noise   float64
+		wantErr error
+	}{
+		{name: "HammingWeight", model: HammingWeight},
+		{name: "HammingDistance", model: HammingDistance, noise: 1.5},
+		{name: "InvalidModel", model: 3, wantErr: errors.New("leakage: invalid model: Model(3)")},
+		{name: "NegativeNoise", model: HammingWeight, noise: -1, wantErr: errors.New("leakage: invalid noise level: -1")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sim, err := NewSimulator(tt.model, tt.noise, 1)
+			assert.Equal(t, tt.wantErr, err)
+			if err == nil {
+				assert.Equal(t, tt.model, sim.Model)
+				assert.Equal(t, tt.noise, sim.Noise)
+			}
+		})
+	}
+}
+
+func TestModelString(t *testing.T) {
+	assert.Equal(t, "HammingWeight", HammingWeight.String())
+	assert.Equal(t, "HammingDistance", HammingDistance.String())
+	assert.Equal(t, "Model(0)", Model(0).String())
+}
+
+func TestLeak(t *testing.T) {
+	tests := []struct {
+		name  string
+		model Model
+		want  []float64
+	}{
+		{name: "HammingWeight", model: HammingWeight, want: []float64{4, 8, 1, 32}},
+		{name: "HammingDistance", model: HammingDistance, want: []float64{4, 12, 1, 24}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sim, _ := NewSimulator(tt.model, 0, 1)
+			sim.Leak(0, 0x0F)
+			sim.Leak(0, 0xFF0)
+			sim.Leak(1, 0x80000000)
+			sim.Leak(0, 0xFFFFFFFF)
+			assert.Equal(t, tt.want, sim.Trace())
+
+			// Reset clears the registers along with the trace
+			sim.Reset()
+			assert.Empty(t, sim.Trace())
+			sim.Leak(0, 0x0F)
+			assert.Equal(t, tt.want[:1], sim.Trace())
+		})
+	}
+}
+
+func TestLeakNoiseAndWindow(t *testing.T) {
+	sim, _ := NewSimulator(HammingWeight, 1, 1)
+	sim.MaxSamples = 100
+	for i := 0; i < 200; i++ {
+		sim.Leak(0, 0xFF)
+	}
+	trace := sim.Trace()
+	assert.Len(t, trace, 100)
+	var mean float64
+	for _, v := range trace {
+		mean += v / 100
+	}
+	assert.InDelta(t, 8, mean, 0.5)
+	assert.NotEqual(t, trace[0], trace[1])
+
+	// The same seed gives the same noise
+	other, _ := NewSimulator(HammingWeight, 1, 1)
+	other.Leak(0, 0xFF)
+	assert.Equal(t, trace[0], other.Trace()[0])
+}
+
+func TestPermutationTracer(t *testing.T) {
+	sim, _ := NewSimulator(HammingWeight, 0, 1)
+	xd, _ := xoodoo.NewXoodoo(1, [xoodoo.StateSizeBytes]byte{})
+	for i := range xd.State {
+		xd.State[i] = uint32(i) * 0x01010101
+	}
+	in := xd.State
+	var trace xoodoo.Trace
+	tracer := sim.PermutationTracer()
+	xd.SetTracer(func(round int, step xoodoo.Step, state xoodoo.State) {
+		trace.Record(round, step, state)
+		tracer(round, step, state)
+	})
+	xd.Permutation()
+
+	// Every step leaks the twelve lanes, and θ the parity plane first
+	samples := sim.Trace()
+	assert.Len(t, samples, 6*12+4)
+	var want []float64
+	for _, e := range trace.Entries {
+		if e.Step == xoodoo.StepTheta {
+			for _, p := range in.ParityPlane() {
+				want = append(want, float64(bits.OnesCount32(p)))
+			}
+		}
+		for _, lane := range e.State {
+			want = append(want, float64(bits.OnesCount32(lane)))
+		}
+	}
+	assert.Equal(t, want, samples)
+}
+
+func TestMaskedTracer(t *testing.T) {
+	sim, _ := NewSimulator(HammingDistance, 0, 1)
+	m, _ := masked.NewXoodoo(1, 2, xoodoo.State{}, nil)
+	m.SetTracer(sim.MaskedTracer())
+	assert.NoError(t, m.Permutation())
+	// Three shares leak at every step
+	assert.Len(t, sim.Trace(), 3*(6*12+4))
+}
+
+func TestCyclistTracer(t *testing.T) {
+	sim, _ := NewSimulator(HammingWeight, 0, 1)
+	xk := xoodyak.Instantiate(nil, nil, nil)
+	xk.SetTracer(sim.CyclistTracer())
+	xk.Absorb([]byte{0xFF})
+	assert.Len(t, sim.Trace(), 12)
+	// Padding adds one bit to the first lane and the masked Cd one bit to the last
+	assert.Equal(t, []float64{9, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1}, sim.Trace())
+
+	sim.Reset()
+	out := xk.Squeeze(5)
+	samples := sim.Trace()
+	assert.Len(t, samples, 2)
+	assert.Equal(t, float64(bits.OnesCount8(out[4])), samples[1])
+}
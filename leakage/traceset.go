@@ -0,0 +1,135 @@
+package leakage
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/inmcm/xoodoo/masked"
+	"github.com/inmcm/xoodoo/xoodyak"
+)
+
+// TraceSet holds traces of equal length along with the public input of each
+type TraceSet struct {
+	Inputs [][]byte
+	Traces [][]float64
+}
+
+// Add appends a trace and its input to the set
+func (ts *TraceSet) Add(input []byte, trace []float64) error {
+	if len(ts.Traces) > 0 && len(trace) != len(ts.Traces[0]) {
+		return fmt.Errorf("leakage: trace length %d differs from set length %d", len(trace), len(ts.Traces[0]))
+	}
+	ts.Inputs = append(ts.Inputs, append([]byte{}, input...))
+	ts.Traces = append(ts.Traces, trace)
+	return nil
+}
+
+// Len returns the number of traces in the set
+func (ts *TraceSet) Len() int {
+	return len(ts.Traces)
+}
+
+// Samples returns the length of the traces in the set
+func (ts *TraceSet) Samples() int {
+	if len(ts.Traces) == 0 {
+		return 0
+	}
+	return len(ts.Traces[0])
+}
+
+// Window returns a trace set holding samples start to end-1 of each trace, to restrict an
+// analysis to the points of interest
+func (ts *TraceSet) Window(start, end int) (*TraceSet, error) {
+	if start < 0 || end > ts.Samples() || start >= end {
+		return nil, fmt.Errorf("leakage: invalid window [%d, %d) of %d samples", start, end, ts.Samples())
+	}
+	w := &TraceSet{Inputs: ts.Inputs, Traces: make([][]float64, len(ts.Traces))}
+	for i, trace := range ts.Traces {
+		w.Traces[i] = trace[start:end]
+	}
+	return w, nil
+}
+
+// WriteCSV writes the set to w as CSV, one record per trace holding the input in hex followed by
+// the samples
+func (ts *TraceSet) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	for i, trace := range ts.Traces {
+		record := make([]string, 1+len(trace))
+		record[0] = fmt.Sprintf("%X", ts.Inputs[i])
+		for j, v := range trace {
+			record[1+j] = strconv.FormatFloat(v, 'g', -1, 64)
+		}
+		cw.Write(record)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Target runs a computation on a public input while the Simulator records its leakage
+type Target func(sim *Simulator, input []byte) error
+
+// Acquire resets the simulator and runs the target on each input, collecting the traces
+func Acquire(target Target, sim *Simulator, inputs [][]byte) (*TraceSet, error) {
+	ts := &TraceSet{}
+	for _, input := range inputs {
+		sim.Reset()
+		if err := target(sim, input); err != nil {
+			return nil, err
+		}
+		if err := ts.Add(input, sim.Trace()); err != nil {
+			return nil, err
+		}
+	}
+	return ts, nil
+}
+
+// XoodyakTarget returns a Target running the unmasked Xoodyak keyed mode: it absorbs the key with
+// the input as nonce, then encrypts an empty message, which applies the first permutation
+func XoodyakTarget(key []byte) Target {
+	return func(sim *Simulator, input []byte) error {
+		if len(key)+len(input) >= 44 {
+			return fmt.Errorf("leakage: key (%d bytes) and nonce (%d bytes) too long", len(key), len(input))
+		}
+		xk := xoodyak.Instantiate(nil, nil, nil)
+		xk.Instance.SetTracer(sim.PermutationTracer())
+		xk.SetTracer(sim.CyclistTracer())
+		xk.AbsorbKey(key, input, nil)
+		xk.Encrypt(nil)
+		return nil
+	}
+}
+
+// CounterTarget returns a Target running the unmasked Xoodyak keyed mode with a fixed nonce and the
+// input as counter: it absorbs the key, the nonce and the counter, then encrypts an empty message.
+// The counter is absorbed one byte per permutation call, so that each input byte only meets a state
+// that the permutation has already mixed with the whole key.
+func CounterTarget(key, nonce []byte) Target {
+	return func(sim *Simulator, input []byte) error {
+		if len(key)+len(nonce) >= 44 {
+			return fmt.Errorf("leakage: key (%d bytes) and nonce (%d bytes) too long", len(key), len(nonce))
+		}
+		xk := xoodyak.Instantiate(nil, nil, nil)
+		xk.Instance.SetTracer(sim.PermutationTracer())
+		xk.SetTracer(sim.CyclistTracer())
+		xk.AbsorbKey(append([]byte{}, key...), nonce, input)
+		xk.Encrypt(nil)
+		return nil
+	}
+}
+
+// MaskedTarget returns a Target running the masked Xoodyak keyed mode of the given order as done by
+// XoodyakTarget, drawing the masks from rnd (crypto/rand when nil)
+func MaskedTarget(key []byte, order int, rnd io.Reader) Target {
+	return func(sim *Simulator, input []byte) error {
+		xk, err := masked.NewXoodyak(key, input, nil, order, rnd)
+		if err != nil {
+			return err
+		}
+		xk.Permutation().SetTracer(sim.MaskedTracer())
+		_, err = xk.Encrypt(nil)
+		return err
+	}
+}
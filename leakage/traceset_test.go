@@ -0,0 +1,128 @@
+package leakage
+
+import (
+	"bytes"
+	"errors"
+	"math/bits"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTraceSet(t *testing.T) {
+	var ts TraceSet
+	assert.Equal(t, 0, ts.Samples())
+	assert.NoError(t, ts.Add([]byte{0xAB}, []float64{1, 2.5, 3}))
+	assert.NoError(t, ts.Add([]byte{0x01, 0x02}, []float64{-1, 0, 1e-3}))
+	assert.Equal(t, errors.New("leakage: trace length 2 differs from set length 3"), ts.Add(nil, []float64{1, 2}))
+	assert.Equal(t, 2, ts.Len())
+	assert.Equal(t, 3, ts.Samples())
+
+	var b bytes.Buffer
+	assert.NoError(t, ts.WriteCSV(&b))
+	assert.Equal(t, "AB,1,2.5,3\n0102,-1,0,0.001\n", b.String())
+
+	w, err := ts.Window(1, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]float64{{2.5, 3}, {0, 1e-3}}, w.Traces)
+	assert.Equal(t, ts.Inputs, w.Inputs)
+	for _, bounds := range [][2]int{{-1, 2}, {0, 4}, {2, 2}} {
+		_, err := ts.Window(bounds[0], bounds[1])
+		assert.Error(t, err)
+	}
+}
+
+func TestAcquireErrors(t *testing.T) {
+	sim, _ := NewSimulator(HammingWeight, 0, 1)
+	long := make([]byte, 30)
+	tests := []struct {
+		name    string
+		target  Target
+		wantErr error
+	}{
+		{name: "Xoodyak", target: XoodyakTarget(make([]byte, 16)),
+			wantErr: errors.New("leakage: key (16 bytes) and nonce (30 bytes) too long")},
+		{name: "Counter", target: CounterTarget(make([]byte, 16), long),
+			wantErr: errors.New("leakage: key (16 bytes) and nonce (30 bytes) too long")},
+		{name: "Masked", target: MaskedTarget(make([]byte, 16), 1, nil),
+			wantErr: errors.New("masked: key and nonce lengths too large - key:16 nonce:30 combined:46 max:43")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Acquire(tt.target, sim, [][]byte{long})
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+// randomInputs returns n random inputs of the given length
+func randomInputs(rnd *rand.Rand, n, length int) [][]byte {
+	inputs := make([][]byte, n)
+	for i := range inputs {
+		inputs[i] = make([]byte, length)
+		rnd.Read(inputs[i])
+	}
+	return inputs
+}
+
+// recoverKeyBytes runs CPA on the given key bytes, predicting the Hamming weight of the byte of the
+// column parity plane that sums the key byte, an input byte and a known constant, and returns the
+// number of key bytes recovered
+func recoverKeyBytes(t *testing.T, ts *TraceSet, key []byte, constants map[int]byte) int {
+	t.Helper()
+	recovered := 0
+	for j := range key {
+		result, err := CPA(ts, 256, func(input []byte, h int) float64 {
+			return float64(bits.OnesCount8(byte(h) ^ input[j%len(input)] ^ constants[j]))
+		})
+		assert.NoError(t, err)
+		if result.Best() == int(key[j]) {
+			recovered++
+		}
+	}
+	return recovered
+}
+
+func TestKeyRecovery(t *testing.T) {
+	key := make([]byte, 16)
+	rand.New(rand.NewSource(1)).Read(key)
+	// The first permutation input holds the key, the nonce, the nonce length, the padding and
+	// Cd = 0x02 with Cu = 0x80 in its last byte
+	constants := map[int]byte{0: 0x10, 1: 0x01, 15: 0x82}
+	tests := []struct {
+		name       string
+		model      Model
+		target     Target
+		inputLen   int
+		window     [2]int
+		maxSamples int
+		keyBytes   int
+		want       int
+	}{
+		// The parity plane computed by the first θ follows the Down and permutation input states
+		{name: "LeakyHammingWeight", model: HammingWeight, target: XoodyakTarget(key), inputLen: 16,
+			window: [2]int{24, 28}, maxSamples: 100, keyBytes: 16, want: 16},
+		{name: "LeakyHammingDistance", model: HammingDistance, target: XoodyakTarget(key), inputLen: 16,
+			window: [2]int{24, 28}, maxSamples: 100, keyBytes: 16, want: 16},
+		{name: "Masked", model: HammingWeight, target: MaskedTarget(key, 1, rand.New(rand.NewSource(2))), inputLen: 16,
+			window: [2]int{0, 200}, maxSamples: 200, keyBytes: 16, want: 0},
+		// The counter only meets the state after the key has been mixed by a full permutation
+		{name: "Counter", model: HammingWeight, target: CounterTarget(key, make([]byte, 16)), inputLen: 4,
+			window: [2]int{0, 3972}, keyBytes: 4, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if testing.Short() && tt.want == 0 {
+				t.Skip("skipping unsuccessful attack in short mode")
+			}
+			sim, _ := NewSimulator(tt.model, 2, 3)
+			sim.MaxSamples = tt.maxSamples
+			ts, err := Acquire(tt.target, sim, randomInputs(rand.New(rand.NewSource(4)), 500, tt.inputLen))
+			assert.NoError(t, err)
+			ts, err = ts.Window(tt.window[0], tt.window[1])
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, recoverKeyBytes(t, ts, key[:tt.keyBytes], constants))
+		})
+	}
+}
@@ -0,0 +1,234 @@
+// Package masked implements a Boolean-masked Xoodoo permutation and a masked Xoodyak keyed mode on
+// top of it, for side-channel experiments.
+//
+// A masked state of order d is held as d+1 shares whose XOR is the actual state, all but one of
+// them drawn at random. The linear step mappings θ, ρwest and ρeast apply to each share separately
+// and ι to the first share only. The nonlinear step χ computes the AND of its inputs with the ISW
+// multiplication, which needs d(d+1)/2 fresh random words for each lane, so that any d intermediate
+// values of the computation are independent of the state. This gives d-th order security in the
+// probing model for software implementations whose instructions do not combine shares; it offers
+// no protection against glitches in hardware.
+package masked
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/inmcm/xoodoo/xoodoo"
+)
+
+// Xoodoo is a masked Xoodoo permutation instance
+type Xoodoo struct {
+	// Shares holds the d+1 shares of the state, whose XOR is the unmasked state
+	Shares []xoodoo.State
+	rounds int
+	rnd    io.Reader
+	buf    []byte
+	tracer Tracer
+}
+
+// Tracer observes the intermediate shares of the masked Xoodoo permutation. It receives the round
+// index (an index into xoodoo.RoundConstants), the step that was just applied and a copy of the
+// resulting shares.
+type Tracer func(round int, step xoodoo.Step, shares []xoodoo.State)
+
+// SetTracer installs a Tracer that is called for every intermediate step of subsequent calls to
+// Permutation and Round. A nil Tracer stops tracing.
+func (m *Xoodoo) SetTracer(fn Tracer) {
+	m.tracer = fn
+}
+
+func (m *Xoodoo) trace(round int, step xoodoo.Step) {
+	if m.tracer != nil {
+		m.tracer(round, step, append([]xoodoo.State{}, m.Shares...))
+	}
+}
+
+// NewXoodoo returns a masked Xoodoo instance of the given number of rounds (1 to 12) and masking
+// order, holding a fresh sharing of the initial state. Randomness is read from rnd, or from
+// crypto/rand when rnd is nil. An order of 0 holds the state in a single share and is not masked,
+// which is useful as a baseline.
+func NewXoodoo(rounds, order int, state xoodoo.State, rnd io.Reader) (*Xoodoo, error) {
+	if rounds < 1 || rounds > xoodoo.MaxRounds {
+		return nil, fmt.Errorf("masked: invalid number of rounds: %d", rounds)
+	}
+	if order < 0 {
+		return nil, fmt.Errorf("masked: invalid masking order: %d", order)
+	}
+	if rnd == nil {
+		rnd = rand.Reader
+	}
+	m := &Xoodoo{Shares: make([]xoodoo.State, order+1), rounds: rounds, rnd: rnd}
+	m.Shares[0] = state
+	if err := m.Refresh(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Order returns the masking order, one less than the number of shares
+func (m *Xoodoo) Order() int {
+	return len(m.Shares) - 1
+}
+
+// State returns the unmasked state, combining the shares
+func (m *Xoodoo) State() xoodoo.State {
+	s := m.Shares[0]
+	for _, share := range m.Shares[1:] {
+		for i := range s {
+			s[i] ^= share[i]
+		}
+	}
+	return s
+}
+
+// random returns n random words
+func (m *Xoodoo) random(n int) ([]uint32, error) {
+	if cap(m.buf) < 4*n {
+		m.buf = make([]byte, 4*n)
+	}
+	buf := m.buf[:4*n]
+	if _, err := io.ReadFull(m.rnd, buf); err != nil {
+		return nil, fmt.Errorf("masked: reading randomness: %s", err)
+	}
+	words := make([]uint32, n)
+	for i := range words {
+		words[i] = binary.LittleEndian.Uint32(buf[4*i:])
+	}
+	return words, nil
+}
+
+// Refresh replaces the sharing of the state with a fresh one, adding a random state to the first
+// share and to each of the others
+func (m *Xoodoo) Refresh() error {
+	r, err := m.random(len(xoodoo.State{}) * m.Order())
+	if err != nil {
+		return err
+	}
+	for i := 1; i < len(m.Shares); i++ {
+		for j := range m.Shares[i] {
+			v := r[len(xoodoo.State{})*(i-1)+j]
+			m.Shares[0][j] ^= v
+			m.Shares[i][j] ^= v
+		}
+	}
+	return nil
+}
+
+// Permutation applies the masked Xoodoo permutation to the shares
+func (m *Xoodoo) Permutation() error {
+	m.trace(xoodoo.MaxRounds-m.rounds, xoodoo.StepInput)
+	for round := xoodoo.MaxRounds - m.rounds; round < xoodoo.MaxRounds; round++ {
+		if err := m.Round(round); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Round applies round i of the masked Xoodoo permutation, as an index into xoodoo.RoundConstants,
+// to the shares
+func (m *Xoodoo) Round(i int) error {
+	for s := range m.Shares {
+		m.Shares[s].Theta()
+	}
+	m.trace(i, xoodoo.StepTheta)
+	for s := range m.Shares {
+		m.Shares[s].RhoWest()
+	}
+	m.trace(i, xoodoo.StepRhoWest)
+	m.Shares[0].Iota(i)
+	m.trace(i, xoodoo.StepIota)
+	if err := m.Chi(); err != nil {
+		return err
+	}
+	m.trace(i, xoodoo.StepChi)
+	for s := range m.Shares {
+		m.Shares[s].RhoEast()
+	}
+	m.trace(i, xoodoo.StepRhoEast)
+	return nil
+}
+
+// Chi applies the masked χ step mapping to the shares. Plane y becomes
+// a_y + a_(y+2) + a_(y+1)·a_(y+2), where the sums apply to each share and the product is computed
+// with the ISW multiplication.
+func (m *Xoodoo) Chi() error {
+	d := m.Order()
+	r, err := m.random(len(xoodoo.State{}) * d * (d + 1) / 2)
+	if err != nil {
+		return err
+	}
+	a := make([]uint32, len(m.Shares))
+	b := make([]uint32, len(m.Shares))
+	products := make([]xoodoo.State, len(m.Shares))
+	for y := 0; y < xoodoo.PlaneCount; y++ {
+		for x := 0; x < xoodoo.LaneCount; x++ {
+			for s := range m.Shares {
+				a[s] = m.Shares[s].Lane(x, (y+1)%xoodoo.PlaneCount)
+				b[s] = m.Shares[s].Lane(x, (y+2)%xoodoo.PlaneCount)
+			}
+			c := and(a, b, r[:d*(d+1)/2])
+			r = r[d*(d+1)/2:]
+			for s := range m.Shares {
+				products[s].SetLane(x, y, c[s])
+			}
+		}
+	}
+	for s := range m.Shares {
+		out := m.Shares[s]
+		for y := 0; y < xoodoo.PlaneCount; y++ {
+			for x := 0; x < xoodoo.LaneCount; x++ {
+				out.SetLane(x, y, m.Shares[s].Lane(x, y)^m.Shares[s].Lane(x, (y+2)%xoodoo.PlaneCount)^products[s].Lane(x, y))
+			}
+		}
+		m.Shares[s] = out
+	}
+	return nil
+}
+
+// and returns a sharing of the AND of the words shared by a and b, computed with the ISW
+// multiplication from the d(d+1)/2 random words in r
+func and(a, b []uint32, r []uint32) []uint32 {
+	c := make([]uint32, len(a))
+	for i := range a {
+		c[i] = a[i] & b[i]
+	}
+	k := 0
+	for i := range a {
+		for j := i + 1; j < len(a); j++ {
+			// The order of the additions keeps every intermediate value masked by r[k]
+			rji := (r[k] ^ a[i]&b[j]) ^ a[j]&b[i]
+			c[i] ^= r[k]
+			c[j] ^= rji
+			k++
+		}
+	}
+	return c
+}
+
+// AddBytes adds public data to the state at the given byte offset, applying it to the first share
+func (m *Xoodoo) AddBytes(data []byte, offset int) error {
+	return m.Shares[0].AddBytes(data, offset)
+}
+
+// ExtractBytes returns the unmasked bytes of the state from the given offset, adding the shares
+// one at a time
+func (m *Xoodoo) ExtractBytes(n, offset int) ([]byte, error) {
+	return m.ExtractAndAddBytes(make([]byte, n), offset)
+}
+
+// ExtractAndAddBytes returns the sum of in and the bytes of the state from the given offset,
+// adding the shares one at a time so that the intermediate values stay masked until the last
+// share is added
+func (m *Xoodoo) ExtractAndAddBytes(in []byte, offset int) ([]byte, error) {
+	out := append([]byte{}, in...)
+	for s := range m.Shares {
+		if err := m.Shares[s].ExtractAndAddBytes(out, out, offset); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,206 @@
+package masked
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/inmcm/xoodoo/xoodoo"
+	"github.com/stretchr/testify/assert"
+)
+
+// failingReader fails every read
+type failingReader struct{}
+
+func (failingReader) Read(p []byte) (int, error) {
+	return 0, errors.New("no randomness")
+}
+
+func randomState(rnd *rand.Rand) xoodoo.State {
+	var s xoodoo.State
+	for i := range s {
+		s[i] = rnd.Uint32()
+	}
+	return s
+}
+
+func TestNewXoodoo(t *testing.T) {
+	tests := []struct {
+		name    string
+		rounds  int
+		order   int
+		wantErr error
+	}{
+		{name: "Unmasked", rounds: 12, order: 0},
+		{name: "FirstOrder", rounds: 12, order: 1},
+		{name: "ThirdOrder", rounds: 6, order: 3},
+		{name: "NoRounds", rounds: 0, order: 1, wantErr: errors.New("masked: invalid number of rounds: 0")},
+		{name: "TooManyRounds", rounds: 13, order: 1, wantErr: errors.New("masked: invalid number of rounds: 13")},
+		{name: "NegativeOrder", rounds: 12, order: -1, wantErr: errors.New("masked: invalid masking order: -1")},
+	}
+	state := randomState(rand.New(rand.NewSource(1)))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := NewXoodoo(tt.rounds, tt.order, state, nil)
+			assert.Equal(t, tt.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tt.order, m.Order())
+			assert.Len(t, m.Shares, tt.order+1)
+			assert.Equal(t, state, m.State())
+			if tt.order > 0 {
+				assert.NotEqual(t, state, m.Shares[0])
+			}
+		})
+	}
+
+	_, err := NewXoodoo(12, 1, state, failingReader{})
+	assert.Equal(t, errors.New("masked: reading randomness: no randomness"), err)
+}
+
+func TestPermutation(t *testing.T) {
+	rnd := rand.New(rand.NewSource(2))
+	for order := 0; order <= 4; order++ {
+		for _, rounds := range []int{1, 3, 6, 12} {
+			in := randomState(rnd)
+			m, err := NewXoodoo(rounds, order, in, rnd)
+			assert.NoError(t, err)
+			assert.NoError(t, m.Permutation())
+
+			xd, _ := xoodoo.NewXoodoo(rounds, [xoodoo.StateSizeBytes]byte{})
+			xd.State = in
+			xd.Permutation()
+			assert.Equal(t, xd.State, m.State(), "order %d rounds %d", order, rounds)
+		}
+	}
+}
+
+func TestPermutationFreshMasks(t *testing.T) {
+	// The same input permuted with different randomness leaves different shares
+	in := randomState(rand.New(rand.NewSource(3)))
+	m1, _ := NewXoodoo(12, 2, in, rand.New(rand.NewSource(4)))
+	m2, _ := NewXoodoo(12, 2, in, rand.New(rand.NewSource(5)))
+	assert.NoError(t, m1.Permutation())
+	assert.NoError(t, m2.Permutation())
+	assert.Equal(t, m1.State(), m2.State())
+	for s := range m1.Shares {
+		assert.NotEqual(t, m1.Shares[s], m2.Shares[s])
+	}
+}
+
+func TestPermutationRandomnessFailure(t *testing.T) {
+	r := &limitedReader{n: 4 * 12}
+	m, err := NewXoodoo(12, 1, xoodoo.State{}, r)
+	assert.NoError(t, err)
+	assert.Equal(t, errors.New("masked: reading randomness: no randomness"), m.Permutation())
+}
+
+// limitedReader returns n zero bytes, then fails
+type limitedReader struct {
+	n int
+}
+
+func (r *limitedReader) Read(p []byte) (int, error) {
+	if r.n == 0 {
+		return 0, errors.New("no randomness")
+	}
+	if len(p) > r.n {
+		p = p[:r.n]
+	}
+	for i := range p {
+		p[i] = 0
+	}
+	r.n -= len(p)
+	return len(p), nil
+}
+
+func TestRefresh(t *testing.T) {
+	in := randomState(rand.New(rand.NewSource(6)))
+	m, _ := NewXoodoo(12, 3, in, nil)
+	before := append([]xoodoo.State{}, m.Shares...)
+	assert.NoError(t, m.Refresh())
+	assert.Equal(t, in, m.State())
+	for s := range before {
+		assert.NotEqual(t, before[s], m.Shares[s])
+	}
+}
+
+func TestAnd(t *testing.T) {
+	rnd := rand.New(rand.NewSource(7))
+	for shares := 1; shares <= 5; shares++ {
+		a, b := make([]uint32, shares), make([]uint32, shares)
+		var wantA, wantB uint32
+		for i := range a {
+			a[i], b[i] = rnd.Uint32(), rnd.Uint32()
+			wantA ^= a[i]
+			wantB ^= b[i]
+		}
+		r := make([]uint32, shares*(shares-1)/2)
+		for i := range r {
+			r[i] = rnd.Uint32()
+		}
+		var got uint32
+		for _, c := range and(a, b, r) {
+			got ^= c
+		}
+		assert.Equal(t, wantA&wantB, got)
+	}
+}
+
+func TestExtractBytes(t *testing.T) {
+	var in xoodoo.State
+	in.UnmarshalBinary(bytes.Repeat([]byte{0x5A}, xoodoo.StateSizeBytes))
+	m, _ := NewXoodoo(12, 2, in, nil)
+	out, err := m.ExtractBytes(4, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x5A, 0x5A, 0x5A, 0x5A}, out)
+	out, err = m.ExtractAndAddBytes([]byte{0x0F, 0xF0}, 46)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x55, 0xAA}, out)
+	_, err = m.ExtractBytes(4, 46)
+	assert.Error(t, err)
+
+	assert.NoError(t, m.AddBytes([]byte{0x5A}, 0))
+	out, _ = m.ExtractBytes(2, 0)
+	assert.Equal(t, []byte{0x00, 0x5A}, out)
+}
+
+func TestTracer(t *testing.T) {
+	in := randomState(rand.New(rand.NewSource(8)))
+	m, _ := NewXoodoo(3, 2, in, nil)
+	var steps []xoodoo.Step
+	var rounds []int
+	var states []xoodoo.State
+	m.SetTracer(func(round int, step xoodoo.Step, shares []xoodoo.State) {
+		assert.Len(t, shares, 3)
+		var s xoodoo.State
+		for _, share := range shares {
+			for i := range s {
+				s[i] ^= share[i]
+			}
+		}
+		steps = append(steps, step)
+		rounds = append(rounds, round)
+		states = append(states, s)
+	})
+	assert.NoError(t, m.Permutation())
+
+	// The unmasked permutation reports the same sequence of steps and states
+	xd, _ := xoodoo.NewXoodoo(3, [xoodoo.StateSizeBytes]byte{})
+	xd.State = in
+	var trace xoodoo.Trace
+	xd.SetTracer(trace.Record)
+	xd.Permutation()
+	assert.Len(t, states, len(trace.Entries))
+	for i, e := range trace.Entries {
+		assert.Equal(t, e.Step, steps[i])
+		assert.Equal(t, e.Round, rounds[i])
+		assert.Equal(t, e.State, states[i])
+	}
+
+	m.SetTracer(nil)
+	assert.NoError(t, m.Permutation())
+	assert.Len(t, states, len(trace.Entries))
+}
@@ -0,0 +1,248 @@
+package masked
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/inmcm/xoodoo/xoodoo"
+	"github.com/inmcm/xoodoo/xoodyak"
+)
+
+const (
+	// keyedAbsorbSize and keyedSqueezeSize are the Xoodyak keyed mode rates in bytes
+	keyedAbsorbSize  = 44
+	keyedSqueezeSize = 24
+	// ratchetSize is the number of bytes squeezed and absorbed back by Ratchet
+	ratchetSize = 16
+	// squeezeKeyCu is the Cu byte of SqueezeKey
+	squeezeKeyCu = 0x20
+)
+
+// Xoodyak is an instance of the Xoodyak keyed mode running over the masked Xoodoo permutation. It
+// produces the same outputs as xoodyak.Xoodyak in keyed mode. The key is added to the first share
+// of a fresh sharing of the all-zero state, so it is masked from the first permutation call on.
+// Encryption, decryption and the ratchet keep their secret intermediate values masked; outputs
+// (key stream added to the message, tags and squeezed keys) are unmasked one share at a time.
+//
+// Methods return an error when the source of randomness fails.
+type Xoodyak struct {
+	perm  *Xoodoo
+	phase xoodyak.CyclistPhase
+}
+
+// NewXoodyak returns a masked Xoodyak keyed instance of the given masking order that has absorbed
+// the key, id (nonce) and optional counter. Randomness is read from rnd, or from crypto/rand when
+// rnd is nil.
+func NewXoodyak(key, id, counter []byte, order int, rnd io.Reader) (*Xoodyak, error) {
+	if len(key) == 0 {
+		return nil, errors.New("masked: empty key")
+	}
+	if len(key)+len(id) >= keyedAbsorbSize {
+		return nil, fmt.Errorf("masked: key and nonce lengths too large - key:%d nonce:%d combined:%d max:%d", len(key), len(id), len(key)+len(id), keyedAbsorbSize-1)
+	}
+	perm, err := NewXoodoo(xoodoo.MaxRounds, order, xoodoo.State{}, rnd)
+	if err != nil {
+		return nil, err
+	}
+	xk := &Xoodyak{perm: perm, phase: xoodyak.Up}
+	keyIDBuf := append(append(append([]byte{}, key...), id...), byte(len(id)))
+	if err := xk.absorbAny(keyIDBuf, keyedAbsorbSize, 0x02); err != nil {
+		return nil, err
+	}
+	if len(counter) > 0 {
+		if err := xk.absorbAny(counter, 1, xoodyak.AbsorbCdMain); err != nil {
+			return nil, err
+		}
+	}
+	return xk, nil
+}
+
+// Permutation returns the masked permutation instance holding the state
+func (xk *Xoodyak) Permutation() *Xoodoo {
+	return xk.perm
+}
+
+// Absorb ingests a message at the keyed absorb rate
+func (xk *Xoodyak) Absorb(x []byte) error {
+	return xk.absorbAny(x, keyedAbsorbSize, xoodyak.AbsorbCdInit)
+}
+
+// Encrypt returns the encryption of the plaintext
+func (xk *Xoodyak) Encrypt(pt []byte) ([]byte, error) {
+	return xk.crypt(pt, xoodyak.Encrypting)
+}
+
+// Decrypt returns the decryption of the ciphertext
+func (xk *Xoodyak) Decrypt(ct []byte) ([]byte, error) {
+	return xk.crypt(ct, xoodyak.Decrypting)
+}
+
+// Squeeze returns outLen bytes of output
+func (xk *Xoodyak) Squeeze(outLen uint) ([]byte, error) {
+	return xk.squeezeAny(outLen, xoodyak.SqueezeCuInit)
+}
+
+// SqueezeKey returns keyLen bytes of output suitable as a new key
+func (xk *Xoodyak) SqueezeKey(keyLen uint) ([]byte, error) {
+	return xk.squeezeAny(keyLen, squeezeKeyCu)
+}
+
+// Ratchet irreversibly transforms the state to prevent key recovery. Absorbing the squeezed bytes
+// back into the state clears them, which is done share by share without unmasking them.
+func (xk *Xoodyak) Ratchet() error {
+	if err := xk.up(xoodyak.RatchetCu); err != nil {
+		return err
+	}
+	for s := range xk.perm.Shares {
+		xk.perm.Shares[s].OverwriteWithZeroes(ratchetSize)
+	}
+	xk.perm.AddBytes([]byte{0x01}, ratchetSize)
+	xk.perm.AddBytes([]byte{xoodyak.AbsorbCdMain}, xoodoo.StateSizeBytes-1)
+	xk.phase = xoodyak.Down
+	return nil
+}
+
+// up applies the permutation after adding the Cu byte
+func (xk *Xoodyak) up(cu byte) error {
+	xk.perm.AddBytes([]byte{cu}, xoodoo.StateSizeBytes-1)
+	if err := xk.perm.Permutation(); err != nil {
+		return err
+	}
+	xk.phase = xoodyak.Up
+	return nil
+}
+
+// down adds the padded block and the Cd byte to the state
+func (xk *Xoodyak) down(x []byte, cd byte) {
+	xk.perm.AddBytes(x, 0)
+	xk.perm.AddBytes([]byte{0x01}, len(x))
+	xk.perm.AddBytes([]byte{cd}, xoodoo.StateSizeBytes-1)
+	xk.phase = xoodyak.Down
+}
+
+func (xk *Xoodyak) absorbAny(x []byte, r int, cd byte) error {
+	for {
+		if xk.phase != xoodyak.Up {
+			if err := xk.up(0); err != nil {
+				return err
+			}
+		}
+		n := r
+		if len(x) < n {
+			n = len(x)
+		}
+		xk.down(x[:n], cd)
+		cd = xoodyak.AbsorbCdMain
+		x = x[n:]
+		if len(x) == 0 {
+			return nil
+		}
+	}
+}
+
+func (xk *Xoodyak) squeezeAny(outLen uint, cu byte) ([]byte, error) {
+	out := make([]byte, 0, outLen)
+	for {
+		if err := xk.up(cu); err != nil {
+			return nil, err
+		}
+		n := keyedSqueezeSize
+		if int(outLen)-len(out) < n {
+			n = int(outLen) - len(out)
+		}
+		block, _ := xk.perm.ExtractBytes(n, 0)
+		out = append(out, block...)
+		if len(out) == int(outLen) {
+			return out, nil
+		}
+		xk.down(nil, 0)
+		cu = 0
+	}
+}
+
+func (xk *Xoodyak) crypt(msg []byte, cm xoodyak.CryptMode) ([]byte, error) {
+	out := make([]byte, 0, len(msg))
+	cu := xoodyak.CryptCuInit
+	for {
+		if err := xk.up(cu); err != nil {
+			return nil, err
+		}
+		n := keyedSqueezeSize
+		if len(msg) < n {
+			n = len(msg)
+		}
+		block, _ := xk.perm.ExtractAndAddBytes(msg[:n], 0)
+		if cm == xoodyak.Encrypting {
+			xk.down(msg[:n], xoodyak.CryptCd)
+		} else {
+			xk.down(block, xoodyak.CryptCd)
+		}
+		out = append(out, block...)
+		cu = xoodyak.CryptCuMain
+		msg = msg[n:]
+		if len(msg) == 0 {
+			return out, nil
+		}
+	}
+}
+
+// CryptoEncryptAEAD encrypts a plaintext message with the masked Xoodyak keyed mode of the given
+// order, as done by xoodyak.CryptoEncryptAEAD
+func CryptoEncryptAEAD(in, key, id, ad []byte, order int, rnd io.Reader) (ct, tag []byte, err error) {
+	if err := checkAEADLengths(key, id); err != nil {
+		return nil, nil, err
+	}
+	xk, err := NewXoodyak(key, id, nil, order, rnd)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := xk.Absorb(ad); err != nil {
+		return nil, nil, err
+	}
+	if ct, err = xk.Encrypt(in); err != nil {
+		return nil, nil, err
+	}
+	if tag, err = xk.Squeeze(xoodyak.TagLen); err != nil {
+		return nil, nil, err
+	}
+	return ct, tag, nil
+}
+
+// CryptoDecryptAEAD decrypts and authenticates a ciphertext message with the masked Xoodyak keyed
+// mode of the given order, as done by xoodyak.CryptoDecryptAEAD. The plaintext is only returned
+// if authentication is successful.
+func CryptoDecryptAEAD(in, key, id, ad, tag []byte, order int, rnd io.Reader) (pt []byte, valid bool, err error) {
+	if err := checkAEADLengths(key, id); err != nil {
+		return nil, false, err
+	}
+	xk, err := NewXoodyak(key, id, nil, order, rnd)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := xk.Absorb(ad); err != nil {
+		return nil, false, err
+	}
+	if pt, err = xk.Decrypt(in); err != nil {
+		return nil, false, err
+	}
+	calculatedTag, err := xk.Squeeze(xoodyak.TagLen)
+	if err != nil {
+		return nil, false, err
+	}
+	if subtle.ConstantTimeCompare(calculatedTag, tag) != 1 {
+		return []byte{}, false, nil
+	}
+	return pt, true, nil
+}
+
+func checkAEADLengths(key, id []byte) error {
+	if len(key) != xoodyak.KeyLen {
+		return fmt.Errorf("masked: given key length (%d bytes) incorrect (%d bytes)", len(key), xoodyak.KeyLen)
+	}
+	if len(id) != xoodyak.NonceLen {
+		return fmt.Errorf("masked: given nonce length (%d bytes) incorrect (%d bytes)", len(id), xoodyak.NonceLen)
+	}
+	return nil
+}
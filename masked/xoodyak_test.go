@@ -0,0 +1,125 @@
+package masked
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/inmcm/xoodoo/xoodyak"
+	"github.com/stretchr/testify/assert"
+)
+
+func sequence(n int) []byte {
+	out := make([]byte, n)
+	for i := range out {
+		out[i] = byte(i)
+	}
+	return out
+}
+
+func TestCryptoAEAD(t *testing.T) {
+	key, nonce := sequence(16), sequence(16)
+	rnd := rand.New(rand.NewSource(1))
+	for order := 0; order <= 3; order++ {
+		for _, lens := range [][2]int{{0, 0}, {1, 0}, {0, 1}, {23, 44}, {24, 45}, {25, 88}, {100, 3}} {
+			pt, ad := sequence(lens[0]), sequence(lens[1])
+			wantCT, wantTag, _ := xoodyak.CryptoEncryptAEAD(pt, key, nonce, ad)
+			ct, tag, err := CryptoEncryptAEAD(pt, key, nonce, ad, order, rnd)
+			assert.NoError(t, err)
+			assert.Equal(t, wantCT, ct, "order %d lengths %v", order, lens)
+			assert.Equal(t, wantTag, tag, "order %d lengths %v", order, lens)
+
+			got, valid, err := CryptoDecryptAEAD(ct, key, nonce, ad, tag, order, rnd)
+			assert.NoError(t, err)
+			assert.True(t, valid)
+			assert.Equal(t, pt, got)
+
+			tag[0] ^= 1
+			got, valid, err = CryptoDecryptAEAD(ct, key, nonce, ad, tag, order, rnd)
+			assert.NoError(t, err)
+			assert.False(t, valid)
+			assert.Empty(t, got)
+		}
+	}
+}
+
+func TestCryptoAEADErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     []byte
+		nonce   []byte
+		order   int
+		wantErr error
+	}{
+		{name: "ShortKey", key: sequence(15), nonce: sequence(16),
+			wantErr: errors.New("masked: given key length (15 bytes) incorrect (16 bytes)")},
+		{name: "LongNonce", key: sequence(16), nonce: sequence(17),
+			wantErr: errors.New("masked: given nonce length (17 bytes) incorrect (16 bytes)")},
+		{name: "NegativeOrder", key: sequence(16), nonce: sequence(16), order: -1,
+			wantErr: errors.New("masked: invalid masking order: -1")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := CryptoEncryptAEAD(nil, tt.key, tt.nonce, nil, tt.order, nil)
+			assert.Equal(t, tt.wantErr, err)
+			_, _, err = CryptoDecryptAEAD(nil, tt.key, tt.nonce, nil, nil, tt.order, nil)
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestNewXoodyakErrors(t *testing.T) {
+	_, err := NewXoodyak(nil, sequence(16), nil, 1, nil)
+	assert.Equal(t, errors.New("masked: empty key"), err)
+	_, err = NewXoodyak(sequence(30), sequence(14), nil, 1, nil)
+	assert.Equal(t, errors.New("masked: key and nonce lengths too large - key:30 nonce:14 combined:44 max:43"), err)
+	_, err = NewXoodyak(sequence(16), sequence(16), nil, 1, failingReader{})
+	assert.Equal(t, errors.New("masked: reading randomness: no randomness"), err)
+}
+
+func TestXoodyakSession(t *testing.T) {
+	// A longer session mixing every operation matches the unmasked implementation
+	key, nonce, counter := sequence(20), sequence(12), []byte{7, 8, 9}
+	want := xoodyak.Instantiate(append([]byte{}, key...), nonce, counter)
+	xk, err := NewXoodyak(key, nonce, counter, 2, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, xoodyak.Down, xk.phase)
+	assert.Equal(t, 2, xk.Permutation().Order())
+
+	msg := sequence(60)
+	want.Absorb(msg)
+	assert.NoError(t, xk.Absorb(msg))
+	ct, err := xk.Encrypt(msg)
+	assert.NoError(t, err)
+	assert.Equal(t, want.Encrypt(msg), ct)
+	want.Ratchet()
+	assert.NoError(t, xk.Ratchet())
+	got, err := xk.Squeeze(50)
+	assert.NoError(t, err)
+	assert.Equal(t, want.Squeeze(50), got)
+	got, err = xk.SqueezeKey(16)
+	assert.NoError(t, err)
+	assert.Equal(t, want.SqueezeKey(16), got)
+	pt, err := xk.Decrypt(ct)
+	assert.NoError(t, err)
+	assert.Equal(t, want.Decrypt(ct), pt)
+	got, err = xk.Squeeze(0)
+	assert.NoError(t, err)
+	assert.Equal(t, want.Squeeze(0), got)
+	assert.Equal(t, want.Instance.State, xk.Permutation().State())
+}
+
+func TestXoodyakRandomnessFailure(t *testing.T) {
+	xk, err := NewXoodyak(sequence(16), sequence(16), nil, 1, nil)
+	assert.NoError(t, err)
+	xk.perm.rnd = failingReader{}
+	wantErr := errors.New("masked: reading randomness: no randomness")
+	assert.Equal(t, wantErr, xk.Absorb([]byte{1}))
+	_, err = xk.Encrypt([]byte{1})
+	assert.Equal(t, wantErr, err)
+	_, err = xk.Decrypt([]byte{1})
+	assert.Equal(t, wantErr, err)
+	_, err = xk.Squeeze(1)
+	assert.Equal(t, wantErr, err)
+	assert.Equal(t, wantErr, xk.Ratchet())
+}
@@ -0,0 +1,101 @@
+package randtest
+
+import (
+	"fmt"
+	"io"
+	"math"
+)
+
+// AvalancheResult holds the number of times each output bit changed when each input bit was
+// flipped, over a number of random inputs. Bit i of a byte string is bit i%8 of byte i/8.
+type AvalancheResult struct {
+	// InBits is the number of input bits
+	InBits int
+	// OutBits is the number of output bits
+	OutBits int
+	// Trials is the number of random inputs
+	Trials int
+	// Flips holds, for input bit i and output bit j, the number of trials in which flipping input
+	// bit i changed output bit j, at index i*OutBits+j
+	Flips []int
+}
+
+// Avalanche measures how the output of f changes when each input bit is flipped, over trials
+// random inputs of inLen bytes read from rnd. The output of f must have the same length for every
+// input.
+func Avalanche(f func(in []byte) []byte, inLen, trials int, rnd io.Reader) (AvalancheResult, error) {
+	if inLen < 1 || trials < 1 {
+		return AvalancheResult{}, fmt.Errorf("randtest: invalid input length (%d bytes) or number of trials (%d)", inLen, trials)
+	}
+	r := AvalancheResult{InBits: 8 * inLen, Trials: trials}
+	in := make([]byte, inLen)
+	for trial := 0; trial < trials; trial++ {
+		if _, err := io.ReadFull(rnd, in); err != nil {
+			return AvalancheResult{}, fmt.Errorf("randtest: reading random bytes: %s", err)
+		}
+		out := f(in)
+		if r.Flips == nil {
+			r.OutBits = 8 * len(out)
+			r.Flips = make([]int, r.InBits*r.OutBits)
+		}
+		for i := 0; i < r.InBits; i++ {
+			flipped := append([]byte{}, in...)
+			flipped[i>>3] ^= 1 << uint(i&7)
+			diff := f(flipped)
+			if len(diff) != len(out) {
+				return AvalancheResult{}, fmt.Errorf("randtest: output length changed from %d to %d bytes", len(out), len(diff))
+			}
+			row := r.Flips[i*r.OutBits : (i+1)*r.OutBits]
+			for j := range row {
+				row[j] += int((out[j>>3] ^ diff[j>>3]) >> uint(j&7) & 1)
+			}
+		}
+	}
+	return r, nil
+}
+
+// Probability returns the fraction of trials in which flipping input bit i changed output bit j
+func (r AvalancheResult) Probability(i, j int) float64 {
+	return float64(r.Flips[i*r.OutBits+j]) / float64(r.Trials)
+}
+
+// MeanFlipRate returns the average fraction of output bits that changed when a single input bit
+// was flipped, which is 1/2 for a function with a good avalanche effect
+func (r AvalancheResult) MeanFlipRate() float64 {
+	total := 0
+	for _, c := range r.Flips {
+		total += c
+	}
+	return float64(total) / float64(r.Trials*len(r.Flips))
+}
+
+// MaxBias returns the largest deviation from 1/2 of the probability that flipping an input bit
+// changes an output bit. The strict avalanche criterion requires every probability to be 1/2.
+func (r AvalancheResult) MaxBias() float64 {
+	bias := 0.0
+	for k := range r.Flips {
+		if b := math.Abs(r.Probability(k/r.OutBits, k%r.OutBits) - 0.5); b > bias {
+			bias = b
+		}
+	}
+	return bias
+}
+
+// SACPValue returns the P-value of the hypothesis that flipping input bit i changes output bit j
+// with probability 1/2, using the normal approximation of the binomial distribution
+func (r AvalancheResult) SACPValue(i, j int) float64 {
+	z := (float64(r.Flips[i*r.OutBits+j]) - float64(r.Trials)/2) / math.Sqrt(float64(r.Trials)/4)
+	return math.Erfc(math.Abs(z) / math.Sqrt2)
+}
+
+// SACFailures returns the number of pairs of input and output bits whose SACPValue is below alpha.
+// For a function satisfying the strict avalanche criterion, about alpha of all pairs fail.
+func (r AvalancheResult) SACFailures(alpha float64) int {
+	n := 0
+	for k := range r.Flips {
+		if r.SACPValue(k/r.OutBits, k%r.OutBits) < alpha {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,78 @@
+package randtest
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/inmcm/xoodoo/xoodoo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAvalanchePermutation(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	full, _ := PermutationFunc(xoodoo.MaxRounds)
+	r, err := Avalanche(full, xoodoo.StateSizeBytes, 200, rng)
+	assert.NoError(t, err)
+	assert.Equal(t, 384, r.InBits)
+	assert.Equal(t, 384, r.OutBits)
+	assert.InDelta(t, 0.5, r.MeanFlipRate(), 0.005)
+	assert.True(t, r.MaxBias() < 0.2)
+	// About 1% of the pairs fail at the 1% level
+	assert.InDelta(t, 0.01*384*384, r.SACFailures(DefaultAlpha), 0.004*384*384)
+
+	// A single round flips few output bits and most pairs never change
+	one, _ := PermutationFunc(1)
+	r, err = Avalanche(one, xoodoo.StateSizeBytes, 50, rng)
+	assert.NoError(t, err)
+	assert.True(t, r.MeanFlipRate() < 0.1)
+	assert.Equal(t, 0.5, r.MaxBias())
+	assert.True(t, r.SACFailures(DefaultAlpha) > 384*384*9/10)
+}
+
+func TestAvalancheHash(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	hash, _ := HashFunc(xoodoo.MaxRounds, 32)
+	r, err := Avalanche(hash, 20, 100, rng)
+	assert.NoError(t, err)
+	assert.Equal(t, 160, r.InBits)
+	assert.Equal(t, 256, r.OutBits)
+	assert.InDelta(t, 0.5, r.MeanFlipRate(), 0.01)
+}
+
+func TestAvalancheCounts(t *testing.T) {
+	// The identity flips exactly the input bit
+	identity := func(in []byte) []byte { return append([]byte{}, in...) }
+	r, err := Avalanche(identity, 2, 3, rand.New(rand.NewSource(4)))
+	assert.NoError(t, err)
+	for i := 0; i < 16; i++ {
+		for j := 0; j < 16; j++ {
+			if i == j {
+				assert.Equal(t, 1.0, r.Probability(i, j))
+			} else {
+				assert.Equal(t, 0.0, r.Probability(i, j))
+			}
+		}
+	}
+	assert.Equal(t, 1.0/16, r.MeanFlipRate())
+}
+
+func TestAvalancheErrors(t *testing.T) {
+	identity := func(in []byte) []byte { return in }
+	_, err := Avalanche(identity, 0, 1, rand.New(rand.NewSource(5)))
+	assert.Equal(t, errors.New("randtest: invalid input length (0 bytes) or number of trials (1)"), err)
+	_, err = Avalanche(identity, 4, 1, bytes.NewReader([]byte{1, 2}))
+	assert.Equal(t, errors.New("randtest: reading random bytes: unexpected EOF"), err)
+	growing := func(in []byte) []byte { return make([]byte, int(in[0]&1)+1) }
+	_, err = Avalanche(growing, 1, 1, bytes.NewReader([]byte{0}))
+	assert.Equal(t, errors.New("randtest: output length changed from 1 to 2 bytes"), err)
+}
+
+func BenchmarkSuite(b *testing.B) {
+	data, _ := PermutationStream(xoodoo.MaxRounds, 1<<14)
+	bits := Bits(data)
+	for n := 0; n < b.N; n++ {
+		Suite(bits)
+	}
+}
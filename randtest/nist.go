@@ -0,0 +1,277 @@
+// Package randtest implements statistical tests of the output of Xoodoo and Xoodyak, for sanity
+// checks of reduced-round variants and of optimized implementations. It provides a subset of the
+// NIST SP 800-22 randomness tests (frequency, block frequency, runs, approximate entropy, serial and
+// cumulative sums) along with avalanche and strict avalanche criterion measurements.
+//
+// The SP 800-22 tests take a sequence of bits, each held in a byte as 0 or 1. Bits converts bytes
+// to such a sequence, most significant bit first as done by the NIST reference implementation.
+package randtest
+
+import (
+	"fmt"
+	"math"
+)
+
+// DefaultAlpha is the significance level recommended by SP 800-22
+const DefaultAlpha = 0.01
+
+// Result holds the P-values computed by a test. Some tests compute more than one statistic, and
+// the sequence passes the test when every P-value is at least the chosen significance level.
+type Result struct {
+	Name    string
+	PValues []float64
+}
+
+// Passed reports whether every P-value of the result is at least alpha
+func (r Result) Passed(alpha float64) bool {
+	for _, p := range r.PValues {
+		if !(p >= alpha) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r Result) String() string {
+	return fmt.Sprintf("%s %.6f", r.Name, r.PValues)
+}
+
+// Bits returns the bits of data, most significant bit of each byte first
+func Bits(data []byte) []byte {
+	out := make([]byte, 8*len(data))
+	for i, b := range data {
+		for j := 0; j < 8; j++ {
+			out[8*i+j] = b >> uint(7-j) & 1
+		}
+	}
+	return out
+}
+
+func checkLength(name string, bits []byte, min int) error {
+	if len(bits) < min {
+		return fmt.Errorf("randtest: %s test needs at least %d bits, got %d", name, min, len(bits))
+	}
+	return nil
+}
+
+// Frequency applies the frequency (monobit) test, checking that the sequence has as many ones as
+// zeros
+func Frequency(bits []byte) (Result, error) {
+	if err := checkLength("frequency", bits, 1); err != nil {
+		return Result{}, err
+	}
+	sum := 0
+	for _, b := range bits {
+		sum += 2*int(b) - 1
+	}
+	sObs := math.Abs(float64(sum)) / math.Sqrt(float64(len(bits)))
+	return Result{Name: "Frequency", PValues: []float64{math.Erfc(sObs / math.Sqrt2)}}, nil
+}
+
+// BlockFrequency applies the frequency test within blocks of m bits, checking that each block has
+// as many ones as zeros. Bits after the last complete block are ignored.
+func BlockFrequency(bits []byte, m int) (Result, error) {
+	if m < 1 {
+		return Result{}, fmt.Errorf("randtest: invalid block length: %d", m)
+	}
+	if err := checkLength("block frequency", bits, m); err != nil {
+		return Result{}, err
+	}
+	blocks := len(bits) / m
+	chi2 := 0.0
+	for i := 0; i < blocks; i++ {
+		ones := 0
+		for _, b := range bits[i*m : (i+1)*m] {
+			ones += int(b)
+		}
+		pi := float64(ones)/float64(m) - 0.5
+		chi2 += pi * pi
+	}
+	chi2 *= 4 * float64(m)
+	return Result{Name: "BlockFrequency", PValues: []float64{igamc(float64(blocks)/2, chi2/2)}}, nil
+}
+
+// Runs applies the runs test, checking that the number of runs of identical bits is as expected
+// for a random sequence. Sequences failing the frequency test by a wide margin are given a P-value
+// of zero without counting runs.
+func Runs(bits []byte) (Result, error) {
+	if err := checkLength("runs", bits, 2); err != nil {
+		return Result{}, err
+	}
+	n := float64(len(bits))
+	ones := 0
+	for _, b := range bits {
+		ones += int(b)
+	}
+	pi := float64(ones) / n
+	if math.Abs(pi-0.5) >= 2/math.Sqrt(n) {
+		return Result{Name: "Runs", PValues: []float64{0}}, nil
+	}
+	runs := 1
+	for i := 1; i < len(bits); i++ {
+		if bits[i] != bits[i-1] {
+			runs++
+		}
+	}
+	num := math.Abs(float64(runs) - 2*n*pi*(1-pi))
+	den := 2 * math.Sqrt(2*n) * pi * (1 - pi)
+	return Result{Name: "Runs", PValues: []float64{math.Erfc(num / den)}}, nil
+}
+
+// patternCounts returns the number of occurrences of each m-bit pattern in the sequence, reading
+// patterns at every position and wrapping around at the end
+func patternCounts(bits []byte, m int) []int {
+	counts := make([]int, 1<<uint(m))
+	if m == 0 {
+		counts[0] = len(bits)
+		return counts
+	}
+	n := len(bits)
+	for i := 0; i < n; i++ {
+		v := 0
+		for j := 0; j < m; j++ {
+			v = v<<1 | int(bits[(i+j)%n])
+		}
+		counts[v]++
+	}
+	return counts
+}
+
+// ApproximateEntropy applies the approximate entropy test, comparing the frequencies of
+// overlapping patterns of m and m+1 bits
+func ApproximateEntropy(bits []byte, m int) (Result, error) {
+	if m < 1 || m > 24 {
+		return Result{}, fmt.Errorf("randtest: invalid pattern length: %d", m)
+	}
+	if err := checkLength("approximate entropy", bits, m+1); err != nil {
+		return Result{}, err
+	}
+	n := float64(len(bits))
+	phi := func(m int) float64 {
+		sum := 0.0
+		for _, c := range patternCounts(bits, m) {
+			if c > 0 {
+				p := float64(c) / n
+				sum += p * math.Log(p)
+			}
+		}
+		return sum
+	}
+	apEn := phi(m) - phi(m+1)
+	chi2 := 2 * n * (math.Ln2 - apEn)
+	return Result{Name: "ApproximateEntropy", PValues: []float64{igamc(math.Exp2(float64(m-1)), chi2/2)}}, nil
+}
+
+// Serial applies the serial test, checking that all overlapping patterns of m bits are equally
+// frequent. It gives two P-values.
+func Serial(bits []byte, m int) (Result, error) {
+	if m < 2 || m > 24 {
+		return Result{}, fmt.Errorf("randtest: invalid pattern length: %d", m)
+	}
+	if err := checkLength("serial", bits, m); err != nil {
+		return Result{}, err
+	}
+	n := float64(len(bits))
+	psi2 := func(m int) float64 {
+		if m <= 0 {
+			return 0
+		}
+		sum := 0.0
+		for _, c := range patternCounts(bits, m) {
+			sum += float64(c) * float64(c)
+		}
+		return sum*math.Exp2(float64(m))/n - n
+	}
+	p0, p1, p2 := psi2(m), psi2(m-1), psi2(m-2)
+	del1 := p0 - p1
+	del2 := p0 - 2*p1 + p2
+	return Result{Name: "Serial", PValues: []float64{
+		igamc(math.Exp2(float64(m-2)), del1/2),
+		igamc(math.Exp2(float64(m-3)), del2/2),
+	}}, nil
+}
+
+// CumulativeSums applies the cumulative sums test in the forward and backward directions, checking
+// that the random walk given by the sequence stays close to zero. It gives two P-values.
+func CumulativeSums(bits []byte) (Result, error) {
+	if err := checkLength("cumulative sums", bits, 1); err != nil {
+		return Result{}, err
+	}
+	n := len(bits)
+	maxExcursion := func(forward bool) int {
+		sum, z := 0, 0
+		for i := range bits {
+			b := bits[i]
+			if !forward {
+				b = bits[n-1-i]
+			}
+			sum += 2*int(b) - 1
+			if sum > z {
+				z = sum
+			} else if -sum > z {
+				z = -sum
+			}
+		}
+		return z
+	}
+	pValue := func(z int) float64 {
+		// The bounds of the sums use integer division as in the NIST reference implementation
+		sqrtN := math.Sqrt(float64(n))
+		phi := func(k, j int) float64 {
+			return normalCDF(float64((4*k+j)*z) / sqrtN)
+		}
+		sum1, sum2 := 0.0, 0.0
+		for k := (-n/z + 1) / 4; k <= (n/z-1)/4; k++ {
+			sum1 += phi(k, 1) - phi(k, -1)
+		}
+		for k := (-n/z - 3) / 4; k <= (n/z-1)/4; k++ {
+			sum2 += phi(k, 3) - phi(k, 1)
+		}
+		return 1 - sum1 + sum2
+	}
+	return Result{Name: "CumulativeSums", PValues: []float64{
+		pValue(maxExcursion(true)),
+		pValue(maxExcursion(false)),
+	}}, nil
+}
+
+// Suite applies all SP 800-22 tests of the package to the sequence with the parameters suggested
+// for sequences of at least a million bits: blocks of 128 bits for BlockFrequency, patterns of 10
+// bits for ApproximateEntropy and of 16 bits for Serial. Shorter sequences use shorter blocks and
+// patterns, keeping to the limits recommended by SP 800-22.
+func Suite(bits []byte) ([]Result, error) {
+	n := len(bits)
+	blockLen := 128
+	if n < 100*blockLen {
+		blockLen = n/100 + 1
+	}
+	// SP 800-22 recommends m < log2(n) - 5 for approximate entropy and m < log2(n) - 2 for serial
+	log2n := int(math.Log2(float64(n)))
+	apEnLen, serialLen := 10, 16
+	if apEnLen > log2n-6 {
+		apEnLen = log2n - 6
+	}
+	if serialLen > log2n-3 {
+		serialLen = log2n - 3
+	}
+	if apEnLen < 1 || serialLen < 2 {
+		return nil, fmt.Errorf("randtest: suite needs at least %d bits, got %d", 128, n)
+	}
+	tests := []func() (Result, error){
+		func() (Result, error) { return Frequency(bits) },
+		func() (Result, error) { return BlockFrequency(bits, blockLen) },
+		func() (Result, error) { return Runs(bits) },
+		func() (Result, error) { return ApproximateEntropy(bits, apEnLen) },
+		func() (Result, error) { return Serial(bits, serialLen) },
+		func() (Result, error) { return CumulativeSums(bits) },
+	}
+	results := make([]Result, 0, len(tests))
+	for _, test := range tests {
+		r, err := test()
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+	}
+	return results, nil
+}
@@ -0,0 +1,152 @@
+package randtest
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/inmcm/xoodoo/xoodoo"
+	"github.com/stretchr/testify/assert"
+)
+
+// parseBits converts a string of '0' and '1' characters to a sequence
+func parseBits(s string) []byte {
+	out := make([]byte, len(s))
+	for i, c := range s {
+		out[i] = byte(c - '0')
+	}
+	return out
+}
+
+// epsilon100 is the 100-bit example sequence used throughout SP 800-22 section 2
+var epsilon100 = parseBits("11001001000011111101101010100010001000010110100011" +
+	"00001000110100110001001100011001100010100010111000")
+
+// The P-values below are the worked examples of SP 800-22 Rev. 1a, section 2
+var nistExamplesTestTable = []struct {
+	name    string
+	test    func() (Result, error)
+	pValues []float64
+}{
+	{
+		name:    "Frequency",
+		test:    func() (Result, error) { return Frequency(parseBits("1011010101")) },
+		pValues: []float64{0.527089},
+	},
+	{
+		name:    "Frequency",
+		test:    func() (Result, error) { return Frequency(epsilon100) },
+		pValues: []float64{0.109599},
+	},
+	{
+		name:    "BlockFrequency",
+		test:    func() (Result, error) { return BlockFrequency(parseBits("0110011010"), 3) },
+		pValues: []float64{0.801252},
+	},
+	{
+		name:    "BlockFrequency",
+		test:    func() (Result, error) { return BlockFrequency(epsilon100, 10) },
+		pValues: []float64{0.706438},
+	},
+	{
+		name:    "Runs",
+		test:    func() (Result, error) { return Runs(parseBits("1001101011")) },
+		pValues: []float64{0.147232},
+	},
+	{
+		name:    "Runs",
+		test:    func() (Result, error) { return Runs(epsilon100) },
+		pValues: []float64{0.500798},
+	},
+	{
+		name:    "ApproximateEntropy",
+		test:    func() (Result, error) { return ApproximateEntropy(parseBits("0100110101"), 3) },
+		pValues: []float64{0.261961},
+	},
+	{
+		name:    "ApproximateEntropy",
+		test:    func() (Result, error) { return ApproximateEntropy(epsilon100, 2) },
+		pValues: []float64{0.235301},
+	},
+	{
+		name:    "Serial",
+		test:    func() (Result, error) { return Serial(parseBits("0011011101"), 3) },
+		pValues: []float64{0.808792, 0.670320},
+	},
+	{
+		name:    "CumulativeSums",
+		test:    func() (Result, error) { return CumulativeSums(parseBits("1011010111")) },
+		pValues: []float64{0.4116588, 0.4116588},
+	},
+	{
+		name:    "CumulativeSums",
+		test:    func() (Result, error) { return CumulativeSums(epsilon100) },
+		pValues: []float64{0.219194, 0.114866},
+	},
+}
+
+func TestNISTExamples(t *testing.T) {
+	for _, tt := range nistExamplesTestTable {
+		r, err := tt.test()
+		assert.NoError(t, err)
+		assert.Equal(t, tt.name, r.Name)
+		assert.Len(t, r.PValues, len(tt.pValues))
+		for i, p := range tt.pValues {
+			assert.InDelta(t, p, r.PValues[i], 1e-6, tt.name)
+		}
+	}
+}
+
+func TestBits(t *testing.T) {
+	assert.Equal(t, parseBits("1000000001011010"), Bits([]byte{0x80, 0x5A}))
+	assert.Equal(t, []byte{}, Bits(nil))
+}
+
+func TestSuite(t *testing.T) {
+	data, _ := PermutationStream(xoodoo.MaxRounds, 1<<14)
+	results, err := Suite(Bits(data))
+	assert.NoError(t, err)
+	assert.Len(t, results, 6)
+	for _, r := range results {
+		assert.True(t, r.Passed(DefaultAlpha), r.String())
+	}
+
+	// A biased sequence fails
+	for i := range data {
+		data[i] |= 0x01
+	}
+	results, _ = Suite(Bits(data))
+	for _, r := range results {
+		assert.False(t, r.Passed(DefaultAlpha), r.String())
+	}
+
+	_, err = Suite(make([]byte, 127))
+	assert.Equal(t, errors.New("randtest: suite needs at least 128 bits, got 127"), err)
+}
+
+func TestResult(t *testing.T) {
+	r := Result{Name: "Serial", PValues: []float64{0.5, 0.005}}
+	assert.False(t, r.Passed(DefaultAlpha))
+	assert.True(t, r.Passed(0.001))
+	assert.True(t, strings.HasPrefix(r.String(), "Serial [0.500000 0.005000]"))
+}
+
+var nistErrorsTestTable = []struct {
+	test func() (Result, error)
+	err  error
+}{
+	{test: func() (Result, error) { return Frequency(nil) }, err: errors.New("randtest: frequency test needs at least 1 bits, got 0")},
+	{test: func() (Result, error) { return BlockFrequency(epsilon100, 0) }, err: errors.New("randtest: invalid block length: 0")},
+	{test: func() (Result, error) { return BlockFrequency(epsilon100, 101) }, err: errors.New("randtest: block frequency test needs at least 101 bits, got 100")},
+	{test: func() (Result, error) { return Runs(parseBits("1")) }, err: errors.New("randtest: runs test needs at least 2 bits, got 1")},
+	{test: func() (Result, error) { return ApproximateEntropy(epsilon100, 0) }, err: errors.New("randtest: invalid pattern length: 0")},
+	{test: func() (Result, error) { return Serial(epsilon100, 1) }, err: errors.New("randtest: invalid pattern length: 1")},
+	{test: func() (Result, error) { return CumulativeSums(nil) }, err: errors.New("randtest: cumulative sums test needs at least 1 bits, got 0")},
+}
+
+func TestNISTErrors(t *testing.T) {
+	for _, tt := range nistErrorsTestTable {
+		_, err := tt.test()
+		assert.Equal(t, tt.err, err)
+	}
+}
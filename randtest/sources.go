@@ -0,0 +1,112 @@
+package randtest
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/inmcm/xoodoo/xoodoo"
+	"github.com/inmcm/xoodoo/xoodyak"
+)
+
+// The functions below produce output of the Xoodoo permutation and of Xoodyak with a configurable
+// number of rounds, which are the final rounds of the permutation as done by xoodoo.NewXoodoo.
+// Xoodyak with a reduced number of rounds is obtained by replacing the permutation of the Cyclist
+// object before use; the full number of rounds uses the standard functions directly.
+
+func checkRounds(rounds int) error {
+	if rounds < 1 || rounds > xoodoo.MaxRounds {
+		return fmt.Errorf("randtest: invalid number of rounds: %d", rounds)
+	}
+	return nil
+}
+
+// newXoodyak returns a Xoodyak object in hash mode using the given number of rounds
+func newXoodyak(rounds int) *xoodyak.Xoodyak {
+	xk := xoodyak.Instantiate(nil, nil, nil)
+	xk.Instance, _ = xoodoo.NewXoodoo(rounds, [xoodoo.StateSizeBytes]byte{})
+	return xk
+}
+
+// PermutationFunc returns a function applying the permutation to a 48-byte input
+func PermutationFunc(rounds int) (func(in []byte) []byte, error) {
+	if err := checkRounds(rounds); err != nil {
+		return nil, err
+	}
+	return func(in []byte) []byte {
+		var state [xoodoo.StateSizeBytes]byte
+		copy(state[:], in)
+		xd, _ := xoodoo.NewXoodoo(rounds, state)
+		xd.Permutation()
+		return xd.Bytes()
+	}, nil
+}
+
+// HashFunc returns a function computing the Xoodyak hash of its input with outLen bytes of output,
+// which is HashXoodyakLen when rounds is xoodoo.MaxRounds
+func HashFunc(rounds int, outLen uint) (func(in []byte) []byte, error) {
+	if err := checkRounds(rounds); err != nil {
+		return nil, err
+	}
+	if rounds == xoodoo.MaxRounds {
+		return func(in []byte) []byte {
+			return xoodyak.HashXoodyakLen(in, outLen)
+		}, nil
+	}
+	return func(in []byte) []byte {
+		xk := newXoodyak(rounds)
+		xk.Absorb(in)
+		return xk.Squeeze(outLen)
+	}, nil
+}
+
+// PermutationStream returns n bytes of the states obtained by applying the permutation repeatedly
+// to the all-zero state
+func PermutationStream(rounds, n int) ([]byte, error) {
+	if err := checkRounds(rounds); err != nil {
+		return nil, err
+	}
+	xd, _ := xoodoo.NewXoodoo(rounds, [xoodoo.StateSizeBytes]byte{})
+	out := make([]byte, 0, n+xoodoo.StateSizeBytes)
+	for len(out) < n {
+		xd.Permutation()
+		out = append(out, xd.Bytes()...)
+	}
+	return out[:n], nil
+}
+
+// HashStream returns n bytes of the hashes of successive 64-bit little-endian counter values,
+// each hashLen bytes long
+func HashStream(rounds int, hashLen uint, n int) ([]byte, error) {
+	hash, err := HashFunc(rounds, hashLen)
+	if err != nil {
+		return nil, err
+	}
+	if hashLen == 0 {
+		return nil, fmt.Errorf("randtest: invalid hash length: %d", hashLen)
+	}
+	out := make([]byte, 0, n+int(hashLen))
+	var counter [8]byte
+	for i := uint64(0); len(out) < n; i++ {
+		binary.LittleEndian.PutUint64(counter[:], i)
+		out = append(out, hash(counter[:])...)
+	}
+	return out[:n], nil
+}
+
+// SqueezeStream returns n bytes squeezed from Xoodyak in keyed mode with the given key and nonce
+func SqueezeStream(rounds int, key, nonce []byte, n int) ([]byte, error) {
+	if err := checkRounds(rounds); err != nil {
+		return nil, err
+	}
+	if len(key) == 0 || len(key)+len(nonce) > 43 {
+		return nil, fmt.Errorf("randtest: invalid key length (%d bytes) and nonce length (%d bytes)", len(key), len(nonce))
+	}
+	var xk *xoodyak.Xoodyak
+	if rounds == xoodoo.MaxRounds {
+		xk = xoodyak.Instantiate(append([]byte{}, key...), nonce, nil)
+	} else {
+		xk = newXoodyak(rounds)
+		xk.AbsorbKey(append([]byte{}, key...), nonce, nil)
+	}
+	return xk.Squeeze(uint(n)), nil
+}
@@ -0,0 +1,118 @@
+package randtest
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/inmcm/xoodoo/xoodoo"
+	"github.com/inmcm/xoodoo/xoodyak"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPermutationSources(t *testing.T) {
+	f, err := PermutationFunc(6)
+	assert.NoError(t, err)
+	in := make([]byte, xoodoo.StateSizeBytes)
+	in[3] = 0x42
+	xd, _ := xoodoo.NewXoodoo(6, [xoodoo.StateSizeBytes]byte{3: 0x42})
+	xd.Permutation()
+	assert.Equal(t, xd.Bytes(), f(in))
+
+	stream, err := PermutationStream(6, 100)
+	assert.NoError(t, err)
+	assert.Len(t, stream, 100)
+	xd, _ = xoodoo.NewXoodoo(6, [xoodoo.StateSizeBytes]byte{})
+	xd.Permutation()
+	assert.Equal(t, xd.Bytes(), stream[:48])
+	xd.Permutation()
+	assert.Equal(t, xd.Bytes(), stream[48:96])
+}
+
+func TestHashSources(t *testing.T) {
+	// The Cyclist object with the full number of rounds matches HashXoodyakLen
+	full, err := HashFunc(xoodoo.MaxRounds, 40)
+	assert.NoError(t, err)
+	xk := newXoodyak(xoodoo.MaxRounds)
+	xk.Absorb([]byte("abc"))
+	assert.Equal(t, xk.Squeeze(40), full([]byte("abc")))
+
+	reduced, _ := HashFunc(3, 40)
+	assert.NotEqual(t, full([]byte("abc")), reduced([]byte("abc")))
+
+	stream, err := HashStream(xoodoo.MaxRounds, 32, 70)
+	assert.NoError(t, err)
+	counter := make([]byte, 8)
+	binary.LittleEndian.PutUint64(counter, 2)
+	assert.Equal(t, xoodyak.HashXoodyakLen(counter, 32)[:6], stream[64:])
+}
+
+func TestSqueezeStream(t *testing.T) {
+	key, nonce := []byte("0123456789abcdef"), []byte("fedcba9876543210")
+	stream, err := SqueezeStream(xoodoo.MaxRounds, key, nonce, 50)
+	assert.NoError(t, err)
+	assert.Equal(t, xoodyak.Instantiate(key, nonce, nil).Squeeze(50), stream)
+
+	xk := newXoodyak(xoodoo.MaxRounds)
+	xk.AbsorbKey(append([]byte{}, key...), nonce, nil)
+	assert.Equal(t, xk.Squeeze(50), stream)
+
+	reduced, err := SqueezeStream(4, key, nonce, 50)
+	assert.NoError(t, err)
+	assert.NotEqual(t, stream, reduced)
+}
+
+func TestReducedRoundsFailSuite(t *testing.T) {
+	// Iterating a single round from the zero state gives sparse, easily distinguished output, while
+	// a few more rounds are enough for these tests
+	for _, tt := range []struct {
+		rounds int
+		pass   bool
+	}{{rounds: 1, pass: false}, {rounds: 4, pass: true}} {
+		data, _ := PermutationStream(tt.rounds, 1<<13)
+		results, err := Suite(Bits(data))
+		assert.NoError(t, err)
+		passed := true
+		for _, r := range results {
+			passed = passed && r.Passed(DefaultAlpha)
+		}
+		assert.Equal(t, tt.pass, passed)
+	}
+	data, _ := HashStream(xoodoo.MaxRounds, 32, 1<<13)
+	results, _ := Suite(Bits(data))
+	for _, r := range results {
+		assert.True(t, r.Passed(DefaultAlpha), r.String())
+	}
+}
+
+var sourcesErrorsTestTable = []struct {
+	source func() error
+	err    error
+}{
+	{
+		source: func() error { _, err := PermutationFunc(0); return err },
+		err:    errors.New("randtest: invalid number of rounds: 0"),
+	},
+	{
+		source: func() error { _, err := PermutationStream(13, 10); return err },
+		err:    errors.New("randtest: invalid number of rounds: 13"),
+	},
+	{
+		source: func() error { _, err := HashStream(12, 0, 10); return err },
+		err:    errors.New("randtest: invalid hash length: 0"),
+	},
+	{
+		source: func() error { _, err := SqueezeStream(12, nil, nil, 10); return err },
+		err:    errors.New("randtest: invalid key length (0 bytes) and nonce length (0 bytes)"),
+	},
+	{
+		source: func() error { _, err := SqueezeStream(12, make([]byte, 40), make([]byte, 4), 10); return err },
+		err:    errors.New("randtest: invalid key length (40 bytes) and nonce length (4 bytes)"),
+	},
+}
+
+func TestSourcesErrors(t *testing.T) {
+	for _, tt := range sourcesErrorsTestTable {
+		assert.Equal(t, tt.err, tt.source())
+	}
+}
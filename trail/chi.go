@@ -0,0 +1,138 @@
+package trail
+
+import (
+	"math/bits"
+
+	"github.com/inmcm/xoodoo/xoodoo"
+)
+
+// chiColumn applies χ to a single column, bit y of the value holding plane y
+func chiColumn(a uint8) uint8 {
+	var b uint8
+	for y := uint(0); y < 3; y++ {
+		a1, a2 := a>>((y+1)%3)&1, a>>((y+2)%3)&1
+		b |= (a>>y&1 ^ (a1^1)&a2) << y
+	}
+	return b
+}
+
+// compatible holds, for each kind and column value at the input of χ, the set of compatible
+// column values at its output as a bitmask over the eight possible values
+var compatible = func() [2][8]uint8 {
+	var table [2][8]uint8
+	for in := uint8(0); in < 8; in++ {
+		for a := uint8(0); a < 8; a++ {
+			table[0][in] |= 1 << (chiColumn(a) ^ chiColumn(a^in))
+		}
+		for out := uint8(0); out < 8; out++ {
+			// Walsh coefficient of χ for input mask in and output mask out
+			sum := 0
+			for a := uint8(0); a < 8; a++ {
+				if bits.OnesCount8(in&a^out&chiColumn(a))&1 == 0 {
+					sum++
+				} else {
+					sum--
+				}
+			}
+			if sum != 0 {
+				table[1][in] |= 1 << out
+			}
+		}
+	}
+	return table
+}()
+
+func (k Kind) table() *[8]uint8 {
+	switch k {
+	case Differential:
+		return &compatible[0]
+	case Linear:
+		return &compatible[1]
+	}
+	panic(invalidKind(k))
+}
+
+// CompatibleColumns returns the column values at the output of χ compatible with the column value
+// at its input, in increasing order. Only the low three bits of in are used.
+func CompatibleColumns(kind Kind, in uint8) []uint8 {
+	set := kind.table()[in&7]
+	out := make([]uint8, 0, bits.OnesCount8(set))
+	for v := uint8(0); v < 8; v++ {
+		if set>>v&1 == 1 {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// activeColumns returns a plane with the bit of each column set when any of its bits are
+func activeColumns(s xoodoo.State) [xoodoo.LaneCount]uint32 {
+	var p [xoodoo.LaneCount]uint32
+	for x := range p {
+		p[x] = s[x] | s[4+x] | s[8+x]
+	}
+	return p
+}
+
+// ActiveColumns returns the number of columns of the state with at least one bit set
+func ActiveColumns(s xoodoo.State) int {
+	n := 0
+	for _, lane := range activeColumns(s) {
+		n += bits.OnesCount32(lane)
+	}
+	return n
+}
+
+// Weight returns the weight of χ for the pattern at its input, which is the restriction weight
+// of a difference or the correlation weight of a mask. It is the same for both kinds.
+func Weight(s xoodoo.State) int {
+	return 2 * ActiveColumns(s)
+}
+
+// Compatible reports whether the pattern at the output of χ is compatible with the pattern at its
+// input
+func Compatible(kind Kind, in, out xoodoo.State) bool {
+	table := kind.table()
+	for x := 0; x < xoodoo.LaneCount; x++ {
+		for z := 0; z < xoodoo.LaneSizeBits; z++ {
+			if table[in.Column(x, z)]>>out.Column(x, z)&1 == 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// EnumerateCompatible calls fn for each pattern at the output of χ compatible with the pattern at
+// its input, stopping early when fn returns false. The number of patterns is 2^Weight(in), so the
+// enumeration is only practical for patterns with few active columns.
+func EnumerateCompatible(kind Kind, in xoodoo.State, fn func(out xoodoo.State) bool) {
+	type column struct {
+		x, z    int
+		choices []uint8
+	}
+	var columns []column
+	active := activeColumns(in)
+	for x := 0; x < xoodoo.LaneCount; x++ {
+		for lane := active[x]; lane != 0; lane &= lane - 1 {
+			z := bits.TrailingZeros32(lane)
+			columns = append(columns, column{x, z, CompatibleColumns(kind, in.Column(x, z))})
+		}
+	}
+	var out xoodoo.State
+	var walk func(i int) bool
+	walk = func(i int) bool {
+		if i == len(columns) {
+			return fn(out)
+		}
+		c := columns[i]
+		for _, v := range c.choices {
+			out.SetColumn(c.x, c.z, v)
+			if !walk(i + 1) {
+				return false
+			}
+		}
+		return true
+	}
+	walk(0)
+}
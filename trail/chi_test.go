@@ -0,0 +1,140 @@
+package trail
+
+import (
+	"math/bits"
+	"math/rand"
+	"testing"
+
+	"github.com/inmcm/xoodoo/xoodoo"
+	"github.com/stretchr/testify/assert"
+)
+
+func randomState(rng *rand.Rand) xoodoo.State {
+	var s xoodoo.State
+	for i := range s {
+		s[i] = rng.Uint32()
+	}
+	return s
+}
+
+// sparseState returns a state with the given number of random bits set
+func sparseState(rng *rand.Rand, n int) xoodoo.State {
+	var s xoodoo.State
+	for i := 0; i < n; i++ {
+		s.SetBit(rng.Intn(4), rng.Intn(3), rng.Intn(32), 1)
+	}
+	return s
+}
+
+func TestChiColumnMatchesState(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	s := randomState(rng)
+	expected := s
+	expected.Chi()
+	for x := 0; x < 4; x++ {
+		for z := 0; z < 32; z++ {
+			assert.Equal(t, expected.Column(x, z), chiColumn(s.Column(x, z)))
+		}
+	}
+}
+
+func TestCompatibleColumns(t *testing.T) {
+	for _, kind := range []Kind{Differential, Linear} {
+		assert.Equal(t, []uint8{0}, CompatibleColumns(kind, 0))
+		for in := uint8(1); in < 8; in++ {
+			out := CompatibleColumns(kind, in)
+			assert.Len(t, out, 4)
+			assert.NotContains(t, out, uint8(0))
+		}
+	}
+
+	// Every output difference of χ is equally likely for each compatible output
+	for in := uint8(1); in < 8; in++ {
+		counts := map[uint8]int{}
+		for a := uint8(0); a < 8; a++ {
+			counts[chiColumn(a)^chiColumn(a^in)]++
+		}
+		for _, out := range CompatibleColumns(Differential, in) {
+			assert.Equal(t, 2, counts[out])
+		}
+	}
+
+	// Every compatible pair of masks has correlation ±1/2
+	for in := uint8(1); in < 8; in++ {
+		for _, out := range CompatibleColumns(Linear, in) {
+			sum := 0
+			for a := uint8(0); a < 8; a++ {
+				sum += 1 - 2*(bits.OnesCount8(in&a^out&chiColumn(a))&1)
+			}
+			assert.Equal(t, 16, sum*sum)
+		}
+	}
+	assert.Equal(t, []uint8{1, 3, 5, 7}, CompatibleColumns(Differential, 1))
+	assert.Equal(t, []uint8{1, 3, 5, 7}, CompatibleColumns(Linear, 1))
+}
+
+func TestWeight(t *testing.T) {
+	var s xoodoo.State
+	assert.Equal(t, 0, Weight(s))
+	s.SetBit(1, 0, 3, 1)
+	s.SetBit(1, 2, 3, 1)
+	assert.Equal(t, 1, ActiveColumns(s))
+	assert.Equal(t, 2, Weight(s))
+	s.SetBit(3, 1, 31, 1)
+	assert.Equal(t, 4, Weight(s))
+	assert.Equal(t, 2*128, Weight(xoodoo.State{0xFFFFFFFF, 0xFFFFFFFF, 0xFFFFFFFF, 0xFFFFFFFF}))
+}
+
+func TestEnumerateCompatible(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for _, kind := range []Kind{Differential, Linear} {
+		in := sparseState(rng, 4)
+		seen := map[xoodoo.State]bool{}
+		EnumerateCompatible(kind, in, func(out xoodoo.State) bool {
+			assert.True(t, Compatible(kind, in, out))
+			assert.Equal(t, activeColumns(in), activeColumns(out))
+			seen[out] = true
+			return true
+		})
+		assert.Len(t, seen, 1<<uint(Weight(in)))
+
+		calls := 0
+		EnumerateCompatible(kind, in, func(out xoodoo.State) bool {
+			calls++
+			return calls < 3
+		})
+		assert.Equal(t, 3, calls)
+
+		calls = 0
+		EnumerateCompatible(kind, xoodoo.State{}, func(out xoodoo.State) bool {
+			assert.Equal(t, xoodoo.State{}, out)
+			calls++
+			return true
+		})
+		assert.Equal(t, 1, calls)
+	}
+}
+
+func TestDifferentialChi(t *testing.T) {
+	// The difference at the output of χ for any pair is compatible with the input difference
+	rng := rand.New(rand.NewSource(3))
+	for i := 0; i < 100; i++ {
+		a, d := randomState(rng), sparseState(rng, 6)
+		b := xoodoo.XorState(a, d)
+		a.Chi()
+		b.Chi()
+		assert.True(t, Compatible(Differential, d, xoodoo.XorState(a, b)))
+	}
+	var in, out xoodoo.State
+	in.SetBit(0, 0, 0, 1)
+	out.SetBit(0, 2, 0, 1)
+	assert.False(t, Compatible(Differential, in, out))
+	assert.False(t, Compatible(Differential, xoodoo.State{}, out))
+}
+
+func TestInvalidKind(t *testing.T) {
+	assert.PanicsWithValue(t, "trail: invalid kind: 0", func() { CompatibleColumns(0, 1) })
+	assert.PanicsWithValue(t, "trail: invalid kind: 3", func() { Lambda(3, xoodoo.State{}) })
+	assert.Equal(t, "Kind(3)", Kind(3).String())
+	assert.Equal(t, "linear", Linear.String())
+}
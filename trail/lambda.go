@@ -0,0 +1,99 @@
+package trail
+
+import (
+	"math/bits"
+
+	"github.com/inmcm/xoodoo/xoodoo"
+)
+
+// Differences propagate through a linear map L as L itself, while masks propagate as the inverse
+// of its transpose. The ρ steps only move bits, so their inverse transposes are the steps
+// themselves. For θ, the transpose shifts the column parity by the negated offsets and is
+// therefore the reflection of θ through the origin of the x and z axes.
+
+// reflect maps the bit at (x, y, z) to (-x, y, -z)
+func reflect(s xoodoo.State) xoodoo.State {
+	var r xoodoo.State
+	for y := 0; y < xoodoo.PlaneCount; y++ {
+		for x := 0; x < xoodoo.LaneCount; x++ {
+			r.SetLane(-x, y, bits.RotateLeft32(bits.Reverse32(s.Lane(x, y)), 1))
+		}
+	}
+	return r
+}
+
+// ThetaTranspose applies the transpose of the θ step mapping
+func ThetaTranspose(s xoodoo.State) xoodoo.State {
+	s = reflect(s)
+	s.Theta()
+	return reflect(s)
+}
+
+// InverseThetaTranspose applies the inverse of the transpose of the θ step mapping, which
+// propagates a mask at the input of θ to its output
+func InverseThetaTranspose(s xoodoo.State) xoodoo.State {
+	s = reflect(s)
+	s.InverseTheta()
+	return reflect(s)
+}
+
+// theta propagates the pattern through θ
+func (k Kind) theta(s xoodoo.State) xoodoo.State {
+	switch k {
+	case Differential:
+		s.Theta()
+		return s
+	case Linear:
+		return InverseThetaTranspose(s)
+	}
+	panic(invalidKind(k))
+}
+
+// inverseTheta propagates the pattern backwards through θ
+func (k Kind) inverseTheta(s xoodoo.State) xoodoo.State {
+	switch k {
+	case Differential:
+		s.InverseTheta()
+		return s
+	case Linear:
+		return ThetaTranspose(s)
+	}
+	panic(invalidKind(k))
+}
+
+// ChiInput propagates a pattern at the input of the permutation through θ and ρwest to the input of
+// χ in the first round
+func ChiInput(kind Kind, in xoodoo.State) xoodoo.State {
+	s := kind.theta(in)
+	s.RhoWest()
+	return s
+}
+
+// InverseChiInput returns the pattern at the input of the permutation that ChiInput propagates to
+// the given pattern at the input of χ in the first round
+func InverseChiInput(kind Kind, s xoodoo.State) xoodoo.State {
+	s.InverseRhoWest()
+	return kind.inverseTheta(s)
+}
+
+// Lambda propagates a pattern at the output of χ in one round through ρeast, θ and ρwest to the
+// input of χ in the next round
+func Lambda(kind Kind, out xoodoo.State) xoodoo.State {
+	out.RhoEast()
+	return ChiInput(kind, out)
+}
+
+// InverseLambda returns the pattern at the output of χ that Lambda propagates to the given pattern
+// at the input of χ in the next round
+func InverseLambda(kind Kind, in xoodoo.State) xoodoo.State {
+	s := InverseChiInput(kind, in)
+	s.InverseRhoEast()
+	return s
+}
+
+// ChiOutput propagates a pattern at the output of χ in the final round through ρeast to the output
+// of the permutation
+func ChiOutput(out xoodoo.State) xoodoo.State {
+	out.RhoEast()
+	return out
+}
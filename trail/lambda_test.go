@@ -0,0 +1,73 @@
+package trail
+
+import (
+	"math/bits"
+	"math/rand"
+	"testing"
+
+	"github.com/inmcm/xoodoo/xoodoo"
+	"github.com/stretchr/testify/assert"
+)
+
+// dot returns the inner product of two states over GF(2)
+func dot(a, b xoodoo.State) int {
+	n := 0
+	for i := range a {
+		n += bits.OnesCount32(a[i] & b[i])
+	}
+	return n & 1
+}
+
+func TestThetaTranspose(t *testing.T) {
+	rng := rand.New(rand.NewSource(4))
+	for i := 0; i < 100; i++ {
+		a, u := randomState(rng), randomState(rng)
+		theta := a
+		theta.Theta()
+		assert.Equal(t, dot(u, theta), dot(ThetaTranspose(u), a))
+		assert.Equal(t, u, InverseThetaTranspose(ThetaTranspose(u)))
+		assert.Equal(t, u, reflect(reflect(u)))
+	}
+}
+
+func TestLambda(t *testing.T) {
+	rng := rand.New(rand.NewSource(5))
+	for i := 0; i < 100; i++ {
+		// Differences pass through the linear steps of a round unchanged
+		a, d := randomState(rng), randomState(rng)
+		b := xoodoo.XorState(a, d)
+		for _, s := range []*xoodoo.State{&a, &b} {
+			s.RhoEast()
+			s.Theta()
+			s.RhoWest()
+		}
+		assert.Equal(t, xoodoo.XorState(a, b), Lambda(Differential, d))
+		assert.Equal(t, d, InverseLambda(Differential, Lambda(Differential, d)))
+
+		// Masks preserve the inner product across the linear steps
+		u, x := randomState(rng), randomState(rng)
+		v := Lambda(Linear, u)
+		lx := x
+		lx.RhoEast()
+		lx.Theta()
+		lx.RhoWest()
+		assert.Equal(t, dot(u, x), dot(v, lx))
+		assert.Equal(t, u, InverseLambda(Linear, v))
+	}
+}
+
+func TestChiInput(t *testing.T) {
+	rng := rand.New(rand.NewSource(6))
+	for _, kind := range []Kind{Differential, Linear} {
+		in := randomState(rng)
+		assert.Equal(t, in, InverseChiInput(kind, ChiInput(kind, in)))
+	}
+	in := randomState(rng)
+	expected := in
+	expected.Theta()
+	expected.RhoWest()
+	assert.Equal(t, expected, ChiInput(Differential, in))
+	expected = in
+	expected.RhoEast()
+	assert.Equal(t, expected, ChiOutput(in))
+}
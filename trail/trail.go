@@ -0,0 +1,154 @@
+// Package trail provides tools for analysing differential and linear trails of the Xoodoo
+// permutation. Differences and linear masks are represented as xoodoo.State values.
+//
+// Following the Xoodoo specification, the round function is viewed as a linear layer λ between two
+// applications of the non-linear χ step: λ = ρwest ∘ θ ∘ ρeast maps the output of χ in one round to
+// the input of χ in the next. ι has no effect on differences or masks and is ignored. A trail is a
+// sequence of rounds, each given by the pattern at the input and output of χ, and its weight is the
+// sum of the weights of χ in each round.
+//
+// χ acts independently on the 3-bit columns of the state. For differences, a non-zero column
+// difference at its input is compatible with exactly four output differences, each followed with
+// probability 2^-2. For masks, a non-zero column mask at its input is correlated with exactly four
+// output masks, each with correlation ±2^-1. In both cases the weight of a round is therefore twice
+// its number of active columns.
+package trail
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/inmcm/xoodoo/xoodoo"
+)
+
+// Kind selects whether patterns are propagated as differences or as linear masks
+type Kind int
+
+const (
+	// Differential propagates differences, with weights given by the restriction weight of χ
+	Differential Kind = iota + 1
+	// Linear propagates linear masks, with weights given by the correlation weight of χ
+	Linear
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Differential:
+		return "differential"
+	case Linear:
+		return "linear"
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+func invalidKind(k Kind) string {
+	return fmt.Sprintf("trail: invalid kind: %d", int(k))
+}
+
+// Round holds the patterns at the input and output of χ in one round of a trail
+type Round struct {
+	In  xoodoo.State
+	Out xoodoo.State
+}
+
+// Trail is a differential or linear trail over consecutive rounds of Xoodoo. The input of χ in each
+// round after the first is Lambda of the output of χ in the previous round.
+type Trail struct {
+	Kind   Kind
+	Rounds []Round
+}
+
+// Validate checks that the output of χ in each round is compatible with its input and that the
+// rounds are linked by λ
+func (t Trail) Validate() error {
+	if t.Kind != Differential && t.Kind != Linear {
+		return errors.New(invalidKind(t.Kind))
+	}
+	for i, r := range t.Rounds {
+		if i > 0 && r.In != Lambda(t.Kind, t.Rounds[i-1].Out) {
+			return fmt.Errorf("trail: round %d input does not follow from round %d output", i, i-1)
+		}
+		if !Compatible(t.Kind, r.In, r.Out) {
+			return fmt.Errorf("trail: round %d output incompatible with input", i)
+		}
+	}
+	return nil
+}
+
+// RoundWeights returns the weight of χ in each round of the trail
+func (t Trail) RoundWeights() []int {
+	weights := make([]int, len(t.Rounds))
+	for i, r := range t.Rounds {
+		weights[i] = Weight(r.In)
+	}
+	return weights
+}
+
+// Weight returns the total weight of the trail after checking it with Validate. A differential
+// trail of weight w is followed by a pair with the input difference with probability 2^-w, and a
+// linear trail of weight w has correlation ±2^-w/2.
+func (t Trail) Weight() (int, error) {
+	if err := t.Validate(); err != nil {
+		return 0, err
+	}
+	total := 0
+	for _, w := range t.RoundWeights() {
+		total += w
+	}
+	return total, nil
+}
+
+// Input returns the pattern at the input of the permutation that leads into the trail
+func (t Trail) Input() xoodoo.State {
+	if len(t.Rounds) == 0 {
+		return xoodoo.State{}
+	}
+	return InverseChiInput(t.Kind, t.Rounds[0].In)
+}
+
+// Output returns the pattern at the output of the permutation that the trail leads to
+func (t Trail) Output() xoodoo.State {
+	if len(t.Rounds) == 0 {
+		return xoodoo.State{}
+	}
+	return ChiOutput(t.Rounds[len(t.Rounds)-1].Out)
+}
+
+// Extend appends rounds to a copy of the trail, starting from the given input of χ when the trail is
+// empty. In each new round but the last, the output of χ is chosen among the compatible patterns to
+// minimize the weight of the following round. As all compatible patterns are enumerated, the weight
+// of those rounds may not exceed maxWeight. The output of χ in the last round does not affect the
+// weight of the trail and is the first compatible pattern.
+func (t Trail) Extend(in xoodoo.State, rounds, maxWeight int) (Trail, error) {
+	if t.Kind != Differential && t.Kind != Linear {
+		return Trail{}, errors.New(invalidKind(t.Kind))
+	}
+	out := Trail{Kind: t.Kind, Rounds: append([]Round{}, t.Rounds...)}
+	if len(out.Rounds) > 0 {
+		in = Lambda(t.Kind, out.Rounds[len(out.Rounds)-1].Out)
+	}
+	for i := 0; i < rounds; i++ {
+		var best, next xoodoo.State
+		if i == rounds-1 {
+			EnumerateCompatible(t.Kind, in, func(candidate xoodoo.State) bool {
+				best = candidate
+				return false
+			})
+		} else {
+			if w := Weight(in); w > maxWeight {
+				return Trail{}, fmt.Errorf("trail: round %d weight %d exceeds limit %d", len(out.Rounds), w, maxWeight)
+			}
+			bestWeight := -1
+			EnumerateCompatible(t.Kind, in, func(candidate xoodoo.State) bool {
+				n := Lambda(t.Kind, candidate)
+				if w := Weight(n); bestWeight < 0 || w < bestWeight {
+					best, next, bestWeight = candidate, n, w
+				}
+				return true
+			})
+		}
+		out.Rounds = append(out.Rounds, Round{In: in, Out: best})
+		in = next
+	}
+	return out, nil
+}
@@ -0,0 +1,151 @@
+package trail
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/inmcm/xoodoo/xoodoo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDifferentialTrailFollowed(t *testing.T) {
+	// A pair following a trail through the permutation shows the trail's differences at each round
+	rng := rand.New(rand.NewSource(7))
+	var in xoodoo.State
+	in.SetBit(0, 0, 0, 1)
+	in.SetBit(0, 1, 0, 1)
+	tr, err := Trail{Kind: Differential}.Extend(ChiInput(Differential, in), 2, 64)
+	assert.NoError(t, err)
+	assert.Equal(t, in, tr.Input())
+	w, err := tr.Weight()
+	assert.NoError(t, err)
+
+	followed := 0
+	const pairs = 1 << 14
+	for i := 0; i < pairs; i++ {
+		a := randomState(rng)
+		b := xoodoo.XorState(a, in)
+		ok := true
+		for r, round := range tr.Rounds {
+			a.Theta()
+			a.RhoWest()
+			a.Iota(10 + r)
+			b.Theta()
+			b.RhoWest()
+			b.Iota(10 + r)
+			if xoodoo.XorState(a, b) != round.In {
+				t.Fatal("difference at χ input does not follow the trail")
+			}
+			a.Chi()
+			b.Chi()
+			if xoodoo.XorState(a, b) != round.Out {
+				ok = false
+				break
+			}
+			a.RhoEast()
+			b.RhoEast()
+		}
+		if ok {
+			assert.Equal(t, tr.Output(), xoodoo.XorState(a, b))
+			followed++
+		}
+	}
+	// The expected number of pairs is pairs*2^-w, allow for some deviation
+	expected := pairs >> uint(w)
+	assert.InDelta(t, expected, followed, float64(expected)/2+1)
+}
+
+func TestLinearTrail(t *testing.T) {
+	rng := rand.New(rand.NewSource(8))
+	tr, err := Trail{Kind: Linear}.Extend(sparseState(rng, 2), 2, 64)
+	assert.NoError(t, err)
+	assert.NoError(t, tr.Validate())
+	weights := tr.RoundWeights()
+	assert.Len(t, weights, 2)
+
+	// Extending an existing trail continues from its last round, and the final round of an
+	// extension is not subject to the weight limit
+	longer, err := tr.Extend(xoodoo.State{}, 1, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, tr.Rounds, longer.Rounds[:2])
+	assert.Equal(t, Lambda(Linear, tr.Rounds[1].Out), longer.Rounds[2].In)
+	total, err := longer.Weight()
+	assert.NoError(t, err)
+	assert.Equal(t, weights[0]+weights[1]+Weight(longer.Rounds[2].In), total)
+	assert.Equal(t, InverseChiInput(Linear, tr.Rounds[0].In), longer.Input())
+	assert.Equal(t, ChiOutput(longer.Rounds[2].Out), longer.Output())
+}
+
+// TestTwoRoundBound searches all two-round trails starting from one or two active bits at the
+// input of χ, which include the lightest two-round trails of weight 8 given in the Xoodoo paper
+func TestTwoRoundBound(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping two-round trail search in short mode")
+	}
+	for _, kind := range []Kind{Differential, Linear} {
+		best := -1
+		var bestTrail Trail
+		for p1 := 0; p1 < 384; p1++ {
+			for p2 := p1; p2 < 384; p2++ {
+				var in xoodoo.State
+				in.SetBit(p1&3, p1>>2%3, p1/12, 1)
+				in.SetBit(p2&3, p2>>2%3, p2/12, 1)
+				tr, _ := Trail{Kind: kind}.Extend(in, 2, 4)
+				if w, _ := tr.Weight(); best < 0 || w < best {
+					best, bestTrail = w, tr
+				}
+			}
+		}
+		assert.Equal(t, 8, best, kind.String())
+		assert.Equal(t, []int{4, 4}, bestTrail.RoundWeights())
+	}
+}
+
+var trailErrorsTestTable = []struct {
+	trail func(in xoodoo.State) Trail
+	err   error
+}{
+	{
+		trail: func(in xoodoo.State) Trail { return Trail{Kind: 5} },
+		err:   errors.New("trail: invalid kind: 5"),
+	},
+	{
+		trail: func(in xoodoo.State) Trail {
+			return Trail{Kind: Differential, Rounds: []Round{{In: in, Out: xoodoo.State{}}}}
+		},
+		err: errors.New("trail: round 0 output incompatible with input"),
+	},
+	{
+		trail: func(in xoodoo.State) Trail {
+			return Trail{Kind: Linear, Rounds: []Round{{In: in, Out: in}, {In: in, Out: in}}}
+		},
+		err: errors.New("trail: round 1 input does not follow from round 0 output"),
+	},
+}
+
+func TestTrailErrors(t *testing.T) {
+	var in xoodoo.State
+	in.SetBit(0, 0, 0, 1)
+	for _, tt := range trailErrorsTestTable {
+		w, err := tt.trail(in).Weight()
+		assert.Equal(t, 0, w)
+		assert.Equal(t, tt.err, err)
+	}
+	_, err := Trail{Kind: Differential}.Extend(in, 3, 8)
+	assert.Equal(t, errors.New("trail: round 1 weight 14 exceeds limit 8"), err)
+	_, err = Trail{}.Extend(in, 1, 8)
+	assert.Equal(t, errors.New("trail: invalid kind: 0"), err)
+	empty := Trail{Kind: Differential}
+	assert.Equal(t, xoodoo.State{}, empty.Input())
+	assert.Equal(t, xoodoo.State{}, empty.Output())
+}
+
+func BenchmarkExtend(b *testing.B) {
+	var in xoodoo.State
+	in.SetBit(0, 0, 0, 1)
+	in.SetBit(0, 1, 0, 1)
+	for n := 0; n < b.N; n++ {
+		Trail{Kind: Differential}.Extend(in, 3, 64)
+	}
+}
@@ -0,0 +1,90 @@
+package xoodoo
+
+const (
+	// PlaneCount is the number of planes (y coordinate) in the Xoodoo state
+	PlaneCount = 3
+	// LaneCount is the number of lanes (x coordinate) in each plane of the Xoodoo state
+	LaneCount = 4
+	// LaneSizeBits is the number of bits (z coordinate) in each lane of the Xoodoo state
+	LaneSizeBits = 32
+)
+
+// The methods below view the state in the coordinates of the specification: 3 planes (y) of
+// 4 lanes (x) of 32 bits (z), where lane (x, y) is stored at State[4*y+x] and bit z is bit z of
+// that word. As in the specification, x is taken modulo 4 and z modulo 32 so shifted coordinates
+// may be passed directly. The plane index y must be 0, 1 or 2.
+
+func laneIndex(x, y int) int {
+	return LaneCount*y + (x & (LaneCount - 1))
+}
+
+func bitIndex(z int) uint {
+	return uint(z & (LaneSizeBits - 1))
+}
+
+// Plane returns the four lanes of plane y
+func (xds *State) Plane(y int) [LaneCount]uint32 {
+	var p [LaneCount]uint32
+	copy(p[:], xds[LaneCount*y:LaneCount*(y+1)])
+	return p
+}
+
+// SetPlane overwrites the four lanes of plane y
+func (xds *State) SetPlane(y int, p [LaneCount]uint32) {
+	copy(xds[LaneCount*y:LaneCount*(y+1)], p[:])
+}
+
+// Lane returns the 32-bit lane at position (x, y)
+func (xds *State) Lane(x, y int) uint32 {
+	return xds[laneIndex(x, y)]
+}
+
+// SetLane overwrites the 32-bit lane at position (x, y)
+func (xds *State) SetLane(x, y int, v uint32) {
+	xds[laneIndex(x, y)] = v
+}
+
+// Bit returns the value (0 or 1) of the bit at position (x, y, z)
+func (xds *State) Bit(x, y, z int) uint8 {
+	return uint8(xds[laneIndex(x, y)]>>bitIndex(z)) & 1
+}
+
+// SetBit sets the bit at position (x, y, z) to the least significant bit of v
+func (xds *State) SetBit(x, y, z int, v uint8) {
+	i, shift := laneIndex(x, y), bitIndex(z)
+	xds[i] = xds[i]&^(1<<shift) | uint32(v&1)<<shift
+}
+
+// FlipBit complements the bit at position (x, y, z)
+func (xds *State) FlipBit(x, y, z int) {
+	xds[laneIndex(x, y)] ^= 1 << bitIndex(z)
+}
+
+// Column returns the three bits of column (x, z) packed into the low bits of a byte, with the
+// bit of plane y at bit position y
+func (xds *State) Column(x, z int) uint8 {
+	return xds.Bit(x, 0, z) | xds.Bit(x, 1, z)<<1 | xds.Bit(x, 2, z)<<2
+}
+
+// SetColumn overwrites the three bits of column (x, z) from the low bits of c, using the same
+// packing as Column
+func (xds *State) SetColumn(x, z int, c uint8) {
+	xds.SetBit(x, 0, z, c)
+	xds.SetBit(x, 1, z, c>>1)
+	xds.SetBit(x, 2, z, c>>2)
+}
+
+// ColumnParity returns the parity (0 or 1) of the three bits of column (x, z)
+func (xds *State) ColumnParity(x, z int) uint8 {
+	return xds.Bit(x, 0, z) ^ xds.Bit(x, 1, z) ^ xds.Bit(x, 2, z)
+}
+
+// ParityPlane returns the parity of every column as a plane, i.e. the sum of the three planes.
+// This is the plane P computed at the start of theta.
+func (xds *State) ParityPlane() [LaneCount]uint32 {
+	var p [LaneCount]uint32
+	for x := range p {
+		p[x] = xds[x] ^ xds[LaneCount+x] ^ xds[2*LaneCount+x]
+	}
+	return p
+}
@@ -0,0 +1,80 @@
+package xoodoo
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlaneAndLaneAccess(t *testing.T) {
+	s := State{0, 1, 2, 3, 10, 11, 12, 13, 20, 21, 22, 23}
+	assert.Equal(t, [LaneCount]uint32{10, 11, 12, 13}, s.Plane(1))
+	assert.Equal(t, uint32(22), s.Lane(2, 2))
+	assert.Equal(t, uint32(13), s.Lane(-1, 1))
+	assert.Equal(t, uint32(0), s.Lane(4, 0))
+
+	s.SetPlane(2, [LaneCount]uint32{5, 6, 7, 8})
+	s.SetLane(1, 0, 0xAA)
+	assert.Equal(t, State{0, 0xAA, 2, 3, 10, 11, 12, 13, 5, 6, 7, 8}, s)
+}
+
+var bitAccessTestTable = []struct {
+	x, y, z int
+	state   State
+}{
+	{x: 0, y: 0, z: 0, state: State{0x00000001}},
+	{x: 3, y: 0, z: 31, state: State{0, 0, 0, 0x80000000}},
+	{x: 1, y: 2, z: 8, state: State{0, 0, 0, 0, 0, 0, 0, 0, 0, 0x00000100}},
+	{x: 5, y: 1, z: 33, state: State{0, 0, 0, 0, 0, 0x00000002}},
+	{x: -1, y: 1, z: -1, state: State{0, 0, 0, 0, 0, 0, 0, 0x80000000}},
+}
+
+func TestBitAccess(t *testing.T) {
+	for _, tt := range bitAccessTestTable {
+		var s State
+		s.SetBit(tt.x, tt.y, tt.z, 1)
+		assert.Equal(t, tt.state, s)
+		assert.Equal(t, uint8(1), s.Bit(tt.x, tt.y, tt.z))
+		assert.Equal(t, uint8(1), s.ColumnParity(tt.x, tt.z))
+		assert.Equal(t, uint8(1)<<uint(tt.y), s.Column(tt.x, tt.z))
+		s.FlipBit(tt.x, tt.y, tt.z)
+		assert.Equal(t, State{}, s)
+		s.FlipBit(tt.x, tt.y, tt.z)
+		s.SetBit(tt.x, tt.y, tt.z, 0)
+		assert.Equal(t, State{}, s)
+	}
+}
+
+func TestColumns(t *testing.T) {
+	rng := rand.New(rand.NewSource(8))
+	s := randomState(rng)
+	var rebuilt State
+	for x := 0; x < LaneCount; x++ {
+		for z := 0; z < LaneSizeBits; z++ {
+			c := s.Column(x, z)
+			rebuilt.SetColumn(x, z, c)
+			parity := c&1 ^ c>>1&1 ^ c>>2
+			assert.Equal(t, parity, s.ColumnParity(x, z))
+			assert.Equal(t, parity, uint8(s.ParityPlane()[x]>>uint(z))&1)
+		}
+	}
+	assert.Equal(t, s, rebuilt)
+}
+
+func TestParityPlaneMatchesTheta(t *testing.T) {
+	rng := rand.New(rand.NewSource(9))
+	s := randomState(rng)
+	p := s.ParityPlane()
+	theta := s
+	theta.Theta()
+	// theta adds the same effect E to every plane, so the difference of each plane equals E
+	for x := 0; x < LaneCount; x++ {
+		src := p[(x+3)&3]
+		e := src<<5 | src>>27
+		e ^= src<<14 | src>>18
+		for y := 0; y < PlaneCount; y++ {
+			assert.Equal(t, e, theta.Lane(x, y)^s.Lane(x, y))
+		}
+	}
+}
@@ -0,0 +1,32 @@
+//go:build amd64 && !purego
+// +build amd64,!purego
+
+package xoodoo
+
+// cpuid and xgetbv are implemented in xoodoo_amd64.s
+func cpuid(eaxArg, ecxArg uint32) (eax, ebx, ecx, edx uint32)
+func xgetbv() (eax, edx uint32)
+
+var hasAVX2, hasAVX512 = detectFeatures()
+
+// detectFeatures reports whether the CPU and operating system support the AVX2 and
+// AVX-512 (F and VL) instructions used by the assembly routines
+func detectFeatures() (avx2, avx512 bool) {
+	maxID, _, _, _ := cpuid(0, 0)
+	if maxID < 7 {
+		return false, false
+	}
+	_, _, ecx1, _ := cpuid(1, 0)
+	osXSAVE := ecx1&(1<<27) != 0
+	if !osXSAVE || ecx1&(1<<28) == 0 {
+		return false, false
+	}
+	// The OS must preserve the XMM/YMM registers (and opmask/ZMM registers for AVX-512)
+	xcr0, _ := xgetbv()
+	osAVX := xcr0&0x6 == 0x6
+	osAVX512 := osAVX && xcr0&0xe0 == 0xe0
+	_, ebx7, _, _ := cpuid(7, 0)
+	avx2 = osAVX && ebx7&(1<<5) != 0
+	avx512 = osAVX512 && ebx7&(1<<16) != 0 && ebx7&(1<<31) != 0
+	return avx2, avx512
+}
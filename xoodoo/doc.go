@@ -2,6 +2,10 @@
 // other cryptographic primitives and modes can be built. Xoodoo operates on a 384-bit state, realized
 // here as an array of twelve(12) 32-bit unsigned integers, to generate a new pseudo-random state each time
 // the permutation is applied. In addition to the main constructor and permutation functions, a variety
-// of other helper methods are provided to manipulate the underlying state bytes.
+// of other helper methods are provided to manipulate the underlying state bytes, including an
+// allocation-free State-and-Permutation (SnP) style interface for arbitrary byte ranges. The XoodooTimes
+// type applies the permutation to 4, 8 or 16 independent states at once for modes that can process
+// several states in parallel. For cryptanalysis, NewXoodooVariant instantiates Xoodoo-like permutations
+// with custom round constants, rotation offsets and round windows described by a Variant.
 //
 package xoodoo
@@ -2,6 +2,7 @@ package xoodoo_test
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/inmcm/xoodoo/xoodoo"
 )
@@ -14,3 +15,18 @@ func Example() {
 	// Output: Starting State:[]byte{0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0}
 	// Permuted State:[]byte{0x8d, 0xd8, 0xd5, 0x89, 0xbf, 0xfc, 0x63, 0xa9, 0x19, 0x2d, 0x23, 0x1b, 0x14, 0xa0, 0xa5, 0xff, 0x6, 0x81, 0xb1, 0x36, 0xfe, 0xc1, 0xc7, 0xaf, 0xbe, 0x7c, 0xe5, 0xae, 0xbd, 0x40, 0x75, 0xa7, 0x70, 0xe8, 0x86, 0x2e, 0xc9, 0xb7, 0xf5, 0xfe, 0xf2, 0xad, 0x4f, 0x8b, 0x62, 0x40, 0x4f, 0x5e}
 }
+
+func ExampleXoodoo_SetTracer() {
+	newXoodoo, _ := xoodoo.NewXoodoo(1, [xoodoo.StateSizeBytes]byte{})
+	trace := &xoodoo.Trace{}
+	newXoodoo.SetTracer(trace.Record)
+	newXoodoo.Permutation()
+	trace.WriteTo(os.Stdout)
+	// Output:
+	// Input    a00 00000000, a01 00000000, a02 00000000, a03 00000000, a10 00000000, a11 00000000, a12 00000000, a13 00000000, a20 00000000, a21 00000000, a22 00000000, a23 00000000
+	// Theta    a00 00000000, a01 00000000, a02 00000000, a03 00000000, a10 00000000, a11 00000000, a12 00000000, a13 00000000, a20 00000000, a21 00000000, a22 00000000, a23 00000000
+	// Rho-west a00 00000000, a01 00000000, a02 00000000, a03 00000000, a10 00000000, a11 00000000, a12 00000000, a13 00000000, a20 00000000, a21 00000000, a22 00000000, a23 00000000
+	// Iota     a00 00000012, a01 00000000, a02 00000000, a03 00000000, a10 00000000, a11 00000000, a12 00000000, a13 00000000, a20 00000000, a21 00000000, a22 00000000, a23 00000000
+	// Chi      a00 00000012, a01 00000000, a02 00000000, a03 00000000, a10 00000012, a11 00000000, a12 00000000, a13 00000000, a20 00000000, a21 00000000, a22 00000000, a23 00000000
+	// Rho-east a00 00000012, a01 00000000, a02 00000000, a03 00000000, a10 00000024, a11 00000000, a12 00000000, a13 00000000, a20 00000000, a21 00000000, a22 00000000, a23 00000000
+}
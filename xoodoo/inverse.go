@@ -0,0 +1,92 @@
+package xoodoo
+
+import "math/bits"
+
+// InversePermutation executes the inverse of the Xoodoo permutation operation over the provided
+// xoodoo state, such that calling Permutation followed by InversePermutation (with the same number
+// of rounds) leaves the state unchanged
+func (xd *Xoodoo) InversePermutation() {
+	if xd.variant != nil && !xd.variant.fast {
+		xd.variant.inversePermute(&xd.State)
+		return
+	}
+	inversePermuteGeneric(&xd.State, xd.rounds)
+}
+
+// inversePermuteGeneric undoes the final number of rounds of the Xoodoo permutation, applying
+// the inverse of each step mapping in the reverse order
+func inversePermuteGeneric(s *State, rounds int) {
+	var tmp State
+	var p, e [4]uint32
+	for i := MaxRounds - 1; i >= MaxRounds-rounds; i-- {
+		// inverse rho east
+		tmp[0] = s[0]
+		tmp[1] = s[1]
+		tmp[2] = s[2]
+		tmp[3] = s[3]
+
+		tmp[4] = bits.RotateLeft32(s[4], -1)
+		tmp[5] = bits.RotateLeft32(s[5], -1)
+		tmp[6] = bits.RotateLeft32(s[6], -1)
+		tmp[7] = bits.RotateLeft32(s[7], -1)
+
+		tmp[8] = bits.RotateLeft32(s[10], -8)
+		tmp[9] = bits.RotateLeft32(s[11], -8)
+		tmp[10] = bits.RotateLeft32(s[8], -8)
+		tmp[11] = bits.RotateLeft32(s[9], -8)
+
+		// chi is an involution, iota is its own inverse, and the inverse rho west is folded in
+		// when writing back the state
+		s[0] = (^tmp[4] & tmp[8]) ^ tmp[0] ^ RoundConstants[i]
+		s[1] = (^tmp[5] & tmp[9]) ^ tmp[1]
+		s[2] = (^tmp[6] & tmp[10]) ^ tmp[2]
+		s[3] = (^tmp[7] & tmp[11]) ^ tmp[3]
+
+		s[7] = (^tmp[8] & tmp[0]) ^ tmp[4]
+		s[4] = (^tmp[9] & tmp[1]) ^ tmp[5]
+		s[5] = (^tmp[10] & tmp[2]) ^ tmp[6]
+		s[6] = (^tmp[11] & tmp[3]) ^ tmp[7]
+
+		s[8] = bits.RotateLeft32((^tmp[0]&tmp[4])^tmp[8], -11)
+		s[9] = bits.RotateLeft32((^tmp[1]&tmp[5])^tmp[9], -11)
+		s[10] = bits.RotateLeft32((^tmp[2]&tmp[6])^tmp[10], -11)
+		s[11] = bits.RotateLeft32((^tmp[3]&tmp[7])^tmp[11], -11)
+
+		// inverse theta
+		p = [4]uint32{
+			s[0] ^ s[4] ^ s[8],
+			s[1] ^ s[5] ^ s[9],
+			s[2] ^ s[6] ^ s[10],
+			s[3] ^ s[7] ^ s[11],
+		}
+		p = inverseColumnParity(p)
+		e = [4]uint32{
+			bits.RotateLeft32(p[3], 5) ^ bits.RotateLeft32(p[3], 14),
+			bits.RotateLeft32(p[0], 5) ^ bits.RotateLeft32(p[0], 14),
+			bits.RotateLeft32(p[1], 5) ^ bits.RotateLeft32(p[1], 14),
+			bits.RotateLeft32(p[2], 5) ^ bits.RotateLeft32(p[2], 14),
+		}
+		for y := 0; y < 12; y += 4 {
+			s[y+0] ^= e[0]
+			s[y+1] ^= e[1]
+			s[y+2] ^= e[2]
+			s[y+3] ^= e[3]
+		}
+	}
+}
+
+// inverseColumnParity recovers the column parity of the state before theta from the column parity
+// after theta. Theta maps the parity p to L(p) = p + p(x-1, z-5) + p(x-1, z-14), and since L^32 is
+// the identity, the inverse is L^31 = L * L^2 * L^4 * L^8 * L^16 where L^(2^k) shifts by 2^k times
+// the original offsets.
+func inverseColumnParity(p [4]uint32) [4]uint32 {
+	for k := uint(0); k < 5; k++ {
+		shift := 1 << k
+		t := p
+		for x := 0; x < 4; x++ {
+			src := t[(x-shift)&3]
+			p[x] = t[x] ^ bits.RotateLeft32(src, (5<<k)%32) ^ bits.RotateLeft32(src, (14<<k)%32)
+		}
+	}
+	return p
+}
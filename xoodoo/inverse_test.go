@@ -0,0 +1,58 @@
+package xoodoo
+
+import (
+	"math/bits"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func BenchmarkXoodooInversePermutation(b *testing.B) {
+	newXD, _ := NewXoodoo(12, [48]byte{})
+	for n := 0; n < b.N; n++ {
+		newXD.InversePermutation()
+	}
+}
+
+func TestInversePermutation(t *testing.T) {
+	for _, tt := range permutationTestTable {
+		var in [StateSizeBytes]byte
+		copy(in[:], tt.outBytes)
+		newXD, _ := NewXoodoo(tt.rounds, in)
+		newXD.InversePermutation()
+		assert.Equal(t, tt.inBytes[:], newXD.Bytes())
+	}
+}
+
+func TestInversePermutationRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(4))
+	for rounds := 1; rounds <= MaxRounds; rounds++ {
+		for trial := 0; trial < 32; trial++ {
+			var in [StateSizeBytes]byte
+			rng.Read(in[:])
+			newXD, _ := NewXoodoo(rounds, in)
+			newXD.Permutation()
+			assert.NotEqual(t, in[:], newXD.Bytes())
+			newXD.InversePermutation()
+			assert.Equal(t, in[:], newXD.Bytes(), "rounds:%d", rounds)
+
+			newXD.InversePermutation()
+			newXD.Permutation()
+			assert.Equal(t, in[:], newXD.Bytes(), "rounds:%d", rounds)
+		}
+	}
+}
+
+func TestInverseColumnParity(t *testing.T) {
+	rng := rand.New(rand.NewSource(5))
+	for trial := 0; trial < 64; trial++ {
+		p := [4]uint32{rng.Uint32(), rng.Uint32(), rng.Uint32(), rng.Uint32()}
+		var theta [4]uint32
+		for x := 0; x < 4; x++ {
+			src := p[(x+3)&3]
+			theta[x] = p[x] ^ bits.RotateLeft32(src, 5) ^ bits.RotateLeft32(src, 14)
+		}
+		assert.Equal(t, p, inverseColumnParity(theta))
+	}
+}
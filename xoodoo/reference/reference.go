@@ -0,0 +1,187 @@
+// Package reference is a deliberately unoptimized, bit-by-bit implementation of the Xoodoo
+// permutation that follows the pseudo-code of the specification as literally as possible. It is
+// intended as an oracle for differential testing of the optimized implementations in the xoodoo
+// package and should not be used for anything where speed matters.
+//
+// The state is held as three planes A_y, each a 4x32 array of bits indexed by [x][z], and every
+// plane shift is performed by moving individual bits:
+//
+//	A ⋘ (t, v) moves the bit at (x, z) to position (x+t mod 4, z+v mod 32)
+package reference
+
+import "github.com/inmcm/xoodoo/xoodoo"
+
+// Plane is a 4x32 array of bits indexed by [x][z], each bit held in its own byte (0 or 1)
+type Plane [4][32]uint8
+
+// State is the 3x4x32 Xoodoo state as three planes indexed by y
+type State [3]Plane
+
+// roundConstants are the constants C_i of the specification for i = -11 up to 0
+var roundConstants = [12]uint32{
+	0x00000058, 0x00000038, 0x000003C0, 0x000000D0,
+	0x00000120, 0x00000014, 0x00000060, 0x0000002C,
+	0x00000380, 0x000000F0, 0x000001A0, 0x00000012,
+}
+
+// RoundConstant returns the constant C_i of round i, where i runs from -11 to 0 as in the
+// specification
+func RoundConstant(i int) Plane {
+	var c Plane
+	for z := 0; z < 32; z++ {
+		c[0][z] = uint8(roundConstants[11+i]>>uint(z)) & 1
+	}
+	return c
+}
+
+// FromState converts a word oriented xoodoo.State (lane (x, y) in word 4y+x) to bits
+func FromState(s xoodoo.State) State {
+	var a State
+	for y := 0; y < 3; y++ {
+		for x := 0; x < 4; x++ {
+			for z := 0; z < 32; z++ {
+				a[y][x][z] = uint8(s[4*y+x]>>uint(z)) & 1
+			}
+		}
+	}
+	return a
+}
+
+// ToState converts the bits back to a word oriented xoodoo.State
+func (a State) ToState() xoodoo.State {
+	var s xoodoo.State
+	for y := 0; y < 3; y++ {
+		for x := 0; x < 4; x++ {
+			for z := 0; z < 32; z++ {
+				s[4*y+x] |= uint32(a[y][x][z]) << uint(z)
+			}
+		}
+	}
+	return s
+}
+
+// Shift returns the plane A ⋘ (t, v)
+func Shift(a Plane, t, v int) Plane {
+	var b Plane
+	for x := 0; x < 4; x++ {
+		for z := 0; z < 32; z++ {
+			b[mod(x+t, 4)][mod(z+v, 32)] = a[x][z]
+		}
+	}
+	return b
+}
+
+// Add returns the bitwise sum (exclusive-or) of two planes
+func Add(a, b Plane) Plane {
+	var c Plane
+	for x := 0; x < 4; x++ {
+		for z := 0; z < 32; z++ {
+			c[x][z] = a[x][z] ^ b[x][z]
+		}
+	}
+	return c
+}
+
+// Complement returns the bitwise complement of a plane
+func Complement(a Plane) Plane {
+	var c Plane
+	for x := 0; x < 4; x++ {
+		for z := 0; z < 32; z++ {
+			c[x][z] = 1 ^ a[x][z]
+		}
+	}
+	return c
+}
+
+// Multiply returns the bitwise product (and) of two planes
+func Multiply(a, b Plane) Plane {
+	var c Plane
+	for x := 0; x < 4; x++ {
+		for z := 0; z < 32; z++ {
+			c[x][z] = a[x][z] & b[x][z]
+		}
+	}
+	return c
+}
+
+// Theta is the column parity mixer:
+//
+//	P ← A0 + A1 + A2
+//	E ← P ⋘ (1, 5) + P ⋘ (1, 14)
+//	Ay ← Ay + E for y ∈ {0, 1, 2}
+func Theta(a State) State {
+	p := Add(Add(a[0], a[1]), a[2])
+	e := Add(Shift(p, 1, 5), Shift(p, 1, 14))
+	for y := 0; y < 3; y++ {
+		a[y] = Add(a[y], e)
+	}
+	return a
+}
+
+// RhoWest is the first plane shift:
+//
+//	A1 ← A1 ⋘ (1, 0)
+//	A2 ← A2 ⋘ (0, 11)
+func RhoWest(a State) State {
+	a[1] = Shift(a[1], 1, 0)
+	a[2] = Shift(a[2], 0, 11)
+	return a
+}
+
+// Iota adds the round constant of round i:
+//
+//	A0 ← A0 + Ci
+func Iota(a State, i int) State {
+	a[0] = Add(a[0], RoundConstant(i))
+	return a
+}
+
+// Chi is the non-linear layer:
+//
+//	B0 ← ¬A1 · A2
+//	B1 ← ¬A2 · A0
+//	B2 ← ¬A0 · A1
+//	Ay ← Ay + By for y ∈ {0, 1, 2}
+func Chi(a State) State {
+	var b State
+	b[0] = Multiply(Complement(a[1]), a[2])
+	b[1] = Multiply(Complement(a[2]), a[0])
+	b[2] = Multiply(Complement(a[0]), a[1])
+	for y := 0; y < 3; y++ {
+		a[y] = Add(a[y], b[y])
+	}
+	return a
+}
+
+// RhoEast is the second plane shift:
+//
+//	A1 ← A1 ⋘ (0, 1)
+//	A2 ← A2 ⋘ (2, 8)
+func RhoEast(a State) State {
+	a[1] = Shift(a[1], 0, 1)
+	a[2] = Shift(a[2], 2, 8)
+	return a
+}
+
+// Round applies round i (from -11 up to 0) of Xoodoo
+func Round(a State, i int) State {
+	a = Theta(a)
+	a = RhoWest(a)
+	a = Iota(a, i)
+	a = Chi(a)
+	a = RhoEast(a)
+	return a
+}
+
+// Permute applies Xoodoo[rounds] to the provided state: rounds i = 1-rounds up to 0
+func Permute(s xoodoo.State, rounds int) xoodoo.State {
+	a := FromState(s)
+	for i := 1 - rounds; i <= 0; i++ {
+		a = Round(a, i)
+	}
+	return a.ToState()
+}
+
+func mod(a, n int) int {
+	return ((a % n) + n) % n
+}
@@ -0,0 +1,153 @@
+package reference
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/inmcm/xoodoo/xoodoo"
+	"github.com/stretchr/testify/assert"
+)
+
+func randomState(rng *rand.Rand) xoodoo.State {
+	var s xoodoo.State
+	for i := range s {
+		s[i] = rng.Uint32()
+	}
+	return s
+}
+
+func TestPermuteKnownAnswer(t *testing.T) {
+	// Xoodoo[12] of the all-zero state
+	expected := xoodoo.State{
+		0x89d5d88d, 0xa963fcbf, 0x1b232d19, 0xffa5a014,
+		0x36b18106, 0xafc7c1fe, 0xaee57cbe, 0xa77540bd,
+		0x2e86e870, 0xfef5b7c9, 0x8b4fadf2, 0x5e4f4062,
+	}
+	assert.Equal(t, expected, Permute(xoodoo.State{}, xoodoo.MaxRounds))
+}
+
+func TestStateConversion(t *testing.T) {
+	rng := rand.New(rand.NewSource(10))
+	s := randomState(rng)
+	assert.Equal(t, s, FromState(s).ToState())
+
+	a := FromState(xoodoo.State{0, 0, 0, 0, 0, 0, 0x00000004})
+	assert.Equal(t, uint8(1), a[1][2][2])
+}
+
+func TestShift(t *testing.T) {
+	var p Plane
+	p[3][30] = 1
+	shifted := Shift(p, 2, 5)
+	assert.Equal(t, uint8(1), shifted[1][3])
+	assert.Equal(t, p, Shift(shifted, -2, -5))
+}
+
+// TestDifferentialPermutation compares the optimized permutation (including any assembly
+// backend) against the reference for random states and every round count
+func TestDifferentialPermutation(t *testing.T) {
+	rng := rand.New(rand.NewSource(11))
+	for rounds := 1; rounds <= xoodoo.MaxRounds; rounds++ {
+		for trial := 0; trial < 8; trial++ {
+			s := randomState(rng)
+			in, _ := s.MarshalBinary()
+			var inBytes [xoodoo.StateSizeBytes]byte
+			copy(inBytes[:], in)
+			xd, _ := xoodoo.NewXoodoo(rounds, inBytes)
+			xd.Permutation()
+			expected := Permute(s, rounds)
+			assert.Equal(t, expected, xd.State, "rounds:%d", rounds)
+
+			xd.InversePermutation()
+			assert.Equal(t, s, xd.State, "rounds:%d", rounds)
+		}
+	}
+}
+
+func TestDifferentialPermutationTimes(t *testing.T) {
+	rng := rand.New(rand.NewSource(12))
+	for _, instances := range []int{xoodoo.Times4, xoodoo.Times8, xoodoo.Times16} {
+		xt, _ := xoodoo.NewXoodooTimes(instances, xoodoo.MaxRounds)
+		inputs := make([]xoodoo.State, instances)
+		for i := range inputs {
+			inputs[i] = randomState(rng)
+			xt.SetInstance(i, inputs[i])
+		}
+		xt.Permutation()
+		for i := range inputs {
+			got, _ := xt.Instance(i)
+			assert.Equal(t, Permute(inputs[i], xoodoo.MaxRounds), got)
+		}
+	}
+}
+
+// FuzzPermutation compares the optimized permutation, its inverse and the multi-state permutation
+// (including any assembly backend) against the reference for fuzzed states and round counts
+func FuzzPermutation(f *testing.F) {
+	f.Add(make([]byte, xoodoo.StateSizeBytes), uint8(xoodoo.MaxRounds))
+	f.Add([]byte("differential fuzzing of the Xoodoo permutation"), uint8(5))
+	f.Add(bytes.Repeat([]byte{0xFF}, xoodoo.StateSizeBytes), uint8(0))
+	f.Fuzz(func(t *testing.T, data []byte, r uint8) {
+		rounds := int(r)%xoodoo.MaxRounds + 1
+		var in [xoodoo.StateSizeBytes]byte
+		copy(in[:], data)
+		xd, err := xoodoo.NewXoodoo(rounds, in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := xd.State
+		expected := Permute(s, rounds)
+		xd.Permutation()
+		assert.Equal(t, expected, xd.State, "rounds:%d", rounds)
+		xd.InversePermutation()
+		assert.Equal(t, s, xd.State, "rounds:%d", rounds)
+
+		xt, err := xoodoo.NewXoodooTimes(xoodoo.Times16, rounds)
+		if err != nil {
+			t.Fatal(err)
+		}
+		inputs := make([]xoodoo.State, xoodoo.Times16)
+		for i := range inputs {
+			inputs[i] = s
+			inputs[i][i%len(s)] ^= uint32(i)
+			xt.SetInstance(i, inputs[i])
+		}
+		xt.Permutation()
+		for i := range inputs {
+			got, _ := xt.Instance(i)
+			assert.Equal(t, Permute(inputs[i], rounds), got, "rounds:%d instance:%d", rounds, i)
+		}
+	})
+}
+
+var stepsTestTable = []struct {
+	name      string
+	reference func(State) State
+	optimized func(*xoodoo.State)
+}{
+	{name: "theta", reference: Theta, optimized: (*xoodoo.State).Theta},
+	{name: "rho west", reference: RhoWest, optimized: (*xoodoo.State).RhoWest},
+	{name: "iota", reference: func(a State) State { return Iota(a, -4) }, optimized: func(s *xoodoo.State) { s.Iota(7) }},
+	{name: "chi", reference: Chi, optimized: (*xoodoo.State).Chi},
+	{name: "rho east", reference: RhoEast, optimized: (*xoodoo.State).RhoEast},
+}
+
+func TestDifferentialSteps(t *testing.T) {
+	rng := rand.New(rand.NewSource(13))
+	for _, tt := range stepsTestTable {
+		for trial := 0; trial < 16; trial++ {
+			s := randomState(rng)
+			expected := tt.reference(FromState(s)).ToState()
+			tt.optimized(&s)
+			assert.Equal(t, expected, s, tt.name)
+		}
+	}
+}
+
+func BenchmarkPermute(b *testing.B) {
+	var s xoodoo.State
+	for n := 0; n < b.N; n++ {
+		s = Permute(s, xoodoo.MaxRounds)
+	}
+}
@@ -0,0 +1,111 @@
+package xoodoo
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// The methods in this file mirror the State-and-Permutation (SnP) interface of the XKCP. They
+// operate on arbitrary byte ranges of the state and use caller provided buffers so that higher
+// level modes can be built without allocating.
+
+// checkRange validates that length bytes starting at offset fall within the state
+func checkRange(op string, offset, length int) error {
+	if offset < 0 || length < 0 || offset+length > StateSizeBytes {
+		return fmt.Errorf("%s range out of bounds offset:%d length:%d", op, offset, length)
+	}
+	return nil
+}
+
+// AddBytes performs an exclusive-or between the provided bytes and the state bytes starting at
+// the given offset. The result is stored in the State.
+func (xds *State) AddBytes(data []byte, offset int) error {
+	if err := checkRange("add bytes", offset, len(data)); err != nil {
+		return err
+	}
+	i := 0
+	for ; i < len(data) && (offset+i)%4 != 0; i++ {
+		xds[(offset+i)>>2] ^= uint32(data[i]) << (8 * ((offset + i) % 4))
+	}
+	for ; i+4 <= len(data); i += 4 {
+		xds[(offset+i)>>2] ^= binary.LittleEndian.Uint32(data[i:])
+	}
+	for ; i < len(data); i++ {
+		xds[(offset+i)>>2] ^= uint32(data[i]) << (8 * ((offset + i) % 4))
+	}
+	return nil
+}
+
+// OverwriteBytes replaces the state bytes starting at the given offset with the provided bytes
+func (xds *State) OverwriteBytes(data []byte, offset int) error {
+	if err := checkRange("overwrite bytes", offset, len(data)); err != nil {
+		return err
+	}
+	for i, b := range data {
+		shift := 8 * ((offset + i) % 4)
+		w := (offset + i) >> 2
+		xds[w] = xds[w]&^(0xFF<<shift) | uint32(b)<<shift
+	}
+	return nil
+}
+
+// OverwriteWithZeroes sets the first byteCount bytes of the state to zero
+func (xds *State) OverwriteWithZeroes(byteCount int) error {
+	if err := checkRange("overwrite with zeroes", 0, byteCount); err != nil {
+		return err
+	}
+	for w := 0; w < byteCount>>2; w++ {
+		xds[w] = 0
+	}
+	if rem := byteCount % 4; rem != 0 {
+		xds[byteCount>>2] &^= 0xFFFFFFFF >> (32 - 8*uint(rem))
+	}
+	return nil
+}
+
+// ExtractBytes copies the state bytes starting at the given offset into the provided output buffer.
+// The number of bytes extracted is the length of the buffer.
+func (xds *State) ExtractBytes(out []byte, offset int) error {
+	if err := checkRange("extract bytes", offset, len(out)); err != nil {
+		return err
+	}
+	for i := range out {
+		out[i] = byte(xds[(offset+i)>>2] >> (8 * ((offset + i) % 4)))
+	}
+	return nil
+}
+
+// ExtractAndAddBytes performs an exclusive-or between the input bytes and the state bytes starting
+// at the given offset, writing the result to the output buffer. The state is not modified. The
+// output buffer must be at least as long as the input, and may be the same slice.
+func (xds *State) ExtractAndAddBytes(in, out []byte, offset int) error {
+	if err := checkRange("extract and add bytes", offset, len(in)); err != nil {
+		return err
+	}
+	if len(out) < len(in) {
+		return fmt.Errorf("extract and add bytes output (%d bytes) shorter than input (%d bytes)", len(out), len(in))
+	}
+	for i := range in {
+		out[i] = in[i] ^ byte(xds[(offset+i)>>2]>>(8*((offset+i)%4)))
+	}
+	return nil
+}
+
+// FastLoopAbsorb repeatedly adds laneCount 32-bit lanes of data to the start of the state and applies
+// the permutation, for as long as a full set of lanes remains. It returns the number of bytes
+// absorbed, which is always a multiple of 4*laneCount.
+func (xd *Xoodoo) FastLoopAbsorb(laneCount int, data []byte) (int, error) {
+	if laneCount <= 0 || laneCount > StateSizeWords {
+		return 0, fmt.Errorf("fast loop absorb lane count out of range:%d", laneCount)
+	}
+	blockSize := 4 * laneCount
+	processed := 0
+	for len(data)-processed >= blockSize {
+		for w := 0; w < laneCount; w++ {
+			xd.State[w] ^= binary.LittleEndian.Uint32(data[processed+4*w:])
+		}
+		xd.Permutation()
+		processed += blockSize
+	}
+	return processed, nil
+}
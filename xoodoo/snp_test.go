@@ -0,0 +1,153 @@
+package xoodoo
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countingState returns a state whose byte i holds the value i
+func countingState() State {
+	var in [StateSizeBytes]byte
+	for i := range in {
+		in[i] = byte(i)
+	}
+	var s State
+	s.UnmarshalBinary(in[:])
+	return s
+}
+
+func stateBytes(s State) []byte {
+	out, _ := s.MarshalBinary()
+	return out
+}
+
+var addBytesTestTable = []struct {
+	data   []byte
+	offset int
+	err    error
+}{
+	{data: []byte{0xFF}, offset: 0},
+	{data: []byte{0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77}, offset: 3},
+	{data: []byte{0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88}, offset: 8},
+	{data: make([]byte, StateSizeBytes), offset: 0},
+	{data: []byte{0xAA, 0xBB}, offset: 46},
+	{data: []byte{}, offset: StateSizeBytes},
+	{data: []byte{0xAA, 0xBB}, offset: 47, err: errors.New("add bytes range out of bounds offset:47 length:2")},
+	{data: []byte{0xAA}, offset: -1, err: errors.New("add bytes range out of bounds offset:-1 length:1")},
+}
+
+func TestAddBytes(t *testing.T) {
+	for _, tt := range addBytesTestTable {
+		s := countingState()
+		expected := stateBytes(s)
+		if tt.err == nil {
+			for i, b := range tt.data {
+				expected[tt.offset+i] ^= b
+			}
+		}
+		gotErr := s.AddBytes(tt.data, tt.offset)
+		assert.Equal(t, tt.err, gotErr)
+		assert.Equal(t, expected, stateBytes(s))
+	}
+}
+
+func TestOverwriteBytes(t *testing.T) {
+	for _, tt := range addBytesTestTable {
+		s := countingState()
+		expected := stateBytes(s)
+		var expectedErr error
+		if tt.err != nil {
+			expectedErr = errors.New(strings.Replace(tt.err.Error(), "add", "overwrite", 1))
+		} else {
+			copy(expected[tt.offset:], tt.data)
+		}
+		gotErr := s.OverwriteBytes(tt.data, tt.offset)
+		assert.Equal(t, expectedErr, gotErr)
+		assert.Equal(t, expected, stateBytes(s))
+	}
+}
+
+func TestOverwriteWithZeroes(t *testing.T) {
+	for count := 0; count <= StateSizeBytes; count++ {
+		s := countingState()
+		expected := stateBytes(s)
+		for i := 0; i < count; i++ {
+			expected[i] = 0
+		}
+		assert.NoError(t, s.OverwriteWithZeroes(count))
+		assert.Equal(t, expected, stateBytes(s))
+	}
+	s := countingState()
+	assert.Equal(t, errors.New("overwrite with zeroes range out of bounds offset:0 length:49"), s.OverwriteWithZeroes(49))
+}
+
+func TestExtractBytes(t *testing.T) {
+	s := countingState()
+	all := stateBytes(s)
+	for offset := 0; offset <= StateSizeBytes; offset++ {
+		for length := 0; offset+length <= StateSizeBytes; length++ {
+			out := make([]byte, length)
+			assert.NoError(t, s.ExtractBytes(out, offset))
+			assert.Equal(t, all[offset:offset+length], out)
+		}
+	}
+	assert.Equal(t, errors.New("extract bytes range out of bounds offset:40 length:9"), s.ExtractBytes(make([]byte, 9), 40))
+}
+
+func TestExtractAndAddBytes(t *testing.T) {
+	s := countingState()
+	in := []byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0x00}
+	out := make([]byte, len(in))
+	assert.NoError(t, s.ExtractAndAddBytes(in, out, 10))
+	assert.Equal(t, []byte{0xF5, 0xF4, 0xF3, 0xF2, 0xF1, 0x0F}, out)
+	assert.Equal(t, countingState(), s)
+
+	// in place operation
+	assert.NoError(t, s.ExtractAndAddBytes(out, out, 10))
+	assert.Equal(t, in, out)
+
+	assert.Equal(t, errors.New("extract and add bytes output (2 bytes) shorter than input (6 bytes)"), s.ExtractAndAddBytes(in, out[:2], 0))
+	assert.Equal(t, errors.New("extract and add bytes range out of bounds offset:45 length:6"), s.ExtractAndAddBytes(in, out, 45))
+}
+
+func TestFastLoopAbsorb(t *testing.T) {
+	data := make([]byte, 100)
+	for i := range data {
+		data[i] = byte(3 * i)
+	}
+	for laneCount := 1; laneCount <= StateSizeWords; laneCount++ {
+		gotXd, _ := NewXoodoo(MaxRounds, [StateSizeBytes]byte{})
+		expectedXd, _ := NewXoodoo(MaxRounds, [StateSizeBytes]byte{})
+		processed, err := gotXd.FastLoopAbsorb(laneCount, data)
+		assert.NoError(t, err)
+		blockSize := 4 * laneCount
+		assert.Equal(t, len(data)-len(data)%blockSize, processed)
+		for i := 0; i+blockSize <= len(data); i += blockSize {
+			expectedXd.State.AddBytes(data[i:i+blockSize], 0)
+			expectedXd.Permutation()
+		}
+		assert.Equal(t, expectedXd.State, gotXd.State)
+	}
+	newXd, _ := NewXoodoo(MaxRounds, [StateSizeBytes]byte{})
+	_, gotErr := newXd.FastLoopAbsorb(13, data)
+	assert.Equal(t, errors.New("fast loop absorb lane count out of range:13"), gotErr)
+}
+
+func BenchmarkAddBytes(b *testing.B) {
+	var s State
+	data := make([]byte, 44)
+	for n := 0; n < b.N; n++ {
+		s.AddBytes(data, 2)
+	}
+}
+
+func BenchmarkExtractBytes(b *testing.B) {
+	var s State
+	out := make([]byte, 24)
+	for n := 0; n < b.N; n++ {
+		s.ExtractBytes(out, 0)
+	}
+}
@@ -0,0 +1,113 @@
+package xoodoo
+
+import "math/bits"
+
+// The step mappings of a single Xoodoo round are provided below as separate methods for teaching
+// and cryptanalysis. A round applies Theta, RhoWest, Iota, Chi and RhoEast in that order, and
+// Permutation is equivalent to applying Round for each round index from MaxRounds-rounds up to
+// MaxRounds-1. Lanes are addressed as State[4*y+x] for plane y and lane x.
+
+// Theta adds to each bit the parity of two neighbouring columns of the previous lane position
+func (xds *State) Theta() {
+	var e [4]uint32
+	for x := 0; x < 4; x++ {
+		p := xds[(x+3)&3] ^ xds[4+(x+3)&3] ^ xds[8+(x+3)&3]
+		e[x] = bits.RotateLeft32(p, 5) ^ bits.RotateLeft32(p, 14)
+	}
+	for i := range xds {
+		xds[i] ^= e[i&3]
+	}
+}
+
+// InverseTheta undoes the Theta step mapping
+func (xds *State) InverseTheta() {
+	var p [4]uint32
+	for x := 0; x < 4; x++ {
+		p[x] = xds[x] ^ xds[4+x] ^ xds[8+x]
+	}
+	p = inverseColumnParity(p)
+	var e [4]uint32
+	for x := 0; x < 4; x++ {
+		src := p[(x+3)&3]
+		e[x] = bits.RotateLeft32(src, 5) ^ bits.RotateLeft32(src, 14)
+	}
+	for i := range xds {
+		xds[i] ^= e[i&3]
+	}
+}
+
+// RhoWest shifts plane 1 by one lane position and rotates each lane of plane 2 by 11 bits
+func (xds *State) RhoWest() {
+	xds[4], xds[5], xds[6], xds[7] = xds[7], xds[4], xds[5], xds[6]
+	for x := 8; x < 12; x++ {
+		xds[x] = bits.RotateLeft32(xds[x], 11)
+	}
+}
+
+// InverseRhoWest undoes the RhoWest step mapping
+func (xds *State) InverseRhoWest() {
+	xds[4], xds[5], xds[6], xds[7] = xds[5], xds[6], xds[7], xds[4]
+	for x := 8; x < 12; x++ {
+		xds[x] = bits.RotateLeft32(xds[x], -11)
+	}
+}
+
+// Iota adds the round constant of the given round index (0 to MaxRounds-1) to lane (0,0).
+// Iota is its own inverse.
+func (xds *State) Iota(round int) {
+	xds[0] ^= RoundConstants[round]
+}
+
+// Chi applies the non-linear mapping a ^= ^b & c along each column of three bits
+func (xds *State) Chi() {
+	for x := 0; x < 4; x++ {
+		a0, a1, a2 := xds[x], xds[4+x], xds[8+x]
+		xds[x] = a0 ^ (^a1 & a2)
+		xds[4+x] = a1 ^ (^a2 & a0)
+		xds[8+x] = a2 ^ (^a0 & a1)
+	}
+}
+
+// InverseChi undoes the Chi step mapping. Chi acting on three bit columns is an involution, so
+// this is identical to Chi.
+func (xds *State) InverseChi() {
+	xds.Chi()
+}
+
+// RhoEast rotates each lane of plane 1 by one bit, and shifts plane 2 by two lane positions while
+// rotating each of its lanes by 8 bits
+func (xds *State) RhoEast() {
+	for x := 4; x < 8; x++ {
+		xds[x] = bits.RotateLeft32(xds[x], 1)
+	}
+	xds[8], xds[9], xds[10], xds[11] = bits.RotateLeft32(xds[10], 8), bits.RotateLeft32(xds[11], 8),
+		bits.RotateLeft32(xds[8], 8), bits.RotateLeft32(xds[9], 8)
+}
+
+// InverseRhoEast undoes the RhoEast step mapping
+func (xds *State) InverseRhoEast() {
+	for x := 4; x < 8; x++ {
+		xds[x] = bits.RotateLeft32(xds[x], -1)
+	}
+	xds[8], xds[9], xds[10], xds[11] = bits.RotateLeft32(xds[10], -8), bits.RotateLeft32(xds[11], -8),
+		bits.RotateLeft32(xds[8], -8), bits.RotateLeft32(xds[9], -8)
+}
+
+// Round applies a single Xoodoo round with the round constant of the given round index
+// (0 to MaxRounds-1) using the separate step mappings
+func (xds *State) Round(round int) {
+	xds.Theta()
+	xds.RhoWest()
+	xds.Iota(round)
+	xds.Chi()
+	xds.RhoEast()
+}
+
+// InverseRound undoes a single Xoodoo round with the round constant of the given round index
+func (xds *State) InverseRound(round int) {
+	xds.InverseRhoEast()
+	xds.InverseChi()
+	xds.Iota(round)
+	xds.InverseRhoWest()
+	xds.InverseTheta()
+}
@@ -0,0 +1,95 @@
+package xoodoo
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func randomState(rng *rand.Rand) State {
+	var s State
+	for i := range s {
+		s[i] = rng.Uint32()
+	}
+	return s
+}
+
+func TestRoundComposition(t *testing.T) {
+	for _, tt := range permutationTestTable {
+		newXD, _ := NewXoodoo(tt.rounds, tt.inBytes)
+		s := newXD.State
+		for i := MaxRounds - tt.rounds; i < MaxRounds; i++ {
+			s.Round(i)
+		}
+		newXD.State = s
+		assert.Equal(t, tt.outBytes, newXD.Bytes())
+
+		for i := MaxRounds - 1; i >= MaxRounds-tt.rounds; i-- {
+			newXD.State.InverseRound(i)
+		}
+		assert.Equal(t, tt.inBytes[:], newXD.Bytes())
+	}
+}
+
+func TestSingleRoundMatchesPermutation(t *testing.T) {
+	rng := rand.New(rand.NewSource(6))
+	for trial := 0; trial < 64; trial++ {
+		s := randomState(rng)
+		newXD := Xoodoo{State: s, rounds: 1}
+		newXD.Permutation()
+		s.Round(MaxRounds - 1)
+		assert.Equal(t, newXD.State, s)
+	}
+}
+
+var stepInversesTestTable = []struct {
+	name    string
+	step    func(*State)
+	inverse func(*State)
+}{
+	{name: "theta", step: (*State).Theta, inverse: (*State).InverseTheta},
+	{name: "rho west", step: (*State).RhoWest, inverse: (*State).InverseRhoWest},
+	{name: "iota", step: func(s *State) { s.Iota(3) }, inverse: func(s *State) { s.Iota(3) }},
+	{name: "chi", step: (*State).Chi, inverse: (*State).InverseChi},
+	{name: "rho east", step: (*State).RhoEast, inverse: (*State).InverseRhoEast},
+	{name: "round", step: func(s *State) { s.Round(7) }, inverse: func(s *State) { s.InverseRound(7) }},
+}
+
+func TestStepInverses(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	for _, tt := range stepInversesTestTable {
+		for trial := 0; trial < 32; trial++ {
+			in := randomState(rng)
+			s := in
+			tt.step(&s)
+			assert.NotEqual(t, in, s, tt.name)
+			tt.inverse(&s)
+			assert.Equal(t, in, s, tt.name)
+		}
+	}
+}
+
+func TestStepMappings(t *testing.T) {
+	// a single bit in lane (0,0) spreads by theta to two columns of lane position 1
+	s := State{0x00000001}
+	s.Theta()
+	assert.Equal(t, State{0x00000001, 0x00004020, 0, 0, 0, 0x00004020, 0, 0, 0, 0x00004020, 0, 0}, s)
+
+	s = State{0, 0, 0, 0, 1, 2, 3, 4, 1, 2, 3, 4}
+	s.RhoWest()
+	assert.Equal(t, State{0, 0, 0, 0, 4, 1, 2, 3, 1 << 11, 2 << 11, 3 << 11, 4 << 11}, s)
+
+	s = State{0, 0, 0, 0, 1, 2, 3, 4, 1, 2, 3, 4}
+	s.RhoEast()
+	assert.Equal(t, State{0, 0, 0, 0, 2, 4, 6, 8, 3 << 8, 4 << 8, 1 << 8, 2 << 8}, s)
+
+	// chi complements plane 0 where plane 1 is clear and plane 2 is set
+	s = State{0, 0, 0, 0, 0, 0, 0, 0, 0xFF, 0, 0, 0}
+	s.Chi()
+	assert.Equal(t, State{0xFF, 0, 0, 0, 0, 0, 0, 0, 0xFF, 0, 0, 0}, s)
+
+	s = State{}
+	s.Iota(0)
+	assert.Equal(t, State{RoundConstants[0]}, s)
+}
@@ -0,0 +1,136 @@
+package xoodoo
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	// Times4 is the number of independent states processed by a Xoodoo×4 object
+	Times4 = 4
+	// Times8 is the number of independent states processed by a Xoodoo×8 object
+	Times8 = 8
+	// Times16 is the number of independent states processed by a Xoodoo×16 object
+	Times16 = 16
+)
+
+// XoodooTimes holds several independent Xoodoo states so that the permutation can be applied
+// to all of them with a single call (similar to the Xoodootimes4/8/16 interfaces of the XKCP).
+// The states are stored interleaved by word: word w of instance i lives at index w*n+i, which
+// allows the same word of every instance to be processed together.
+type XoodooTimes struct {
+	lanes  []uint32
+	n      int
+	rounds int
+}
+
+// NewXoodooTimes returns a new multi-state Xoodoo object holding the requested number of
+// all-zero states (Times4, Times8 or Times16) and configured with the desired number of
+// rounds for the permutation function to execute
+func NewXoodooTimes(instances, rounds int) (*XoodooTimes, error) {
+	if instances != Times4 && instances != Times8 && instances != Times16 {
+		return nil, fmt.Errorf("invalid number of parallel instances: %d", instances)
+	}
+	if rounds > len(RoundConstants) {
+		return nil, fmt.Errorf("invalid number of rounds: %d", rounds)
+	}
+	return &XoodooTimes{
+		lanes:  make([]uint32, StateSizeWords*instances),
+		n:      instances,
+		rounds: rounds,
+	}, nil
+}
+
+// Instances returns the number of independent states held by the object
+func (xt *XoodooTimes) Instances() int {
+	return xt.n
+}
+
+func (xt *XoodooTimes) checkInstance(instance int) error {
+	if instance < 0 || instance >= xt.n {
+		return fmt.Errorf("instance out of range:%d", instance)
+	}
+	return nil
+}
+
+// SetInstance overwrites the state of a single instance with the provided state
+func (xt *XoodooTimes) SetInstance(instance int, s State) error {
+	if err := xt.checkInstance(instance); err != nil {
+		return err
+	}
+	for w := 0; w < StateSizeWords; w++ {
+		xt.lanes[w*xt.n+instance] = s[w]
+	}
+	return nil
+}
+
+// Instance returns a copy of the state of a single instance
+func (xt *XoodooTimes) Instance(instance int) (State, error) {
+	var s State
+	if err := xt.checkInstance(instance); err != nil {
+		return s, err
+	}
+	for w := 0; w < StateSizeWords; w++ {
+		s[w] = xt.lanes[w*xt.n+instance]
+	}
+	return s, nil
+}
+
+// XorBytes performs an exclusive-or between the input bytes and the state of a single instance,
+// starting from offset 0. Up to StateSizeBytes bytes may be provided.
+func (xt *XoodooTimes) XorBytes(instance int, in []byte) error {
+	if err := xt.checkInstance(instance); err != nil {
+		return err
+	}
+	if len(in) > StateSizeBytes {
+		return fmt.Errorf("xor bytes size out of range:%d", len(in))
+	}
+	w := 0
+	for ; (w+1)*4 <= len(in); w++ {
+		xt.lanes[w*xt.n+instance] ^= binary.LittleEndian.Uint32(in[w*4:])
+	}
+	for i := w * 4; i < len(in); i++ {
+		xt.lanes[w*xt.n+instance] ^= uint32(in[i]) << (8 * (i % 4))
+	}
+	return nil
+}
+
+// ExtractBytes copies the leading bytes of the state of a single instance into the provided
+// output buffer. Up to StateSizeBytes bytes may be requested.
+func (xt *XoodooTimes) ExtractBytes(instance int, out []byte) error {
+	if err := xt.checkInstance(instance); err != nil {
+		return err
+	}
+	if len(out) > StateSizeBytes {
+		return fmt.Errorf("extract bytes size out of range:%d", len(out))
+	}
+	w := 0
+	for ; (w+1)*4 <= len(out); w++ {
+		binary.LittleEndian.PutUint32(out[w*4:], xt.lanes[w*xt.n+instance])
+	}
+	for i := w * 4; i < len(out); i++ {
+		out[i] = byte(xt.lanes[w*xt.n+instance] >> (8 * (i % 4)))
+	}
+	return nil
+}
+
+// Permutation applies the Xoodoo permutation to every state held by the object. The result
+// is identical to calling Permutation on each state separately.
+func (xt *XoodooTimes) Permutation() {
+	permuteTimes(xt.lanes, xt.n, xt.rounds)
+}
+
+// permuteTimesGeneric applies the Xoodoo permutation to n interleaved states using only
+// portable Go code
+func permuteTimesGeneric(lanes []uint32, n, rounds int) {
+	var s State
+	for i := 0; i < n; i++ {
+		for w := 0; w < StateSizeWords; w++ {
+			s[w] = lanes[w*n+i]
+		}
+		permuteGeneric(&s, rounds)
+		for w := 0; w < StateSizeWords; w++ {
+			lanes[w*n+i] = s[w]
+		}
+	}
+}
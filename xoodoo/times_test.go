@@ -0,0 +1,120 @@
+package xoodoo
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func BenchmarkXoodooTimes4Permutation(b *testing.B) {
+	newXT, _ := NewXoodooTimes(Times4, 12)
+	for n := 0; n < b.N; n++ {
+		newXT.Permutation()
+	}
+}
+
+func BenchmarkXoodooTimes8Permutation(b *testing.B) {
+	newXT, _ := NewXoodooTimes(Times8, 12)
+	for n := 0; n < b.N; n++ {
+		newXT.Permutation()
+	}
+}
+
+func BenchmarkXoodooTimes16Permutation(b *testing.B) {
+	newXT, _ := NewXoodooTimes(Times16, 12)
+	for n := 0; n < b.N; n++ {
+		newXT.Permutation()
+	}
+}
+
+func TestXoodooTimesPermutation(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, instances := range []int{Times4, Times8, Times16} {
+		for rounds := 1; rounds <= MaxRounds; rounds++ {
+			newXT, err := NewXoodooTimes(instances, rounds)
+			assert.NoError(t, err)
+			expected := make([]State, instances)
+			for i := range expected {
+				var in [StateSizeBytes]byte
+				rng.Read(in[:])
+				newXD, _ := NewXoodoo(rounds, in)
+				assert.NoError(t, newXT.SetInstance(i, newXD.State))
+				newXD.Permutation()
+				expected[i] = newXD.State
+			}
+			newXT.Permutation()
+			for i := range expected {
+				got, err := newXT.Instance(i)
+				assert.NoError(t, err)
+				assert.Equal(t, expected[i], got)
+			}
+		}
+	}
+}
+
+func TestXoodooTimesMatchesPermutationTable(t *testing.T) {
+	for _, tt := range permutationTestTable {
+		newXT, _ := NewXoodooTimes(Times4, tt.rounds)
+		newXD, _ := NewXoodoo(tt.rounds, tt.inBytes)
+		for i := 0; i < Times4; i++ {
+			newXT.XorBytes(i, tt.inBytes[:])
+		}
+		newXT.Permutation()
+		for i := 0; i < Times4; i++ {
+			out := make([]byte, StateSizeBytes)
+			assert.NoError(t, newXT.ExtractBytes(i, out))
+			assert.Equal(t, tt.outBytes, out)
+		}
+		newXD.Permutation()
+		assert.Equal(t, tt.outBytes, newXD.Bytes())
+	}
+}
+
+func TestXoodooTimesPartialBytes(t *testing.T) {
+	newXT, _ := NewXoodooTimes(Times8, 12)
+	in := []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07}
+	assert.NoError(t, newXT.XorBytes(5, in))
+	got, _ := newXT.Instance(5)
+	assert.Equal(t, State{0x04030201, 0x00070605}, got)
+	untouched, _ := newXT.Instance(4)
+	assert.Equal(t, State{}, untouched)
+
+	out := make([]byte, 6)
+	assert.NoError(t, newXT.ExtractBytes(5, out))
+	assert.Equal(t, in[:6], out)
+}
+
+var xoodooTimesErrorsTestTable = []struct {
+	instances int
+	rounds    int
+	err       error
+}{
+	{
+		instances: 3,
+		rounds:    12,
+		err:       errors.New("invalid number of parallel instances: 3"),
+	},
+	{
+		instances: Times16,
+		rounds:    13,
+		err:       errors.New("invalid number of rounds: 13"),
+	},
+}
+
+func TestXoodooTimesErrors(t *testing.T) {
+	for _, tt := range xoodooTimesErrorsTestTable {
+		gotXT, gotErr := NewXoodooTimes(tt.instances, tt.rounds)
+		assert.Equal(t, (*XoodooTimes)(nil), gotXT)
+		assert.Equal(t, tt.err, gotErr)
+	}
+
+	newXT, _ := NewXoodooTimes(Times4, 12)
+	assert.Equal(t, Times4, newXT.Instances())
+	assert.Equal(t, errors.New("instance out of range:4"), newXT.SetInstance(4, State{}))
+	_, gotErr := newXT.Instance(-1)
+	assert.Equal(t, errors.New("instance out of range:-1"), gotErr)
+	assert.Equal(t, errors.New("xor bytes size out of range:49"), newXT.XorBytes(0, make([]byte, 49)))
+	assert.Equal(t, errors.New("extract bytes size out of range:49"), newXT.ExtractBytes(0, make([]byte, 49)))
+}
@@ -0,0 +1,132 @@
+package xoodoo
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Step identifies a point within the Xoodoo permutation reported to a Tracer
+type Step int
+
+const (
+	// StepInput reports the state before the first round
+	StepInput Step = iota
+	// StepTheta reports the state after the theta step mapping
+	StepTheta
+	// StepRhoWest reports the state after the rho west step mapping
+	StepRhoWest
+	// StepIota reports the state after the iota step mapping
+	StepIota
+	// StepChi reports the state after the chi step mapping
+	StepChi
+	// StepRhoEast reports the state after the rho east step mapping (the end of the round)
+	StepRhoEast
+)
+
+var stepNames = [...]string{
+	StepInput:   "Input",
+	StepTheta:   "Theta",
+	StepRhoWest: "Rho-west",
+	StepIota:    "Iota",
+	StepChi:     "Chi",
+	StepRhoEast: "Rho-east",
+}
+
+// String returns the name of the step as used by the reference C code debug output
+func (s Step) String() string {
+	if s < 0 || int(s) >= len(stepNames) {
+		return fmt.Sprintf("Step(%d)", int(s))
+	}
+	return stepNames[s]
+}
+
+// Tracer observes the intermediate states of the Xoodoo permutation. It receives the round index
+// (an index into RoundConstants), the step that was just applied and a copy of the resulting state.
+type Tracer func(round int, step Step, state State)
+
+// SetTracer installs a Tracer that is called for every intermediate step of subsequent calls to
+// Permutation. Tracing runs the unoptimized step mappings, so a nil Tracer should be set to restore
+// the optimized permutation once tracing is no longer needed.
+func (xd *Xoodoo) SetTracer(fn Tracer) {
+	xd.tracer = fn
+}
+
+// tracePermutation executes the permutation one step mapping at a time, reporting each
+// intermediate state to the installed Tracer
+func (xd *Xoodoo) tracePermutation() {
+	if xd.rounds <= 0 {
+		return
+	}
+	s := &xd.State
+	first := MaxRounds - xd.rounds
+	theta, rhoWest, rhoEast := s.Theta, s.RhoWest, s.RhoEast
+	iota := s.Iota
+	if cv := xd.variant; cv != nil {
+		first = cv.v.FirstRound
+		theta = func() { cv.theta(s) }
+		rhoWest = func() { shiftPlanes(s, cv.v.RhoWest, false) }
+		rhoEast = func() { shiftPlanes(s, cv.v.RhoEast, false) }
+		iota = func(round int) { cv.iota(s, round) }
+	}
+	xd.tracer(first, StepInput, *s)
+	for i := first; i < first+xd.rounds; i++ {
+		theta()
+		xd.tracer(i, StepTheta, *s)
+		rhoWest()
+		xd.tracer(i, StepRhoWest, *s)
+		iota(i)
+		xd.tracer(i, StepIota, *s)
+		s.Chi()
+		xd.tracer(i, StepChi, *s)
+		rhoEast()
+		xd.tracer(i, StepRhoEast, *s)
+	}
+}
+
+// TraceEntry is a single intermediate state recorded by a Trace
+type TraceEntry struct {
+	Round int
+	Step  Step
+	State State
+}
+
+// Trace records every intermediate state of the permutation. Pass its Record method to
+// SetTracer to start recording.
+type Trace struct {
+	Entries []TraceEntry
+}
+
+// Record appends an intermediate state to the trace. It satisfies the Tracer function type.
+func (tr *Trace) Record(round int, step Step, state State) {
+	tr.Entries = append(tr.Entries, TraceEntry{Round: round, Step: step, State: state})
+}
+
+// WriteTo renders the trace to w as hex lanes, one line per entry, in the same layout as the debug
+// output of the reference C code. Lane aYX is lane x of plane y.
+func (tr *Trace) WriteTo(w io.Writer) (int64, error) {
+	var total int64
+	for _, entry := range tr.Entries {
+		s := entry.State
+		n, err := fmt.Fprintf(w, "%-8.8s "+
+			"a00 %08x, a01 %08x, a02 %08x, a03 %08x, "+
+			"a10 %08x, a11 %08x, a12 %08x, a13 %08x, "+
+			"a20 %08x, a21 %08x, a22 %08x, a23 %08x\n",
+			entry.Step.String(),
+			s[0], s[1], s[2], s[3],
+			s[4], s[5], s[6], s[7],
+			s[8], s[9], s[10], s[11])
+		total += int64(n)
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// String returns the rendered trace as produced by WriteTo
+func (tr *Trace) String() string {
+	var b strings.Builder
+	tr.WriteTo(&b)
+	return b.String()
+}
@@ -0,0 +1,70 @@
+package xoodoo
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTracePermutation(t *testing.T) {
+	for _, tt := range permutationTestTable {
+		newXD, _ := NewXoodoo(tt.rounds, tt.inBytes)
+		trace := &Trace{}
+		newXD.SetTracer(trace.Record)
+		start := newXD.State
+		newXD.Permutation()
+		assert.Equal(t, tt.outBytes, newXD.Bytes())
+
+		assert.Len(t, trace.Entries, 1+5*tt.rounds)
+		assert.Equal(t, TraceEntry{Round: MaxRounds - tt.rounds, Step: StepInput, State: start}, trace.Entries[0])
+		assert.Equal(t, newXD.State, trace.Entries[len(trace.Entries)-1].State)
+		for i, entry := range trace.Entries[1:] {
+			assert.Equal(t, MaxRounds-tt.rounds+i/5, entry.Round)
+			assert.Equal(t, Step(1+i%5), entry.Step)
+		}
+
+		// removing the tracer restores the untraced permutation
+		newXD.SetTracer(nil)
+		newXD.Permutation()
+		assert.Len(t, trace.Entries, 1+5*tt.rounds)
+	}
+}
+
+func TestTraceFormat(t *testing.T) {
+	trace := &Trace{}
+	trace.Record(11, StepIota, State{0x58, 1, 2, 3, 0x10, 0x11, 0x12, 0x13, 0xdeadbeef, 0x21, 0x22, 0x23})
+	trace.Record(11, StepRhoWest, State{})
+	expected := "Iota     a00 00000058, a01 00000001, a02 00000002, a03 00000003, " +
+		"a10 00000010, a11 00000011, a12 00000012, a13 00000013, " +
+		"a20 deadbeef, a21 00000021, a22 00000022, a23 00000023\n" +
+		"Rho-west a00 00000000, a01 00000000, a02 00000000, a03 00000000, " +
+		"a10 00000000, a11 00000000, a12 00000000, a13 00000000, " +
+		"a20 00000000, a21 00000000, a22 00000000, a23 00000000\n"
+	assert.Equal(t, expected, trace.String())
+
+	var b strings.Builder
+	n, err := trace.WriteTo(&b)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(expected)), n)
+}
+
+type traceErrWriter struct{}
+
+func (traceErrWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestTraceWriteError(t *testing.T) {
+	trace := &Trace{}
+	trace.Record(0, StepInput, State{})
+	_, err := trace.WriteTo(traceErrWriter{})
+	assert.Equal(t, errors.New("write failed"), err)
+}
+
+func TestStepString(t *testing.T) {
+	assert.Equal(t, "Input", StepInput.String())
+	assert.Equal(t, "Rho-east", StepRhoEast.String())
+	assert.Equal(t, "Step(9)", Step(9).String())
+}
@@ -0,0 +1,238 @@
+package xoodoo
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// Offset describes the cyclic shift of a plane used by the Xoodoo step mappings, written
+// P ⋘ (X, Z) in the specification: lanes move X positions along the x axis and the bits of each
+// lane rotate Z positions along the z axis.
+type Offset struct {
+	X int
+	Z int
+}
+
+var (
+	// DefaultThetaOffsets are the two plane shifts of the column parity added by Theta
+	DefaultThetaOffsets = [2]Offset{{X: 1, Z: 5}, {X: 1, Z: 14}}
+	// DefaultRhoWestOffsets are the shifts applied to planes 1 and 2 by RhoWest
+	DefaultRhoWestOffsets = [2]Offset{{X: 1, Z: 0}, {X: 0, Z: 11}}
+	// DefaultRhoEastOffsets are the shifts applied to planes 1 and 2 by RhoEast
+	DefaultRhoEastOffsets = [2]Offset{{X: 0, Z: 1}, {X: 2, Z: 8}}
+)
+
+// Variant describes a Xoodoo-like permutation with custom round constants, rotation offsets and
+// round window. The round structure (Theta, RhoWest, Iota, Chi, RhoEast) is kept, and round i of
+// the window adds RoundConstants[FirstRound+i] in its Iota step.
+type Variant struct {
+	// RoundConstants is the sequence of constants the round window is taken from
+	RoundConstants []uint32
+	// FirstRound is the index into RoundConstants of the first round applied
+	FirstRound int
+	// Rounds is the number of rounds applied
+	Rounds int
+	// Theta holds the two plane shifts of the column parity added by Theta
+	Theta [2]Offset
+	// RhoWest holds the shifts applied to planes 1 and 2 by RhoWest
+	RhoWest [2]Offset
+	// RhoEast holds the shifts applied to planes 1 and 2 by RhoEast
+	RhoEast [2]Offset
+}
+
+// DefaultVariant returns the descriptor of the standard Xoodoo permutation with the given number
+// of rounds, which uses the final rounds of RoundConstants
+func DefaultVariant(rounds int) Variant {
+	return Variant{
+		RoundConstants: RoundConstants[:],
+		FirstRound:     MaxRounds - rounds,
+		Rounds:         rounds,
+		Theta:          DefaultThetaOffsets,
+		RhoWest:        DefaultRhoWestOffsets,
+		RhoEast:        DefaultRhoEastOffsets,
+	}
+}
+
+// Validate checks that the variant describes a permutation that can be evaluated
+func (v Variant) Validate() error {
+	if v.Rounds < 0 || v.FirstRound < 0 || v.FirstRound+v.Rounds > len(v.RoundConstants) {
+		return fmt.Errorf("variant round window out of range first:%d rounds:%d constants:%d", v.FirstRound, v.Rounds, len(v.RoundConstants))
+	}
+	return nil
+}
+
+// compiledVariant holds a validated copy of a Variant along with the data needed to evaluate it
+type compiledVariant struct {
+	v Variant
+	// fast is set when the variant matches the standard permutation, which is then evaluated
+	// with the optimized implementations
+	fast bool
+	// inverseParity maps the column parity after Theta back to the parity before it, one row
+	// per parity bit (32*x+z)
+	inverseParity [128][4]uint32
+}
+
+// NewXoodooVariant returns a new Xoodoo object that applies the permutation described by the
+// variant, initialized with the provided state. Variants equal to the standard Xoodoo permutation
+// use the same optimized code as NewXoodoo.
+func NewXoodooVariant(v Variant, state [StateSizeBytes]byte) (*Xoodoo, error) {
+	cv, err := compileVariant(v)
+	if err != nil {
+		return nil, err
+	}
+	var new Xoodoo
+	new.rounds = v.Rounds
+	new.variant = cv
+	new.State.UnmarshalBinary(state[:])
+	return &new, nil
+}
+
+func compileVariant(v Variant) (*compiledVariant, error) {
+	if err := v.Validate(); err != nil {
+		return nil, err
+	}
+	cv := &compiledVariant{v: v}
+	cv.v.RoundConstants = append([]uint32{}, v.RoundConstants...)
+	cv.inverseParity = invertThetaParity(v.Theta)
+	cv.fast = v.Theta == DefaultThetaOffsets && v.RhoWest == DefaultRhoWestOffsets &&
+		v.RhoEast == DefaultRhoEastOffsets && v.FirstRound+v.Rounds == MaxRounds
+	for i := v.FirstRound; cv.fast && i < MaxRounds; i++ {
+		cv.fast = v.RoundConstants[i] == RoundConstants[i]
+	}
+	return cv, nil
+}
+
+// Variant returns the descriptor of the permutation applied by the Xoodoo object
+func (xd *Xoodoo) Variant() Variant {
+	if xd.variant == nil {
+		return DefaultVariant(xd.rounds)
+	}
+	v := xd.variant.v
+	v.RoundConstants = append([]uint32{}, v.RoundConstants...)
+	return v
+}
+
+// shiftPlane returns the plane cyclically shifted by the offset
+func shiftPlane(p [4]uint32, o Offset) [4]uint32 {
+	var out [4]uint32
+	for x := 0; x < 4; x++ {
+		out[x] = bits.RotateLeft32(p[(x-o.X)&3], o.Z)
+	}
+	return out
+}
+
+// thetaEffect returns the value Theta adds to every plane for the given column parity
+func thetaEffect(p [4]uint32, offsets [2]Offset) [4]uint32 {
+	a, b := shiftPlane(p, offsets[0]), shiftPlane(p, offsets[1])
+	return [4]uint32{a[0] ^ b[0], a[1] ^ b[1], a[2] ^ b[2], a[3] ^ b[3]}
+}
+
+// invertThetaParity inverts the linear map p → p + E(p) giving the column parity after Theta,
+// returning the rows of the inverse. Viewing planes as polynomials in x and z modulo x^4+1 and
+// z^32+1, the map is multiplication by 1 + x^a·z^b + x^c·z^d, which evaluates to 1 at x = z = 1 and
+// is therefore invertible for any pair of offsets.
+func invertThetaParity(offsets [2]Offset) [128][4]uint32 {
+	// Build the rows of the forward matrix alongside the identity and reduce with Gauss-Jordan
+	// elimination over GF(2)
+	var forward, inverse [128][4]uint32
+	for j := 0; j < 128; j++ {
+		var unit [4]uint32
+		unit[j>>5] = 1 << uint(j&31)
+		e := thetaEffect(unit, offsets)
+		for i := 0; i < 128; i++ {
+			if (unit[i>>5]^e[i>>5])>>uint(i&31)&1 == 1 {
+				forward[i][j>>5] |= 1 << uint(j&31)
+			}
+		}
+		inverse[j][j>>5] = 1 << uint(j&31)
+	}
+	for col := 0; col < 128; col++ {
+		pivot := -1
+		for r := col; r < 128; r++ {
+			if forward[r][col>>5]>>uint(col&31)&1 == 1 {
+				pivot = r
+				break
+			}
+		}
+		if pivot < 0 {
+			panic("xoodoo: theta parity map not invertible")
+		}
+		forward[col], forward[pivot] = forward[pivot], forward[col]
+		inverse[col], inverse[pivot] = inverse[pivot], inverse[col]
+		for r := 0; r < 128; r++ {
+			if r != col && forward[r][col>>5]>>uint(col&31)&1 == 1 {
+				for w := 0; w < 4; w++ {
+					forward[r][w] ^= forward[col][w]
+					inverse[r][w] ^= inverse[col][w]
+				}
+			}
+		}
+	}
+	return inverse
+}
+
+func (cv *compiledVariant) theta(s *State) {
+	e := thetaEffect(columnParity(s), cv.v.Theta)
+	for i := range s {
+		s[i] ^= e[i&3]
+	}
+}
+
+func (cv *compiledVariant) inverseTheta(s *State) {
+	after := columnParity(s)
+	var before [4]uint32
+	for i, row := range cv.inverseParity {
+		var acc uint32
+		for w := 0; w < 4; w++ {
+			acc ^= row[w] & after[w]
+		}
+		before[i>>5] |= uint32(bits.OnesCount32(acc)&1) << uint(i&31)
+	}
+	e := thetaEffect(before, cv.v.Theta)
+	for i := range s {
+		s[i] ^= e[i&3]
+	}
+}
+
+// shiftPlanes applies the offsets to planes 1 and 2, negating them when inverse is set
+func shiftPlanes(s *State, offsets [2]Offset, inverse bool) {
+	for y := 1; y < 3; y++ {
+		o := offsets[y-1]
+		if inverse {
+			o = Offset{X: -o.X, Z: -o.Z}
+		}
+		p := shiftPlane([4]uint32{s[4*y], s[4*y+1], s[4*y+2], s[4*y+3]}, o)
+		copy(s[4*y:4*y+4], p[:])
+	}
+}
+
+func (cv *compiledVariant) iota(s *State, round int) {
+	s[0] ^= cv.v.RoundConstants[round]
+}
+
+// permute applies the variant permutation one step mapping at a time
+func (cv *compiledVariant) permute(s *State) {
+	for i := cv.v.FirstRound; i < cv.v.FirstRound+cv.v.Rounds; i++ {
+		cv.theta(s)
+		shiftPlanes(s, cv.v.RhoWest, false)
+		cv.iota(s, i)
+		s.Chi()
+		shiftPlanes(s, cv.v.RhoEast, false)
+	}
+}
+
+// inversePermute undoes the variant permutation
+func (cv *compiledVariant) inversePermute(s *State) {
+	for i := cv.v.FirstRound + cv.v.Rounds - 1; i >= cv.v.FirstRound; i-- {
+		shiftPlanes(s, cv.v.RhoEast, true)
+		s.InverseChi()
+		cv.iota(s, i)
+		shiftPlanes(s, cv.v.RhoWest, true)
+		cv.inverseTheta(s)
+	}
+}
+
+// columnParity returns the parity of each column of the state as a plane
+func columnParity(s *State) [4]uint32 {
+	return [4]uint32{s[0] ^ s[4] ^ s[8], s[1] ^ s[5] ^ s[9], s[2] ^ s[6] ^ s[10], s[3] ^ s[7] ^ s[11]}
+}
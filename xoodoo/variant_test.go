@@ -0,0 +1,176 @@
+package xoodoo
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultVariantMatchesPermutation(t *testing.T) {
+	for _, tt := range permutationTestTable {
+		newXD, err := NewXoodooVariant(DefaultVariant(tt.rounds), tt.inBytes)
+		assert.NoError(t, err)
+		assert.True(t, newXD.variant.fast)
+		newXD.Permutation()
+		assert.Equal(t, tt.outBytes, newXD.Bytes())
+
+		// The step-by-step evaluation used for custom variants agrees with the optimized code
+		var s State
+		s.UnmarshalBinary(tt.inBytes[:])
+		newXD.variant.permute(&s)
+		assert.Equal(t, newXD.State, s)
+		newXD.variant.inversePermute(&s)
+		assert.Equal(t, tt.inBytes[:], stateBytes(s))
+	}
+}
+
+func TestVariantRoundWindow(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for first := 0; first < MaxRounds; first++ {
+		for rounds := 0; first+rounds <= MaxRounds; rounds++ {
+			v := DefaultVariant(rounds)
+			v.FirstRound = first
+			input := randomState(rng)
+			newXD, err := NewXoodooVariant(v, [StateSizeBytes]byte{})
+			assert.NoError(t, err)
+			assert.Equal(t, first+rounds == MaxRounds, newXD.variant.fast)
+			newXD.State = input
+			newXD.Permutation()
+
+			expected := input
+			for i := first; i < first+rounds; i++ {
+				expected.Round(i)
+			}
+			assert.Equal(t, expected, newXD.State)
+			newXD.InversePermutation()
+			assert.Equal(t, input, newXD.State)
+		}
+	}
+}
+
+func TestVariantRoundConstants(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	constants := make([]uint32, 20)
+	for i := range constants {
+		constants[i] = rng.Uint32()
+	}
+	v := DefaultVariant(6)
+	v.RoundConstants = constants
+	v.FirstRound = 14
+	newXD, err := NewXoodooVariant(v, [StateSizeBytes]byte{})
+	assert.NoError(t, err)
+	assert.False(t, newXD.variant.fast)
+
+	// Changing the caller's constants afterwards has no effect
+	constants[14] = 0
+	input := randomState(rng)
+	newXD.State = input
+	newXD.Permutation()
+
+	expected := input
+	for i := 14; i < 20; i++ {
+		expected.Theta()
+		expected.RhoWest()
+		expected[0] ^= newXD.variant.v.RoundConstants[i]
+		expected.Chi()
+		expected.RhoEast()
+	}
+	assert.Equal(t, expected, newXD.State)
+	assert.NotEqual(t, uint32(0), newXD.Variant().RoundConstants[14])
+}
+
+func TestVariantOffsets(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	v := DefaultVariant(MaxRounds)
+	v.Theta = [2]Offset{{X: 3, Z: 7}, {X: 2, Z: 30}}
+	v.RhoWest = [2]Offset{{X: 2, Z: 3}, {X: -1, Z: 17}}
+	v.RhoEast = [2]Offset{{X: 1, Z: -4}, {X: 3, Z: 9}}
+	newXD, err := NewXoodooVariant(v, [StateSizeBytes]byte{})
+	assert.NoError(t, err)
+	assert.False(t, newXD.variant.fast)
+
+	for i := 0; i < 50; i++ {
+		input := randomState(rng)
+		newXD.State = input
+		newXD.Permutation()
+		assert.NotEqual(t, input, newXD.State)
+		newXD.InversePermutation()
+		assert.Equal(t, input, newXD.State)
+	}
+
+	// Theta with the new offsets adds the shifted parities to every plane
+	s := randomState(rng)
+	p := s.ParityPlane()
+	a, b := shiftPlane(p, v.Theta[0]), shiftPlane(p, v.Theta[1])
+	expected := s
+	for i := range expected {
+		expected[i] ^= a[i&3] ^ b[i&3]
+	}
+	newXD.variant.theta(&s)
+	assert.Equal(t, expected, s)
+}
+
+func TestVariantTrace(t *testing.T) {
+	v := DefaultVariant(3)
+	v.FirstRound = 2
+	v.RhoEast[1] = Offset{X: 1, Z: 3}
+	newXD, _ := NewXoodooVariant(v, [StateSizeBytes]byte{0x01})
+	expected := *newXD
+	expected.Permutation()
+
+	trace := &Trace{}
+	newXD.SetTracer(trace.Record)
+	newXD.Permutation()
+	assert.Equal(t, expected.State, newXD.State)
+	assert.Len(t, trace.Entries, 1+3*5)
+	assert.Equal(t, 2, trace.Entries[0].Round)
+	assert.Equal(t, 4, trace.Entries[len(trace.Entries)-1].Round)
+	assert.Equal(t, newXD.State, trace.Entries[len(trace.Entries)-1].State)
+}
+
+func TestVariantAccessor(t *testing.T) {
+	newXD, _ := NewXoodoo(6, [StateSizeBytes]byte{})
+	assert.Equal(t, DefaultVariant(6), newXD.Variant())
+}
+
+var variantErrorsTestTable = []struct {
+	first  int
+	rounds int
+	err    error
+}{
+	{first: -1, rounds: 3, err: errors.New("variant round window out of range first:-1 rounds:3 constants:12")},
+	{first: 10, rounds: 3, err: errors.New("variant round window out of range first:10 rounds:3 constants:12")},
+	{first: 0, rounds: -1, err: errors.New("variant round window out of range first:0 rounds:-1 constants:12")},
+}
+
+func TestVariantErrors(t *testing.T) {
+	for _, tt := range variantErrorsTestTable {
+		v := DefaultVariant(MaxRounds)
+		v.FirstRound = tt.first
+		v.Rounds = tt.rounds
+		assert.Equal(t, tt.err, v.Validate())
+		gotXD, gotErr := NewXoodooVariant(v, [StateSizeBytes]byte{})
+		assert.Equal(t, (*Xoodoo)(nil), gotXD)
+		assert.Equal(t, tt.err, gotErr)
+	}
+}
+
+func BenchmarkVariantPermutation(b *testing.B) {
+	v := DefaultVariant(MaxRounds)
+	v.RhoEast[1] = Offset{X: 1, Z: 3}
+	newXD, _ := NewXoodooVariant(v, [StateSizeBytes]byte{})
+	for n := 0; n < b.N; n++ {
+		newXD.Permutation()
+	}
+}
+
+func BenchmarkVariantInversePermutation(b *testing.B) {
+	v := DefaultVariant(MaxRounds)
+	v.RhoEast[1] = Offset{X: 1, Z: 3}
+	newXD, _ := NewXoodooVariant(v, [StateSizeBytes]byte{})
+	for n := 0; n < b.N; n++ {
+		newXD.InversePermutation()
+	}
+}
@@ -40,11 +40,10 @@ type State [StateSizeWords]uint32
 // Xoodoo combines the xoodoo state with additional configuration for completing the
 // permutation operation
 type Xoodoo struct {
-	State  State
-	rounds int
-	tmp    State
-	p      [4]uint32
-	e      [4]uint32
+	State   State
+	rounds  int
+	tracer  Tracer
+	variant *compiledVariant
 }
 
 // XorState performs the exclusive-or operation on two XoodooState objects and returns
@@ -103,10 +102,7 @@ func (xd *Xoodoo) XorExtractBytes(x []byte) ([]byte, error) {
 		return nil, fmt.Errorf("xor and extract bytes size out of range:%d", size)
 	}
 	out := make([]byte, size)
-	stateBytes := xd.Bytes()
-	for i := 0; i < size; i++ {
-		out[i] = stateBytes[i] ^ x[i]
-	}
+	xd.State.ExtractAndAddBytes(x, out, 0)
 	return out, nil
 }
 
@@ -171,55 +167,78 @@ func (xd *Xoodoo) Bytes() []byte {
 	return buf
 }
 
+// Clone returns a copy of the Xoodoo object holding its own copy of the state. The copy shares the
+// installed Tracer, if any, and the compiled Variant of NewXoodooVariant with the original: the
+// Tracer is called for the permutations of both objects, and the Variant, which is never modified
+// once compiled, applies the same permutation to both. Install a different Tracer on the copy with
+// SetTracer to tell them apart.
+func (xd *Xoodoo) Clone() *Xoodoo {
+	clone := *xd
+	return &clone
+}
+
 // Permutation executes an optimized implementation of Xoodoo permutation operation over the
 //provided  xoodoo state
 func (xd *Xoodoo) Permutation() {
-	for i := MaxRounds - xd.rounds; i < MaxRounds; i++ {
-		xd.p = [4]uint32{
-			xd.State[0] ^ xd.State[4] ^ xd.State[8],
-			xd.State[1] ^ xd.State[5] ^ xd.State[9],
-			xd.State[2] ^ xd.State[6] ^ xd.State[10],
-			xd.State[3] ^ xd.State[7] ^ xd.State[11],
+	if xd.tracer != nil {
+		xd.tracePermutation()
+		return
+	}
+	if xd.variant != nil && !xd.variant.fast {
+		xd.variant.permute(&xd.State)
+		return
+	}
+	permute(&xd.State, xd.rounds)
+}
+
+// permuteGeneric applies the final number of rounds of the Xoodoo permutation to the provided state
+// using only portable Go code
+func permuteGeneric(s *State, rounds int) {
+	var tmp State
+	var p, e [4]uint32
+	for i := MaxRounds - rounds; i < MaxRounds; i++ {
+		p = [4]uint32{
+			s[0] ^ s[4] ^ s[8],
+			s[1] ^ s[5] ^ s[9],
+			s[2] ^ s[6] ^ s[10],
+			s[3] ^ s[7] ^ s[11],
 		}
-		xd.e = [4]uint32{
-			bits.RotateLeft32(xd.p[3], 5) ^ bits.RotateLeft32(xd.p[3], 14),
-			bits.RotateLeft32(xd.p[0], 5) ^ bits.RotateLeft32(xd.p[0], 14),
-			bits.RotateLeft32(xd.p[1], 5) ^ bits.RotateLeft32(xd.p[1], 14),
-			bits.RotateLeft32(xd.p[2], 5) ^ bits.RotateLeft32(xd.p[2], 14),
+		e = [4]uint32{
+			bits.RotateLeft32(p[3], 5) ^ bits.RotateLeft32(p[3], 14),
+			bits.RotateLeft32(p[0], 5) ^ bits.RotateLeft32(p[0], 14),
+			bits.RotateLeft32(p[1], 5) ^ bits.RotateLeft32(p[1], 14),
+			bits.RotateLeft32(p[2], 5) ^ bits.RotateLeft32(p[2], 14),
 		}
 
-		xd.tmp[0] = xd.e[0] ^ xd.State[0] ^ RoundConstants[i]
-		xd.tmp[1] = xd.e[1] ^ xd.State[1]
-		xd.tmp[2] = xd.e[2] ^ xd.State[2]
-		xd.tmp[3] = xd.e[3] ^ xd.State[3]
+		tmp[0] = e[0] ^ s[0] ^ RoundConstants[i]
+		tmp[1] = e[1] ^ s[1]
+		tmp[2] = e[2] ^ s[2]
+		tmp[3] = e[3] ^ s[3]
 
-		xd.tmp[4] = xd.e[3] ^ xd.State[7]
-		xd.tmp[5] = xd.e[0] ^ xd.State[4]
-		xd.tmp[6] = xd.e[1] ^ xd.State[5]
-		xd.tmp[7] = xd.e[2] ^ xd.State[6]
+		tmp[4] = e[3] ^ s[7]
+		tmp[5] = e[0] ^ s[4]
+		tmp[6] = e[1] ^ s[5]
+		tmp[7] = e[2] ^ s[6]
 
-		xd.tmp[8] = bits.RotateLeft32(xd.e[0]^xd.State[8], 11)
-		xd.tmp[9] = bits.RotateLeft32(xd.e[1]^xd.State[9], 11)
-		xd.tmp[10] = bits.RotateLeft32(xd.e[2]^xd.State[10], 11)
-		xd.tmp[11] = bits.RotateLeft32(xd.e[3]^xd.State[11], 11)
+		tmp[8] = bits.RotateLeft32(e[0]^s[8], 11)
+		tmp[9] = bits.RotateLeft32(e[1]^s[9], 11)
+		tmp[10] = bits.RotateLeft32(e[2]^s[10], 11)
+		tmp[11] = bits.RotateLeft32(e[3]^s[11], 11)
 
-		xd.State[0] = (^xd.tmp[4] & xd.tmp[8]) ^ xd.tmp[0]
-		xd.State[1] = (^xd.tmp[5] & xd.tmp[9]) ^ xd.tmp[1]
-		xd.State[2] = (^xd.tmp[6] & xd.tmp[10]) ^ xd.tmp[2]
-		xd.State[3] = (^xd.tmp[7] & xd.tmp[11]) ^ xd.tmp[3]
+		s[0] = (^tmp[4] & tmp[8]) ^ tmp[0]
+		s[1] = (^tmp[5] & tmp[9]) ^ tmp[1]
+		s[2] = (^tmp[6] & tmp[10]) ^ tmp[2]
+		s[3] = (^tmp[7] & tmp[11]) ^ tmp[3]
 
-		xd.State[4] = bits.RotateLeft32((^xd.tmp[8]&xd.tmp[0])^xd.tmp[4], 1)
-		xd.State[5] = bits.RotateLeft32((^xd.tmp[9]&xd.tmp[1])^xd.tmp[5], 1)
-		xd.State[6] = bits.RotateLeft32((^xd.tmp[10]&xd.tmp[2])^xd.tmp[6], 1)
-		xd.State[7] = bits.RotateLeft32((^xd.tmp[11]&xd.tmp[3])^xd.tmp[7], 1)
+		s[4] = bits.RotateLeft32((^tmp[8]&tmp[0])^tmp[4], 1)
+		s[5] = bits.RotateLeft32((^tmp[9]&tmp[1])^tmp[5], 1)
+		s[6] = bits.RotateLeft32((^tmp[10]&tmp[2])^tmp[6], 1)
+		s[7] = bits.RotateLeft32((^tmp[11]&tmp[3])^tmp[7], 1)
 
-		xd.State[8] = bits.RotateLeft32((^xd.tmp[2]&xd.tmp[6])^xd.tmp[10], 8)
-		xd.State[9] = bits.RotateLeft32((^xd.tmp[3]&xd.tmp[7])^xd.tmp[11], 8)
-		xd.State[10] = bits.RotateLeft32((^xd.tmp[0]&xd.tmp[4])^xd.tmp[8], 8)
-		xd.State[11] = bits.RotateLeft32((^xd.tmp[1]&xd.tmp[5])^xd.tmp[9], 8)
+		s[8] = bits.RotateLeft32((^tmp[2]&tmp[6])^tmp[10], 8)
+		s[9] = bits.RotateLeft32((^tmp[3]&tmp[7])^tmp[11], 8)
+		s[10] = bits.RotateLeft32((^tmp[0]&tmp[4])^tmp[8], 8)
+		s[11] = bits.RotateLeft32((^tmp[1]&tmp[5])^tmp[9], 8)
 
 	}
-	xd.tmp = State{}
-	xd.e = [4]uint32{}
-	xd.p = [4]uint32{}
 }
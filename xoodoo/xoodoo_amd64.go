@@ -0,0 +1,75 @@
+//go:build amd64 && !purego
+// +build amd64,!purego
+
+package xoodoo
+
+import "unsafe"
+
+//go:noescape
+func permuteSSE2(s *State, rc *uint32, rounds int)
+
+//go:noescape
+func permuteAVX512(s *State, rc *uint32, rounds int)
+
+//go:noescape
+func permuteTimes4SSE2(lanes *uint32, stride uintptr, rc *uint32, rounds int)
+
+//go:noescape
+func permuteTimes4AVX512(lanes *uint32, rc *uint32, rounds int)
+
+//go:noescape
+func permuteTimes8AVX512(lanes *uint32, rc *uint32, rounds int)
+
+//go:noescape
+func permuteTimes8AVX2(lanes *uint32, stride uintptr, rc *uint32, rounds int)
+
+//go:noescape
+func permuteTimes16AVX512(lanes *uint32, rc *uint32, rounds int)
+
+// useAVX2 and useAVX512 select the assembly routines used by permute and permuteTimes. They
+// default to the detected CPU features and are only changed by tests.
+var (
+	useAVX2   = hasAVX2
+	useAVX512 = hasAVX512
+)
+
+// permute applies the final number of rounds of the Xoodoo permutation to the provided state
+func permute(s *State, rounds int) {
+	if rounds <= 0 {
+		return
+	}
+	rc := &RoundConstants[MaxRounds-rounds]
+	if useAVX512 {
+		permuteAVX512(s, rc, rounds)
+		return
+	}
+	permuteSSE2(s, rc, rounds)
+}
+
+// permuteTimes applies the final number of rounds of the Xoodoo permutation to n interleaved
+// states, splitting the work into the widest groups of states the CPU can process at once. With
+// AVX-512, four and eight states use its rotations and ternary logic on 128 and 256-bit registers,
+// which the SSE2 and AVX2 routines cannot.
+func permuteTimes(lanes []uint32, n, rounds int) {
+	if rounds <= 0 {
+		return
+	}
+	rc := &RoundConstants[MaxRounds-rounds]
+	stride := uintptr(n) * unsafe.Sizeof(lanes[0])
+	switch {
+	case useAVX512 && n == Times16:
+		permuteTimes16AVX512(&lanes[0], rc, rounds)
+	case useAVX512 && n == Times8:
+		permuteTimes8AVX512(&lanes[0], rc, rounds)
+	case useAVX512 && n == Times4:
+		permuteTimes4AVX512(&lanes[0], rc, rounds)
+	case useAVX2 && n >= Times8:
+		for i := 0; i < n; i += Times8 {
+			permuteTimes8AVX2(&lanes[i], stride, rc, rounds)
+		}
+	default:
+		for i := 0; i < n; i += Times4 {
+			permuteTimes4SSE2(&lanes[i], stride, rc, rounds)
+		}
+	}
+}
@@ -0,0 +1,709 @@
+//go:build amd64 && !purego
+// +build amd64,!purego
+
+#include "textflag.h"
+
+// Single state layout: one 128-bit register per plane, lane x in 32-bit element x.
+// Multi-state layout: one register per state word, instance i in 32-bit element i.
+
+// PSHUFD immediates: SHIFT1 moves lane x-1 into lane x, SHIFT2 moves lane x-2 into lane x
+#define SHIFT1 $0x93
+#define SHIFT2 $0x4E
+
+// VPTERNLOGD immediates: XOR3 is a^b^c, CHI is a^(^b&c)
+#define XOR3 $0x96
+#define CHI $0xD2
+
+#define ROTL_SSE2(r, n, t) \
+	MOVO  r, t;          \
+	PSLLL $(n), r;       \
+	PSRLL $(32-(n)), t;  \
+	POR   t, r
+
+// func cpuid(eaxArg, ecxArg uint32) (eax, ebx, ecx, edx uint32)
+TEXT ·cpuid(SB), NOSPLIT, $0-24
+	MOVL eaxArg+0(FP), AX
+	MOVL ecxArg+4(FP), CX
+	CPUID
+	MOVL AX, eax+8(FP)
+	MOVL BX, ebx+12(FP)
+	MOVL CX, ecx+16(FP)
+	MOVL DX, edx+20(FP)
+	RET
+
+// func xgetbv() (eax, edx uint32)
+TEXT ·xgetbv(SB), NOSPLIT, $0-8
+	MOVL $0, CX
+	XGETBV
+	MOVL AX, eax+0(FP)
+	MOVL DX, edx+4(FP)
+	RET
+
+// func permuteSSE2(s *State, rc *uint32, rounds int)
+TEXT ·permuteSSE2(SB), NOSPLIT, $0-24
+	MOVQ s+0(FP), AX
+	MOVQ rc+8(FP), CX
+	MOVQ rounds+16(FP), DX
+	MOVOU 0(AX), X0
+	MOVOU 16(AX), X1
+	MOVOU 32(AX), X2
+
+loop:
+	// theta
+	MOVO   X0, X3
+	PXOR   X1, X3
+	PXOR   X2, X3
+	PSHUFD SHIFT1, X3, X3
+	MOVO   X3, X4
+	ROTL_SSE2(X3, 5, X5)
+	ROTL_SSE2(X4, 14, X5)
+	PXOR   X4, X3
+	PXOR   X3, X0
+	PXOR   X3, X1
+	PXOR   X3, X2
+
+	// rho west
+	PSHUFD SHIFT1, X1, X1
+	ROTL_SSE2(X2, 11, X4)
+
+	// iota
+	MOVL (CX), X4
+	PXOR X4, X0
+
+	// chi
+	MOVO  X1, X4
+	PANDN X2, X4
+	MOVO  X2, X5
+	PANDN X0, X5
+	MOVO  X0, X6
+	PANDN X1, X6
+	PXOR  X4, X0
+	PXOR  X5, X1
+	PXOR  X6, X2
+
+	// rho east
+	ROTL_SSE2(X1, 1, X4)
+	ROTL_SSE2(X2, 8, X4)
+	PSHUFD SHIFT2, X2, X2
+
+	ADDQ $4, CX
+	DECQ DX
+	JNZ  loop
+
+	MOVOU X0, 0(AX)
+	MOVOU X1, 16(AX)
+	MOVOU X2, 32(AX)
+	RET
+
+// func permuteAVX512(s *State, rc *uint32, rounds int)
+TEXT ·permuteAVX512(SB), NOSPLIT, $0-24
+	MOVQ s+0(FP), AX
+	MOVQ rc+8(FP), CX
+	MOVQ rounds+16(FP), DX
+	VMOVDQU32 0(AX), X0
+	VMOVDQU32 16(AX), X1
+	VMOVDQU32 32(AX), X2
+
+loop:
+	// theta
+	VMOVDQA32  X0, X3
+	VPTERNLOGD XOR3, X2, X1, X3
+	VPSHUFD    SHIFT1, X3, X3
+	VPROLD     $5, X3, X4
+	VPROLD     $14, X3, X5
+	VPTERNLOGD XOR3, X5, X4, X0
+	VPTERNLOGD XOR3, X5, X4, X1
+	VPTERNLOGD XOR3, X5, X4, X2
+
+	// rho west
+	VPSHUFD SHIFT1, X1, X1
+	VPROLD  $11, X2, X2
+
+	// iota
+	VMOVD  (CX), X4
+	VPXORD X4, X0, X0
+
+	// chi
+	VMOVDQA32  X0, X3
+	VMOVDQA32  X1, X4
+	VPTERNLOGD CHI, X2, X1, X0
+	VPTERNLOGD CHI, X3, X2, X1
+	VPTERNLOGD CHI, X4, X3, X2
+
+	// rho east
+	VPROLD  $1, X1, X1
+	VPROLD  $8, X2, X2
+	VPSHUFD SHIFT2, X2, X2
+
+	ADDQ $4, CX
+	DECQ DX
+	JNZ  loop
+
+	VMOVDQU32 X0, 0(AX)
+	VMOVDQU32 X1, 16(AX)
+	VMOVDQU32 X2, 32(AX)
+	VZEROUPPER
+	RET
+
+// Column parity p_x of the multi-state layout is spilled to the stack at offset off
+#define PARITY_SSE2(off, a, b, c) \
+	MOVO  a, X12;             \
+	PXOR  b, X12;             \
+	PXOR  c, X12;             \
+	MOVOU X12, off(SP)
+
+// Apply E_x = (p_{x-1} <<< 5) ^ (p_{x-1} <<< 14) to the column a, b, c
+#define THETA_SSE2(off, a, b, c) \
+	MOVOU off(SP), X12;      \
+	MOVO  X12, X13;          \
+	MOVO  X12, X14;          \
+	MOVO  X12, X15;          \
+	PSLLL $5, X12;           \
+	PSRLL $27, X13;          \
+	PSLLL $14, X14;          \
+	PSRLL $18, X15;          \
+	POR   X13, X12;          \
+	POR   X15, X14;          \
+	PXOR  X14, X12;          \
+	PXOR  X12, a;            \
+	PXOR  X12, b;            \
+	PXOR  X12, c
+
+#define CHI_SSE2(b0, b1, b2) \
+	MOVO  b1, X12;           \
+	PANDN b2, X12;           \
+	MOVO  b2, X13;           \
+	PANDN b0, X13;           \
+	MOVO  b0, X14;           \
+	PANDN b1, X14;           \
+	PXOR  X12, b0;           \
+	PXOR  X13, b1;           \
+	PXOR  X14, b2
+
+// func permuteTimes4SSE2(lanes *uint32, stride uintptr, rc *uint32, rounds int)
+TEXT ·permuteTimes4SSE2(SB), NOSPLIT, $64-32
+	MOVQ lanes+0(FP), AX
+	MOVQ stride+8(FP), BX
+	MOVQ rc+16(FP), CX
+	MOVQ rounds+24(FP), DX
+	MOVQ AX, SI
+	MOVOU (SI), X0
+	ADDQ  BX, SI
+	MOVOU (SI), X1
+	ADDQ  BX, SI
+	MOVOU (SI), X2
+	ADDQ  BX, SI
+	MOVOU (SI), X3
+	ADDQ  BX, SI
+	MOVOU (SI), X4
+	ADDQ  BX, SI
+	MOVOU (SI), X5
+	ADDQ  BX, SI
+	MOVOU (SI), X6
+	ADDQ  BX, SI
+	MOVOU (SI), X7
+	ADDQ  BX, SI
+	MOVOU (SI), X8
+	ADDQ  BX, SI
+	MOVOU (SI), X9
+	ADDQ  BX, SI
+	MOVOU (SI), X10
+	ADDQ  BX, SI
+	MOVOU (SI), X11
+
+loop:
+	// theta
+	PARITY_SSE2(0, X0, X4, X8)
+	PARITY_SSE2(16, X1, X5, X9)
+	PARITY_SSE2(32, X2, X6, X10)
+	PARITY_SSE2(48, X3, X7, X11)
+	THETA_SSE2(48, X0, X4, X8)
+	THETA_SSE2(0, X1, X5, X9)
+	THETA_SSE2(16, X2, X6, X10)
+	THETA_SSE2(32, X3, X7, X11)
+
+	// rho west, the plane 1 shift is resolved by register selection in chi
+	ROTL_SSE2(X8, 11, X12)
+	ROTL_SSE2(X9, 11, X12)
+	ROTL_SSE2(X10, 11, X12)
+	ROTL_SSE2(X11, 11, X12)
+
+	// iota
+	MOVL   (CX), X12
+	PSHUFD $0, X12, X12
+	PXOR   X12, X0
+
+	// chi
+	CHI_SSE2(X0, X7, X8)
+	CHI_SSE2(X1, X4, X9)
+	CHI_SSE2(X2, X5, X10)
+	CHI_SSE2(X3, X6, X11)
+
+	// rho east
+	ROTL_SSE2(X4, 1, X12)
+	ROTL_SSE2(X5, 1, X12)
+	ROTL_SSE2(X6, 1, X12)
+	ROTL_SSE2(X7, 1, X12)
+	ROTL_SSE2(X8, 8, X12)
+	ROTL_SSE2(X9, 8, X12)
+	ROTL_SSE2(X10, 8, X12)
+	ROTL_SSE2(X11, 8, X12)
+
+	// move planes 1 and 2 back to their home registers
+	MOVO X7, X12
+	MOVO X6, X7
+	MOVO X5, X6
+	MOVO X4, X5
+	MOVO X12, X4
+	MOVO X8, X12
+	MOVO X10, X8
+	MOVO X12, X10
+	MOVO X9, X12
+	MOVO X11, X9
+	MOVO X12, X11
+
+	ADDQ $4, CX
+	DECQ DX
+	JNZ  loop
+
+	MOVQ  AX, SI
+	MOVOU X0, (SI)
+	ADDQ  BX, SI
+	MOVOU X1, (SI)
+	ADDQ  BX, SI
+	MOVOU X2, (SI)
+	ADDQ  BX, SI
+	MOVOU X3, (SI)
+	ADDQ  BX, SI
+	MOVOU X4, (SI)
+	ADDQ  BX, SI
+	MOVOU X5, (SI)
+	ADDQ  BX, SI
+	MOVOU X6, (SI)
+	ADDQ  BX, SI
+	MOVOU X7, (SI)
+	ADDQ  BX, SI
+	MOVOU X8, (SI)
+	ADDQ  BX, SI
+	MOVOU X9, (SI)
+	ADDQ  BX, SI
+	MOVOU X10, (SI)
+	ADDQ  BX, SI
+	MOVOU X11, (SI)
+	RET
+
+#define ROTL_AVX2(r, n, t)  \
+	VPSLLD $(n), r, t;      \
+	VPSRLD $(32-(n)), r, r; \
+	VPOR   t, r, r
+
+#define PARITY_AVX2(off, a, b, c) \
+	VPXOR   b, a, Y12;            \
+	VPXOR   c, Y12, Y12;          \
+	VMOVDQU Y12, off(SP)
+
+#define THETA_AVX2(off, a, b, c) \
+	VMOVDQU off(SP), Y12;        \
+	VPSLLD  $5, Y12, Y13;        \
+	VPSRLD  $27, Y12, Y14;       \
+	VPOR    Y14, Y13, Y13;       \
+	VPSLLD  $14, Y12, Y14;       \
+	VPSRLD  $18, Y12, Y15;       \
+	VPOR    Y15, Y14, Y14;       \
+	VPXOR   Y14, Y13, Y13;       \
+	VPXOR   Y13, a, a;           \
+	VPXOR   Y13, b, b;           \
+	VPXOR   Y13, c, c
+
+#define CHI_AVX2(b0, b1, b2) \
+	VPANDN b2, b1, Y12;      \
+	VPANDN b0, b2, Y13;      \
+	VPANDN b1, b0, Y14;      \
+	VPXOR  Y12, b0, b0;      \
+	VPXOR  Y13, b1, b1;      \
+	VPXOR  Y14, b2, b2
+
+// func permuteTimes8AVX2(lanes *uint32, stride uintptr, rc *uint32, rounds int)
+TEXT ·permuteTimes8AVX2(SB), NOSPLIT, $128-32
+	MOVQ lanes+0(FP), AX
+	MOVQ stride+8(FP), BX
+	MOVQ rc+16(FP), CX
+	MOVQ rounds+24(FP), DX
+	MOVQ AX, SI
+	VMOVDQU (SI), Y0
+	ADDQ    BX, SI
+	VMOVDQU (SI), Y1
+	ADDQ    BX, SI
+	VMOVDQU (SI), Y2
+	ADDQ    BX, SI
+	VMOVDQU (SI), Y3
+	ADDQ    BX, SI
+	VMOVDQU (SI), Y4
+	ADDQ    BX, SI
+	VMOVDQU (SI), Y5
+	ADDQ    BX, SI
+	VMOVDQU (SI), Y6
+	ADDQ    BX, SI
+	VMOVDQU (SI), Y7
+	ADDQ    BX, SI
+	VMOVDQU (SI), Y8
+	ADDQ    BX, SI
+	VMOVDQU (SI), Y9
+	ADDQ    BX, SI
+	VMOVDQU (SI), Y10
+	ADDQ    BX, SI
+	VMOVDQU (SI), Y11
+
+loop:
+	// theta
+	PARITY_AVX2(0, Y0, Y4, Y8)
+	PARITY_AVX2(32, Y1, Y5, Y9)
+	PARITY_AVX2(64, Y2, Y6, Y10)
+	PARITY_AVX2(96, Y3, Y7, Y11)
+	THETA_AVX2(96, Y0, Y4, Y8)
+	THETA_AVX2(0, Y1, Y5, Y9)
+	THETA_AVX2(32, Y2, Y6, Y10)
+	THETA_AVX2(64, Y3, Y7, Y11)
+
+	// rho west, the plane 1 shift is resolved by register selection in chi
+	ROTL_AVX2(Y8, 11, Y12)
+	ROTL_AVX2(Y9, 11, Y12)
+	ROTL_AVX2(Y10, 11, Y12)
+	ROTL_AVX2(Y11, 11, Y12)
+
+	// iota
+	VPBROADCASTD (CX), Y12
+	VPXOR        Y12, Y0, Y0
+
+	// chi
+	CHI_AVX2(Y0, Y7, Y8)
+	CHI_AVX2(Y1, Y4, Y9)
+	CHI_AVX2(Y2, Y5, Y10)
+	CHI_AVX2(Y3, Y6, Y11)
+
+	// rho east
+	ROTL_AVX2(Y4, 1, Y12)
+	ROTL_AVX2(Y5, 1, Y12)
+	ROTL_AVX2(Y6, 1, Y12)
+	ROTL_AVX2(Y7, 1, Y12)
+	ROTL_AVX2(Y8, 8, Y12)
+	ROTL_AVX2(Y9, 8, Y12)
+	ROTL_AVX2(Y10, 8, Y12)
+	ROTL_AVX2(Y11, 8, Y12)
+
+	// move planes 1 and 2 back to their home registers
+	VMOVDQA Y7, Y12
+	VMOVDQA Y6, Y7
+	VMOVDQA Y5, Y6
+	VMOVDQA Y4, Y5
+	VMOVDQA Y12, Y4
+	VMOVDQA Y8, Y12
+	VMOVDQA Y10, Y8
+	VMOVDQA Y12, Y10
+	VMOVDQA Y9, Y12
+	VMOVDQA Y11, Y9
+	VMOVDQA Y12, Y11
+
+	ADDQ $4, CX
+	DECQ DX
+	JNZ  loop
+
+	MOVQ    AX, SI
+	VMOVDQU Y0, (SI)
+	ADDQ    BX, SI
+	VMOVDQU Y1, (SI)
+	ADDQ    BX, SI
+	VMOVDQU Y2, (SI)
+	ADDQ    BX, SI
+	VMOVDQU Y3, (SI)
+	ADDQ    BX, SI
+	VMOVDQU Y4, (SI)
+	ADDQ    BX, SI
+	VMOVDQU Y5, (SI)
+	ADDQ    BX, SI
+	VMOVDQU Y6, (SI)
+	ADDQ    BX, SI
+	VMOVDQU Y7, (SI)
+	ADDQ    BX, SI
+	VMOVDQU Y8, (SI)
+	ADDQ    BX, SI
+	VMOVDQU Y9, (SI)
+	ADDQ    BX, SI
+	VMOVDQU Y10, (SI)
+	ADDQ    BX, SI
+	VMOVDQU Y11, (SI)
+	VZEROUPPER
+	RET
+
+#define THETA_AVX512(p, a, b, c) \
+	VPROLD     $5, p, Z16;       \
+	VPROLD     $14, p, Z17;      \
+	VPTERNLOGD XOR3, Z17, Z16, a; \
+	VPTERNLOGD XOR3, Z17, Z16, b; \
+	VPTERNLOGD XOR3, Z17, Z16, c
+
+#define CHI_AVX512(b0, b1, b2)   \
+	VMOVDQA32  b0, Z16;          \
+	VMOVDQA32  b1, Z17;          \
+	VPTERNLOGD CHI, b2, b1, b0;  \
+	VPTERNLOGD CHI, Z16, b2, b1; \
+	VPTERNLOGD CHI, Z17, Z16, b2
+
+// func permuteTimes16AVX512(lanes *uint32, rc *uint32, rounds int)
+TEXT ·permuteTimes16AVX512(SB), NOSPLIT, $0-24
+	MOVQ lanes+0(FP), AX
+	MOVQ rc+8(FP), CX
+	MOVQ rounds+16(FP), DX
+	VMOVDQU32 0(AX), Z0
+	VMOVDQU32 64(AX), Z1
+	VMOVDQU32 128(AX), Z2
+	VMOVDQU32 192(AX), Z3
+	VMOVDQU32 256(AX), Z4
+	VMOVDQU32 320(AX), Z5
+	VMOVDQU32 384(AX), Z6
+	VMOVDQU32 448(AX), Z7
+	VMOVDQU32 512(AX), Z8
+	VMOVDQU32 576(AX), Z9
+	VMOVDQU32 640(AX), Z10
+	VMOVDQU32 704(AX), Z11
+
+loop:
+	// theta
+	VMOVDQA32  Z0, Z12
+	VPTERNLOGD XOR3, Z8, Z4, Z12
+	VMOVDQA32  Z1, Z13
+	VPTERNLOGD XOR3, Z9, Z5, Z13
+	VMOVDQA32  Z2, Z14
+	VPTERNLOGD XOR3, Z10, Z6, Z14
+	VMOVDQA32  Z3, Z15
+	VPTERNLOGD XOR3, Z11, Z7, Z15
+	THETA_AVX512(Z15, Z0, Z4, Z8)
+	THETA_AVX512(Z12, Z1, Z5, Z9)
+	THETA_AVX512(Z13, Z2, Z6, Z10)
+	THETA_AVX512(Z14, Z3, Z7, Z11)
+
+	// rho west, the plane 1 shift is resolved by register selection in chi
+	VPROLD $11, Z8, Z8
+	VPROLD $11, Z9, Z9
+	VPROLD $11, Z10, Z10
+	VPROLD $11, Z11, Z11
+
+	// iota
+	VPBROADCASTD (CX), Z16
+	VPXORD       Z16, Z0, Z0
+
+	// chi
+	CHI_AVX512(Z0, Z7, Z8)
+	CHI_AVX512(Z1, Z4, Z9)
+	CHI_AVX512(Z2, Z5, Z10)
+	CHI_AVX512(Z3, Z6, Z11)
+
+	// rho east, writing planes 1 and 2 back to their home registers
+	VPROLD    $1, Z7, Z12
+	VPROLD    $1, Z6, Z7
+	VPROLD    $1, Z5, Z6
+	VPROLD    $1, Z4, Z5
+	VMOVDQA32 Z12, Z4
+	VPROLD    $8, Z10, Z12
+	VPROLD    $8, Z11, Z13
+	VPROLD    $8, Z8, Z10
+	VPROLD    $8, Z9, Z11
+	VMOVDQA32 Z12, Z8
+	VMOVDQA32 Z13, Z9
+
+	ADDQ $4, CX
+	DECQ DX
+	JNZ  loop
+
+	VMOVDQU32 Z0, 0(AX)
+	VMOVDQU32 Z1, 64(AX)
+	VMOVDQU32 Z2, 128(AX)
+	VMOVDQU32 Z3, 192(AX)
+	VMOVDQU32 Z4, 256(AX)
+	VMOVDQU32 Z5, 320(AX)
+	VMOVDQU32 Z6, 384(AX)
+	VMOVDQU32 Z7, 448(AX)
+	VMOVDQU32 Z8, 512(AX)
+	VMOVDQU32 Z9, 576(AX)
+	VMOVDQU32 Z10, 640(AX)
+	VMOVDQU32 Z11, 704(AX)
+	VZEROUPPER
+	RET
+
+// The AVX-512VL macros below take their temporaries as arguments so that they can be used on XMM
+// and YMM registers, whose EVEX encodings give access to VPROLD and VPTERNLOGD
+#define THETA_AVX512VL(p, a, b, c, t0, t1) \
+	VPROLD     $5, p, t0;                  \
+	VPROLD     $14, p, t1;                 \
+	VPTERNLOGD XOR3, t1, t0, a;            \
+	VPTERNLOGD XOR3, t1, t0, b;            \
+	VPTERNLOGD XOR3, t1, t0, c
+
+#define CHI_AVX512VL(b0, b1, b2, t0, t1) \
+	VMOVDQA32  b0, t0;                   \
+	VMOVDQA32  b1, t1;                   \
+	VPTERNLOGD CHI, b2, b1, b0;          \
+	VPTERNLOGD CHI, t0, b2, b1;          \
+	VPTERNLOGD CHI, t1, t0, b2
+
+// func permuteTimes4AVX512(lanes *uint32, rc *uint32, rounds int)
+TEXT ·permuteTimes4AVX512(SB), NOSPLIT, $0-24
+	MOVQ lanes+0(FP), AX
+	MOVQ rc+8(FP), CX
+	MOVQ rounds+16(FP), DX
+	VMOVDQU32 0(AX), X0
+	VMOVDQU32 16(AX), X1
+	VMOVDQU32 32(AX), X2
+	VMOVDQU32 48(AX), X3
+	VMOVDQU32 64(AX), X4
+	VMOVDQU32 80(AX), X5
+	VMOVDQU32 96(AX), X6
+	VMOVDQU32 112(AX), X7
+	VMOVDQU32 128(AX), X8
+	VMOVDQU32 144(AX), X9
+	VMOVDQU32 160(AX), X10
+	VMOVDQU32 176(AX), X11
+
+loop:
+	// theta
+	VMOVDQA32  X0, X12
+	VPTERNLOGD XOR3, X8, X4, X12
+	VMOVDQA32  X1, X13
+	VPTERNLOGD XOR3, X9, X5, X13
+	VMOVDQA32  X2, X14
+	VPTERNLOGD XOR3, X10, X6, X14
+	VMOVDQA32  X3, X15
+	VPTERNLOGD XOR3, X11, X7, X15
+	THETA_AVX512VL(X15, X0, X4, X8, X16, X17)
+	THETA_AVX512VL(X12, X1, X5, X9, X16, X17)
+	THETA_AVX512VL(X13, X2, X6, X10, X16, X17)
+	THETA_AVX512VL(X14, X3, X7, X11, X16, X17)
+
+	// rho west, the plane 1 shift is resolved by register selection in chi
+	VPROLD $11, X8, X8
+	VPROLD $11, X9, X9
+	VPROLD $11, X10, X10
+	VPROLD $11, X11, X11
+
+	// iota
+	VPBROADCASTD (CX), X16
+	VPXORD       X16, X0, X0
+
+	// chi
+	CHI_AVX512VL(X0, X7, X8, X16, X17)
+	CHI_AVX512VL(X1, X4, X9, X16, X17)
+	CHI_AVX512VL(X2, X5, X10, X16, X17)
+	CHI_AVX512VL(X3, X6, X11, X16, X17)
+
+	// rho east, writing planes 1 and 2 back to their home registers
+	VPROLD    $1, X7, X12
+	VPROLD    $1, X6, X7
+	VPROLD    $1, X5, X6
+	VPROLD    $1, X4, X5
+	VMOVDQA32 X12, X4
+	VPROLD    $8, X10, X12
+	VPROLD    $8, X11, X13
+	VPROLD    $8, X8, X10
+	VPROLD    $8, X9, X11
+	VMOVDQA32 X12, X8
+	VMOVDQA32 X13, X9
+
+	ADDQ $4, CX
+	DECQ DX
+	JNZ  loop
+
+	VMOVDQU32 X0, 0(AX)
+	VMOVDQU32 X1, 16(AX)
+	VMOVDQU32 X2, 32(AX)
+	VMOVDQU32 X3, 48(AX)
+	VMOVDQU32 X4, 64(AX)
+	VMOVDQU32 X5, 80(AX)
+	VMOVDQU32 X6, 96(AX)
+	VMOVDQU32 X7, 112(AX)
+	VMOVDQU32 X8, 128(AX)
+	VMOVDQU32 X9, 144(AX)
+	VMOVDQU32 X10, 160(AX)
+	VMOVDQU32 X11, 176(AX)
+	VZEROUPPER
+	RET
+
+// func permuteTimes8AVX512(lanes *uint32, rc *uint32, rounds int)
+TEXT ·permuteTimes8AVX512(SB), NOSPLIT, $0-24
+	MOVQ lanes+0(FP), AX
+	MOVQ rc+8(FP), CX
+	MOVQ rounds+16(FP), DX
+	VMOVDQU32 0(AX), Y0
+	VMOVDQU32 32(AX), Y1
+	VMOVDQU32 64(AX), Y2
+	VMOVDQU32 96(AX), Y3
+	VMOVDQU32 128(AX), Y4
+	VMOVDQU32 160(AX), Y5
+	VMOVDQU32 192(AX), Y6
+	VMOVDQU32 224(AX), Y7
+	VMOVDQU32 256(AX), Y8
+	VMOVDQU32 288(AX), Y9
+	VMOVDQU32 320(AX), Y10
+	VMOVDQU32 352(AX), Y11
+
+loop:
+	// theta
+	VMOVDQA32  Y0, Y12
+	VPTERNLOGD XOR3, Y8, Y4, Y12
+	VMOVDQA32  Y1, Y13
+	VPTERNLOGD XOR3, Y9, Y5, Y13
+	VMOVDQA32  Y2, Y14
+	VPTERNLOGD XOR3, Y10, Y6, Y14
+	VMOVDQA32  Y3, Y15
+	VPTERNLOGD XOR3, Y11, Y7, Y15
+	THETA_AVX512VL(Y15, Y0, Y4, Y8, Y16, Y17)
+	THETA_AVX512VL(Y12, Y1, Y5, Y9, Y16, Y17)
+	THETA_AVX512VL(Y13, Y2, Y6, Y10, Y16, Y17)
+	THETA_AVX512VL(Y14, Y3, Y7, Y11, Y16, Y17)
+
+	// rho west, the plane 1 shift is resolved by register selection in chi
+	VPROLD $11, Y8, Y8
+	VPROLD $11, Y9, Y9
+	VPROLD $11, Y10, Y10
+	VPROLD $11, Y11, Y11
+
+	// iota
+	VPBROADCASTD (CX), Y16
+	VPXORD       Y16, Y0, Y0
+
+	// chi
+	CHI_AVX512VL(Y0, Y7, Y8, Y16, Y17)
+	CHI_AVX512VL(Y1, Y4, Y9, Y16, Y17)
+	CHI_AVX512VL(Y2, Y5, Y10, Y16, Y17)
+	CHI_AVX512VL(Y3, Y6, Y11, Y16, Y17)
+
+	// rho east, writing planes 1 and 2 back to their home registers
+	VPROLD    $1, Y7, Y12
+	VPROLD    $1, Y6, Y7
+	VPROLD    $1, Y5, Y6
+	VPROLD    $1, Y4, Y5
+	VMOVDQA32 Y12, Y4
+	VPROLD    $8, Y10, Y12
+	VPROLD    $8, Y11, Y13
+	VPROLD    $8, Y8, Y10
+	VPROLD    $8, Y9, Y11
+	VMOVDQA32 Y12, Y8
+	VMOVDQA32 Y13, Y9
+
+	ADDQ $4, CX
+	DECQ DX
+	JNZ  loop
+
+	VMOVDQU32 Y0, 0(AX)
+	VMOVDQU32 Y1, 32(AX)
+	VMOVDQU32 Y2, 64(AX)
+	VMOVDQU32 Y3, 96(AX)
+	VMOVDQU32 Y4, 128(AX)
+	VMOVDQU32 Y5, 160(AX)
+	VMOVDQU32 Y6, 192(AX)
+	VMOVDQU32 Y7, 224(AX)
+	VMOVDQU32 Y8, 256(AX)
+	VMOVDQU32 Y9, 288(AX)
+	VMOVDQU32 Y10, 320(AX)
+	VMOVDQU32 Y11, 352(AX)
+	VZEROUPPER
+	RET
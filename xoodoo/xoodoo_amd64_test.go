@@ -0,0 +1,85 @@
+//go:build amd64 && !purego
+// +build amd64,!purego
+
+package xoodoo
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var permuteBackendsTestTable = []struct {
+	name      string
+	available bool
+	avx2      bool
+	avx512    bool
+}{
+	{name: "SSE2", available: true},
+	{name: "AVX2", available: hasAVX2, avx2: true},
+	{name: "AVX512", available: hasAVX512, avx2: true, avx512: true},
+}
+
+// withBackend runs f with the assembly routine selection forced to the given backend
+func withBackend(avx2, avx512 bool, f func()) {
+	savedAVX2, savedAVX512 := useAVX2, useAVX512
+	useAVX2, useAVX512 = avx2, avx512
+	defer func() { useAVX2, useAVX512 = savedAVX2, savedAVX512 }()
+	f()
+}
+
+func TestPermuteBackends(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for _, tt := range permuteBackendsTestTable {
+		if !tt.available {
+			t.Logf("%s not supported by CPU, skipping", tt.name)
+			continue
+		}
+		withBackend(tt.avx2, tt.avx512, func() {
+			for rounds := -1; rounds <= MaxRounds; rounds++ {
+				for trial := 0; trial < 16; trial++ {
+					var s State
+					for w := range s {
+						s[w] = rng.Uint32()
+					}
+					got, expected := s, s
+					permute(&got, rounds)
+					permuteGeneric(&expected, rounds)
+					assert.Equal(t, expected, got, "%s rounds:%d", tt.name, rounds)
+				}
+			}
+		})
+	}
+}
+
+func TestPermuteTimesBackends(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	for _, tt := range permuteBackendsTestTable {
+		if !tt.available {
+			t.Logf("%s not supported by CPU, skipping", tt.name)
+			continue
+		}
+		withBackend(tt.avx2, tt.avx512, func() {
+			for _, n := range []int{Times4, Times8, Times16} {
+				for rounds := -1; rounds <= MaxRounds; rounds++ {
+					lanes := make([]uint32, StateSizeWords*n)
+					for i := range lanes {
+						lanes[i] = rng.Uint32()
+					}
+					expected := append([]uint32{}, lanes...)
+					permuteTimes(lanes, n, rounds)
+					permuteTimesGeneric(expected, n, rounds)
+					assert.Equal(t, expected, lanes, "%s instances:%d rounds:%d", tt.name, n, rounds)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkPermuteGeneric(b *testing.B) {
+	var s State
+	for n := 0; n < b.N; n++ {
+		permuteGeneric(&s, MaxRounds)
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !amd64 || purego
+// +build !amd64 purego
+
+package xoodoo
+
+// permute applies the final number of rounds of the Xoodoo permutation to the provided state
+func permute(s *State, rounds int) {
+	permuteGeneric(s, rounds)
+}
+
+// permuteTimes applies the final number of rounds of the Xoodoo permutation to n interleaved
+// states
+func permuteTimes(lanes []uint32, n, rounds int) {
+	permuteTimesGeneric(lanes, n, rounds)
+}
@@ -360,3 +360,21 @@ func TestUnmarshalBinaryError(t *testing.T) {
 	gotErr := newXd.State.UnmarshalBinary(input)
 	assert.Equal(t, errors.New("input data (100 bytes) != xoodoo state size (48 bytes)"), gotErr)
 }
+
+func TestClone(t *testing.T) {
+	v := DefaultVariant(6)
+	v.RoundConstants = append([]uint32{}, v.RoundConstants...)
+	v.RoundConstants[MaxRounds-1] ^= 0x1
+	variantXd, _ := NewXoodooVariant(v, [StateSizeBytes]byte{0x01})
+	standardXd, _ := NewXoodoo(MaxRounds, [StateSizeBytes]byte{0x01})
+	for _, xd := range []*Xoodoo{standardXd, variantXd} {
+		clone := xd.Clone()
+		assert.Equal(t, xd.State, clone.State)
+		assert.Equal(t, xd.Variant(), clone.Variant())
+
+		xd.Permutation()
+		assert.NotEqual(t, xd.State, clone.State)
+		clone.Permutation()
+		assert.Equal(t, xd.State, clone.State)
+	}
+}
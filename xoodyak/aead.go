@@ -25,22 +25,43 @@ var (
 
 	// ErrEncryptStreamClosed is returned when trying to close an EncryptStream that has previously been closed
 	ErrEncryptStreamClosed = errors.New("xoodyak/aead: encryptstream already closed")
+
+	// ErrFault is returned by the fault-resistant AEAD functions when their redundant computations
+	// disagree, indicating a fault. No ciphertext, plaintext or tag is released in that case.
+	ErrFault = errors.New("xoodyak/aead: fault detected")
 )
 
+// checkKeyNonce checks the lengths of an AEAD key and nonce
+func checkKeyNonce(key, id []byte) error {
+	if len(key) != KeyLen {
+		return fmt.Errorf("xoodyak/aead: given key length (%d bytes) incorrect (%d bytes)", len(key), KeyLen)
+	}
+	if len(id) != NonceLen {
+		return fmt.Errorf("xoodyak/aead: given nonce length (%d bytes) incorrect (%d bytes)", len(id), NonceLen)
+	}
+	return nil
+}
+
 // CryptoEncryptAEAD encrypts a plaintext message given a 16-byte key, 16-bytes nonce, and optional
 // associated metadata bytes. Along with a cipher text, a 16-byte authentication tag is also generated
 // The ciphertext and tag data is compatible with the Xoodyak LWC AEAD  implementation.
 func CryptoEncryptAEAD(in, key, id, ad []byte) (ct, tag []byte, err error) {
-	if len(key) != KeyLen {
-		return []byte{}, []byte{}, fmt.Errorf("xoodyak/aead: given key length (%d bytes) incorrect (%d bytes)", len(key), KeyLen)
+	if err := checkKeyNonce(key, id); err != nil {
+		return []byte{}, []byte{}, err
 	}
-	if len(id) != NonceLen {
-		return []byte{}, []byte{}, fmt.Errorf("xoodyak/aead: given nonce length (%d bytes) incorrect (%d bytes)", len(id), NonceLen)
+	newXd, err := InstantiateChecked(key, id, nil)
+	if err != nil {
+		return []byte{}, []byte{}, err
+	}
+	if err = newXd.AbsorbChecked(ad); err != nil {
+		return []byte{}, []byte{}, err
+	}
+	if ct, err = newXd.EncryptChecked(in); err != nil {
+		return []byte{}, []byte{}, err
+	}
+	if tag, err = newXd.SqueezeChecked(TagLen); err != nil {
+		return []byte{}, []byte{}, err
 	}
-	newXd := Instantiate(key, id, nil)
-	newXd.Absorb(ad)
-	ct = newXd.Encrypt(in)
-	tag = newXd.Squeeze(TagLen)
 	return ct, tag, nil
 }
 
@@ -51,16 +72,23 @@ func CryptoEncryptAEAD(in, key, id, ad []byte) (ct, tag []byte, err error) {
 // The plaintext message is only returned if authentication is successful
 // This decryption process is compatible with the Xoodyak LWC AEAD implementation.
 func CryptoDecryptAEAD(in, key, id, ad, tag []byte) (pt []byte, valid bool, err error) {
-	if len(key) != KeyLen {
-		return []byte{}, false, fmt.Errorf("xoodyak/aead: given key length (%d bytes) incorrect (%d bytes)", len(key), KeyLen)
+	if err := checkKeyNonce(key, id); err != nil {
+		return []byte{}, false, err
 	}
-	if len(id) != NonceLen {
-		return []byte{}, false, fmt.Errorf("xoodyak/aead: given nonce length (%d bytes) incorrect (%d bytes)", len(id), NonceLen)
+	newXd, err := InstantiateChecked(key, id, nil)
+	if err != nil {
+		return []byte{}, false, err
+	}
+	if err = newXd.AbsorbChecked(ad); err != nil {
+		return []byte{}, false, err
+	}
+	if pt, err = newXd.DecryptChecked(in); err != nil {
+		return []byte{}, false, err
+	}
+	calculatedTag, err := newXd.SqueezeChecked(TagLen)
+	if err != nil {
+		return []byte{}, false, err
 	}
-	newXd := Instantiate(key, id, nil)
-	newXd.Absorb(ad)
-	pt = newXd.Decrypt(in)
-	calculatedTag := newXd.Squeeze(TagLen)
 	valid = true
 	if subtle.ConstantTimeCompare(calculatedTag, tag) != 1 {
 		valid = false
@@ -70,7 +98,10 @@ func CryptoDecryptAEAD(in, key, id, ad, tag []byte) (pt []byte, valid bool, err
 }
 
 type xoodyakAEAD struct {
-	key []byte
+	key            []byte
+	faultResistant bool
+	// instantiate creates the instances of a fault-resistant AEAD
+	instantiate instantiator
 }
 
 // NewXoodyakAEAD accepts a set of key bytes and returns object compatible with
@@ -105,7 +136,16 @@ func (a *xoodyakAEAD) Seal(dst, nonce, plaintext, additionalData []byte) []byte
 		panic(fmt.Sprintf("xoodyak/aead: given nonce length (%d bytes) incorrect (%d bytes)", len(nonce), NonceLen))
 	}
 
-	ct, tag, _ := CryptoEncryptAEAD(plaintext, a.key, nonce, additionalData)
+	var ct, tag []byte
+	var err error
+	if a.faultResistant {
+		ct, tag, err = encryptAEADFaultResistant(a.instantiate, plaintext, a.key, nonce, additionalData)
+	} else {
+		ct, tag, err = CryptoEncryptAEAD(plaintext, a.key, nonce, additionalData)
+	}
+	if err != nil {
+		panic(err)
+	}
 	output := ct
 	if dst != nil {
 		output = dst
@@ -135,7 +175,17 @@ func (a *xoodyakAEAD) Open(dst, nonce, ciphertext, additionalData []byte) ([]byt
 	}
 
 	tag := ciphertext[len(ciphertext)-TagLen:]
-	pt, valid, _ := CryptoDecryptAEAD(ciphertext[:len(ciphertext)-TagLen], a.key, nonce, additionalData, tag)
+	var pt []byte
+	var valid bool
+	var err error
+	if a.faultResistant {
+		pt, valid, err = decryptAEADFaultResistant(a.instantiate, ciphertext[:len(ciphertext)-TagLen], a.key, nonce, additionalData, tag)
+	} else {
+		pt, valid, err = CryptoDecryptAEAD(ciphertext[:len(ciphertext)-TagLen], a.key, nonce, additionalData, tag)
+	}
+	if err != nil {
+		return []byte{}, err
+	}
 	if !valid {
 		return []byte{}, ErrAuthOpen
 	}
@@ -154,26 +204,63 @@ type EncryptStream struct {
 	nx      int
 	cryptCu uint8
 	closed  bool
+	// redundant pairs xk with a shadow instance in a fault-resistant stream, and fault is set once
+	// the instances have disagreed
+	redundant *shadowed
+	fault     bool
+}
+
+// cryptBlock encrypts a block, checking it against the shadow instance of a fault-resistant stream
+func (es *EncryptStream) cryptBlock(pt []byte) ([]byte, error) {
+	if es.fault {
+		return nil, ErrFault
+	}
+	if es.redundant == nil {
+		return es.xk.CryptBlockChecked(pt, es.cryptCu, Encrypting)
+	}
+	ct, err := es.redundant.cryptBlock(pt, es.cryptCu, Encrypting)
+	es.fault = err != nil
+	return ct, err
+}
+
+// squeezeTag generates the tag, checking it against the shadow instance of a fault-resistant stream
+func (es *EncryptStream) squeezeTag() ([]byte, error) {
+	if es.fault {
+		return nil, ErrFault
+	}
+	if es.redundant == nil {
+		return es.xk.SqueezeChecked(TagLen)
+	}
+	tag, err := es.redundant.squeeze(TagLen)
+	es.fault = err != nil
+	return tag, err
 }
 
 // NewEncryptStream wraps an existing io.Writer with the Xoodyak LWC AEAD encryption engine given an
 // encryption key, nonce(id) and metadata(ad). The input message may be any length (including zero).
 func NewEncryptStream(target io.Writer, key, id, ad []byte) (*EncryptStream, error) {
-	if len(key) != KeyLen {
-		return nil, fmt.Errorf("xoodyak/aead: given key length (%d bytes) incorrect (%d bytes)", len(key), KeyLen)
+	return newEncryptStream(InstantiateChecked, target, key, id, ad)
+}
+
+func newEncryptStream(instantiate instantiator, target io.Writer, key, id, ad []byte) (*EncryptStream, error) {
+	if err := checkKeyNonce(key, id); err != nil {
+		return nil, err
 	}
-	if len(id) != NonceLen {
-		return nil, fmt.Errorf("xoodyak/aead: given nonce length (%d bytes) incorrect (%d bytes)", len(id), NonceLen)
+	xk, err := instantiate(key, id, nil)
+	if err != nil {
+		return nil, err
 	}
 	new := EncryptStream{
 		out:     target,
-		xk:      Instantiate(key, id, nil),
+		xk:      xk,
 		x:       make([]byte, xoodyakRkOut),
 		nx:      0,
 		cryptCu: CryptCuInit,
 		closed:  false,
 	}
-	new.xk.Absorb(ad)
+	if err := new.xk.AbsorbChecked(ad); err != nil {
+		return nil, err
+	}
 	return &new, nil
 }
 
@@ -188,7 +275,11 @@ func (es *EncryptStream) Write(p []byte) (n int, err error) {
 		n += nn
 		es.nx += nn
 		if es.nx == xoodyakRkOut {
-			ct, _ := es.xk.CryptBlock(es.x, es.cryptCu, Encrypting)
+			var ct []byte
+			ct, err = es.cryptBlock(es.x)
+			if err != nil {
+				return
+			}
 			_, err = es.out.Write(ct)
 			if err != nil {
 				err = fmt.Errorf("xoodyak/aead: encryptstream failed writing: %w", err)
@@ -202,7 +293,11 @@ func (es *EncryptStream) Write(p []byte) (n int, err error) {
 	if len(p) >= xoodyakRkOut {
 		nn := len(p) - (len(p) % xoodyakRkOut)
 		for i := 0; i < nn; i += xoodyakRkOut {
-			ct, _ := es.xk.CryptBlock(p[:xoodyakRkOut], es.cryptCu, Encrypting)
+			var ct []byte
+			ct, err = es.cryptBlock(p[:xoodyakRkOut])
+			if err != nil {
+				return
+			}
 			_, err = es.out.Write(ct)
 			n += xoodyakRkOut
 			if err != nil {
@@ -233,8 +328,11 @@ func (es *EncryptStream) Close() error {
 
 	// encrypt any remaining buffered plaintext
 	if es.nx > 0 {
-		ct, _ := es.xk.CryptBlock(es.x[:es.nx], es.cryptCu, Encrypting)
-		_, err := es.out.Write(ct)
+		ct, err := es.cryptBlock(es.x[:es.nx])
+		if err != nil {
+			return err
+		}
+		_, err = es.out.Write(ct)
 		if err != nil {
 			err = fmt.Errorf("xoodyak/aead: encryptstream failed writing end of stream: %w", err)
 			return err
@@ -245,12 +343,17 @@ func (es *EncryptStream) Close() error {
 	// If plaintext was empty, process a single empty block to advance the Xoodyak state to the point
 	// we can generate the tag
 	if es.cryptCu == CryptCuInit {
-		es.xk.CryptBlock([]byte{}, es.cryptCu, Encrypting)
+		if _, err := es.cryptBlock([]byte{}); err != nil {
+			return err
+		}
 	}
 
 	// Generate and write the auth tag to the
-	tag := es.xk.Squeeze(TagLen)
-	_, err := es.out.Write(tag)
+	tag, err := es.squeezeTag()
+	if err != nil {
+		return err
+	}
+	_, err = es.out.Write(tag)
 	if err != nil {
 		err = fmt.Errorf("xoodyak/aead: encryptstream failed writing auth tag: %w", err)
 	}
@@ -274,22 +377,25 @@ type DecryptStream struct {
 // NewDecryptStream wraps an existing io.Reader with the Xoodyak AEAD decryption engine with
 // a given encryption key, nonce(id) and metadata(ad).
 func NewDecryptStream(source io.Reader, key, id, ad []byte) (*DecryptStream, error) {
-	if len(key) != KeyLen {
-		return nil, fmt.Errorf("xoodyak/aead: given key length (%d bytes) incorrect (%d bytes)", len(key), KeyLen)
+	if err := checkKeyNonce(key, id); err != nil {
+		return nil, err
 	}
-	if len(id) != NonceLen {
-		return nil, fmt.Errorf("xoodyak/aead: given nonce length (%d bytes) incorrect (%d bytes)", len(id), NonceLen)
+	xk, err := InstantiateChecked(key, id, nil)
+	if err != nil {
+		return nil, err
 	}
 	new := DecryptStream{
 		in:       source,
-		xk:       Instantiate(key, id, nil),
+		xk:       xk,
 		x:        make([]byte, decryptBufSize),
 		nx:       0,
 		ptx:      0,
 		cryptCu:  CryptCuInit,
 		complete: false,
 	}
-	new.xk.Absorb(ad)
+	if err := new.xk.AbsorbChecked(ad); err != nil {
+		return nil, err
+	}
 	return &new, nil
 }
 
@@ -331,7 +437,10 @@ func (ds *DecryptStream) Read(p []byte) (n int, err error) {
 			// and have some bytes remaining in the buffer
 			if ds.nx == decryptBufSize || (ds.complete && (ds.nx > TagLen)) {
 				//Decrypt a full block of buffered ciphertext in place
-				pt, _ := ds.xk.CryptBlock(ds.x[:ds.nx-TagLen], ds.cryptCu, Decrypting)
+				pt, err := ds.xk.CryptBlockChecked(ds.x[:ds.nx-TagLen], ds.cryptCu, Decrypting)
+				if err != nil {
+					return n - ptRemain, err
+				}
 				if n != 0 {
 					ds.ptx = len(pt)
 					copy(ds.x, pt)
@@ -357,9 +466,14 @@ func (ds *DecryptStream) Read(p []byte) (n int, err error) {
 			// All that should remain in the buffer is the authentication tag bytes
 			if ds.cryptCu == CryptCuInit {
 				// Run one empty decrypt cycle if the ciphertext message len was 0
-				ds.xk.CryptBlock([]byte{}, ds.cryptCu, Decrypting)
+				if _, err := ds.xk.CryptBlockChecked([]byte{}, ds.cryptCu, Decrypting); err != nil {
+					return n - ptRemain, err
+				}
+			}
+			calculatedTag, err := ds.xk.SqueezeChecked(TagLen)
+			if err != nil {
+				return n - ptRemain, err
 			}
-			calculatedTag := ds.xk.Squeeze(TagLen)
 			ds.nx = 0
 			if subtle.ConstantTimeCompare(calculatedTag, ds.x[:TagLen]) != 1 {
 				return n - ptRemain, ErrAuthOpen
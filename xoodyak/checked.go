@@ -0,0 +1,311 @@
+package xoodyak
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/inmcm/xoodoo/xoodoo"
+)
+
+/* Error-Returning Cyclist Interface */
+
+// The Checked methods below mirror the Cyclist methods of Xoodyak, returning an error where the
+// latter panic on misuse. The errors wrap one of these sentinel values, to be tested with
+// errors.Is.
+var (
+	// ErrNotKeyed is returned by operations that are only defined in keyed mode when called on an
+	// instance in hash mode
+	ErrNotKeyed = errors.New("only available in keyed mode")
+
+	// ErrKeyNonceTooLong is returned when the key and nonce do not fit a single keyed absorb block
+	ErrKeyNonceTooLong = errors.New("key and nonce lengths too large")
+
+	// ErrBlockTooLarge is returned when a block given to Down or requested from Up does not fit the
+	// Xoodoo state
+	ErrBlockTooLarge = errors.New("block size exceeds Xoodoo state size")
+
+	// ErrInvalidRate is returned when a non-empty input is absorbed or output is squeezed at a rate of
+	// zero, which would never finish. Rates too large for the state fail with ErrBlockTooLarge.
+	ErrInvalidRate = errors.New("invalid rate")
+)
+
+// InstantiateChecked generates a new Xoodyak object initialized for hashing or keyed operations as
+// done by Instantiate, returning an error instead of panicking when the key and nonce are too long
+func InstantiateChecked(key, id, counter []byte) (*Xoodyak, error) {
+	newXK := Xoodyak{}
+	newXK.Instance, _ = xoodoo.NewXoodoo(xoodoo.MaxRounds, [48]byte{})
+	newXK.Mode = Hash
+	newXK.Phase = Up
+	newXK.AbsorbSize = xoodyakHashIn
+	newXK.SqueezeSize = xoodyakHashIn
+	if len(key) != 0 {
+		if err := newXK.AbsorbKeyChecked(key, id, counter); err != nil {
+			return nil, err
+		}
+	}
+	return &newXK, nil
+}
+
+// AbsorbChecked ingests a provided message at the rate of the Xoodyak instance's absorption size,
+// returning ErrInvalidRate if that size is unusable
+func (xk *Xoodyak) AbsorbChecked(x []byte) error {
+	return xk.AbsorbAnyChecked(x, xk.AbsorbSize, AbsorbCdInit)
+}
+
+// EncryptChecked transforms the provided plaintext message into a ciphertext message of equal size,
+// returning ErrNotKeyed if the instance is not in keyed mode
+func (xk *Xoodyak) EncryptChecked(pt []byte) ([]byte, error) {
+	if xk.Mode != Keyed {
+		return nil, fmt.Errorf("encrypt %w", ErrNotKeyed)
+	}
+	return xk.CryptChecked(pt, Encrypting)
+}
+
+// DecryptChecked transforms the provided ciphertext message into a plaintext message of equal size,
+// returning ErrNotKeyed if the instance is not in keyed mode
+func (xk *Xoodyak) DecryptChecked(ct []byte) ([]byte, error) {
+	if xk.Mode != Keyed {
+		return nil, fmt.Errorf("decrypt %w", ErrNotKeyed)
+	}
+	return xk.CryptChecked(ct, Decrypting)
+}
+
+// CryptChecked transforms a message of any length into its encryption or decryption as done by
+// Crypt, returning an error instead of panicking
+func (xk *Xoodyak) CryptChecked(msg []byte, cm CryptMode) ([]byte, error) {
+	cuTmp := CryptCuInit
+	processed := 0
+	remaining := len(msg)
+	cryptLen := xoodyakRkOut
+	out := make([]byte, remaining)
+	for {
+		if remaining < cryptLen {
+			cryptLen = remaining
+		}
+		xorBytes, err := xk.CryptBlockChecked(msg[processed:processed+cryptLen], cuTmp, cm)
+		if err != nil {
+			return nil, err
+		}
+		copy(out[processed:], xorBytes)
+		cuTmp = CryptCuMain
+		remaining -= cryptLen
+		processed += cryptLen
+		if remaining == 0 {
+			return out, nil
+		}
+	}
+}
+
+// CryptBlockChecked executes one step of the encryption/decryption cycle on the provided bytes as
+// done by CryptBlock, returning ErrBlockTooLarge if they exceed the keyed squeeze rate
+func (xk *Xoodyak) CryptBlockChecked(msg []byte, cu uint8, cm CryptMode) ([]byte, error) {
+	if len(msg) > xoodyakRkOut {
+		return nil, fmt.Errorf("%w: input size [%d], max encryption block size [%d]", ErrBlockTooLarge, len(msg), xoodyakRkOut)
+	}
+	if _, err := xk.UpChecked(cu, 0); err != nil {
+		return nil, err
+	}
+	xorBytes, _ := xk.Instance.XorExtractBytes(msg)
+	down := msg
+	if cm != Encrypting {
+		down = xorBytes
+	}
+	if err := xk.DownChecked(down, CryptCd); err != nil {
+		return nil, err
+	}
+	return xorBytes, nil
+}
+
+// SqueezeChecked outputs a provided number of pseudo-random bytes at the rate of the Xoodyak
+// instance's squeeze size, returning ErrInvalidRate if that size is unusable
+func (xk *Xoodyak) SqueezeChecked(outLen uint) ([]byte, error) {
+	return xk.SqueezeAnyChecked(outLen, SqueezeCuInit)
+}
+
+// SqueezeKeyChecked generates a new encryption key from the existing Xoodyak state, returning
+// ErrNotKeyed if the instance is not in keyed mode
+func (xk *Xoodyak) SqueezeKeyChecked(keyLen uint) ([]byte, error) {
+	if xk.Mode != Keyed {
+		return nil, fmt.Errorf("squeeze key %w", ErrNotKeyed)
+	}
+	return xk.SqueezeAnyChecked(keyLen, 0x20)
+}
+
+// RatchetChecked performs an irreversible transformation of the underlying Xoodoo state to prevent
+// key recovery, returning ErrNotKeyed if the instance is not in keyed mode
+func (xk *Xoodyak) RatchetChecked() error {
+	if xk.Mode != Keyed {
+		return fmt.Errorf("ratchet %w", ErrNotKeyed)
+	}
+	ratchetSqueeze, err := xk.SqueezeAnyChecked(xoodyakRatchet, RatchetCu)
+	if err != nil {
+		return err
+	}
+	return xk.AbsorbAnyChecked(ratchetSqueeze, xk.AbsorbSize, AbsorbCdMain)
+}
+
+// AbsorbBlockChecked ingests a single block of bytes encompassing a single iteration of the Cyclist
+// sequence, returning ErrBlockTooLarge if the block does not fit
+func (xk *Xoodyak) AbsorbBlockChecked(x []byte, cd uint8) error {
+	if err := checkDownLen(len(x)); err != nil {
+		return err
+	}
+	if xk.Phase != Up {
+		if _, err := xk.UpChecked(0, 0); err != nil {
+			return err
+		}
+	}
+	return xk.DownChecked(x, cd)
+}
+
+// AbsorbAnyChecked allows input of any number of bytes into the Xoodoo state at rate r, returning
+// ErrInvalidRate if r is zero and x is not empty, or ErrBlockTooLarge if the blocks of x at rate r
+// do not fit the state. The state is left untouched when an error is returned.
+func (xk *Xoodyak) AbsorbAnyChecked(x []byte, r uint, cd uint8) error {
+	if r == 0 && len(x) > 0 {
+		return fmt.Errorf("%w: absorb rate [0] with [%d] input bytes", ErrInvalidRate, len(x))
+	}
+	if uint(len(x)) < r {
+		if err := checkDownLen(len(x)); err != nil {
+			return err
+		}
+	} else if err := checkDownLen(int(r)); err != nil {
+		return err
+	}
+	var cdTmp uint8 = cd
+	var processed uint = 0
+	var remaining uint = uint(len(x))
+	absorbLen := r
+	for {
+		if xk.Phase != Up {
+			if _, err := xk.UpChecked(0, 0); err != nil {
+				return err
+			}
+		}
+		if remaining < absorbLen {
+			absorbLen = remaining
+		}
+		if err := xk.DownChecked(x[processed:processed+absorbLen], cdTmp); err != nil {
+			return err
+		}
+		cdTmp = AbsorbCdMain
+		remaining -= absorbLen
+		processed += absorbLen
+		if remaining == 0 {
+			return nil
+		}
+	}
+}
+
+// AbsorbKeyChecked ingests the provided key, id (nonce) and counter messages into the Xoodoo state
+// enabling the keyed mode of operation, returning ErrKeyNonceTooLong if the key and nonce do not
+// fit a single block
+func (xk *Xoodyak) AbsorbKeyChecked(key, id, counter []byte) error {
+	if len(key)+len(id) >= xoodyakRkIn {
+		return fmt.Errorf("%w - key:%d nonce:%d combined:%d max:%d", ErrKeyNonceTooLong, len(key), len(id), len(key)+len(id), xoodyakRkIn-1)
+	}
+	xk.Mode = Keyed
+	xk.AbsorbSize = xoodyakRkIn
+	xk.SqueezeSize = xoodyakRkOut
+	if len(key) > 0 {
+		keyIDBuf := make([]byte, 0, len(key)+len(id)+1)
+		keyIDBuf = append(keyIDBuf, key...)
+		keyIDBuf = append(keyIDBuf, id...)
+		keyIDBuf = append(keyIDBuf, byte(len(id)))
+		if err := xk.AbsorbAnyChecked(keyIDBuf, xk.AbsorbSize, 0x02); err != nil {
+			return err
+		}
+		if len(counter) > 0 {
+			if err := xk.AbsorbAnyChecked(counter, 1, 0x00); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// SqueezeAnyChecked allows generation of any number of pseudo-random bytes from the underlying
+// Xoodoo state, returning ErrInvalidRate if output is requested from an instance with a squeeze size
+// of zero
+func (xk *Xoodyak) SqueezeAnyChecked(YLen uint, Cu uint8) ([]byte, error) {
+	if xk.SqueezeSize == 0 && YLen > 0 {
+		return nil, fmt.Errorf("%w: squeeze rate [0] with [%d] output bytes", ErrInvalidRate, YLen)
+	}
+	squeezeLen := xk.SqueezeSize
+	if YLen < squeezeLen {
+		squeezeLen = YLen
+	}
+	output, err := xk.UpChecked(Cu, squeezeLen)
+	if err != nil {
+		return nil, err
+	}
+	var remaining uint = YLen - squeezeLen
+
+	for remaining > 0 {
+		if err := xk.DownChecked([]byte{}, 0); err != nil {
+			return nil, err
+		}
+		if remaining < squeezeLen {
+			squeezeLen = remaining
+		}
+		block, err := xk.UpChecked(0, squeezeLen)
+		if err != nil {
+			return nil, err
+		}
+		output = append(output, block...)
+		remaining -= squeezeLen
+	}
+	return output, nil
+}
+
+// checkDownLen checks that a block of n bytes leaves room in the state for the Cd byte. This is the
+// limit Down has always applied; the Cyclist operations never give it more than the keyed absorb
+// rate.
+func checkDownLen(n int) error {
+	if n >= xoodoo.StateSizeBytes {
+		return fmt.Errorf("%w: input slice size [%d], max [%d]", ErrBlockTooLarge, n, xoodoo.StateSizeBytes-1)
+	}
+	return nil
+}
+
+// DownChecked injects the provided slice of bytes into the Xoodoo state via xor with the existing
+// state, returning ErrBlockTooLarge if the block leaves no room for the Cd byte
+func (xk *Xoodyak) DownChecked(Xi []byte, Cd byte) error {
+	if err := checkDownLen(len(Xi)); err != nil {
+		return err
+	}
+	cd1 := Cd
+	if xk.Mode == Hash {
+		cd1 &= 0x01
+	}
+	fill := make([]byte, xoodoo.StateSizeBytes)
+	copy(fill, Xi)
+	fill[len(Xi)] = 0x01
+	fill[len(fill)-1] = cd1
+	xk.Instance.State.XorStateBytes(fill)
+	xk.Phase = Down
+	if xk.tracer != nil {
+		xk.tracer(Down, cd1, append([]byte{}, Xi...), xk.Instance.State)
+	}
+	return nil
+}
+
+// UpChecked applies the Xoodoo permutation to the Xoodoo state and returns the requested number of
+// bytes, returning ErrBlockTooLarge if more bytes than the state holds are requested
+func (xk *Xoodyak) UpChecked(Cu byte, Yilen uint) ([]byte, error) {
+	if Yilen > xoodoo.StateSizeBytes {
+		return nil, fmt.Errorf("%w: requested number of bytes [%d], max [%d]", ErrBlockTooLarge, Yilen, xoodoo.StateSizeBytes)
+	}
+	var cu byte
+	if xk.Mode != Hash {
+		cu = Cu
+		xk.Instance.State.XorByte(cu, xoodoo.StateSizeBytes-1)
+	}
+	xk.Instance.Permutation()
+	xk.Phase = Up
+	out := xk.Instance.Bytes()[:Yilen]
+	if xk.tracer != nil {
+		xk.tracer(Up, cu, append([]byte{}, out...), xk.Instance.State)
+	}
+	return out, nil
+}
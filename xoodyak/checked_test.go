@@ -0,0 +1,197 @@
+package xoodyak
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/inmcm/xoodoo/xoodoo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstantiateChecked(t *testing.T) {
+	for _, tt := range absorbKeyPanicTestTable {
+		key := make([]byte, tt.keySize)
+		nonce := make([]byte, tt.nonceSize)
+		gotXK, gotErr := InstantiateChecked(key, nonce, nil)
+		if tt.panicErr != "" {
+			assert.EqualError(t, gotErr, tt.panicErr)
+			assert.True(t, errors.Is(gotErr, ErrKeyNonceTooLong))
+			assert.Nil(t, gotXK)
+			continue
+		}
+		assert.NoError(t, gotErr)
+		assert.Equal(t, Instantiate(key, nonce, nil).Instance.State, gotXK.Instance.State)
+	}
+}
+
+func TestAbsorbKeyCheckedKeepsKey(t *testing.T) {
+	key := make([]byte, 16, 64)
+	nonce := []byte{0x01, 0x02, 0x03}
+	spare := key[:20]
+	_, err := InstantiateChecked(key, nonce, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, make([]byte, 20), spare)
+}
+
+func TestCheckedWrongMode(t *testing.T) {
+	var checkedWrongModeTestTable = []struct {
+		name string
+		op   func(xk *Xoodyak) error
+	}{
+		{
+			name: "encrypt",
+			op: func(xk *Xoodyak) error {
+				_, err := xk.EncryptChecked(make([]byte, 64))
+				return err
+			},
+		},
+		{
+			name: "decrypt",
+			op: func(xk *Xoodyak) error {
+				_, err := xk.DecryptChecked(make([]byte, 64))
+				return err
+			},
+		},
+		{
+			name: "squeeze key",
+			op: func(xk *Xoodyak) error {
+				_, err := xk.SqueezeKeyChecked(10)
+				return err
+			},
+		},
+		{
+			name: "ratchet",
+			op: func(xk *Xoodyak) error {
+				return xk.RatchetChecked()
+			},
+		},
+	}
+	for _, tt := range checkedWrongModeTestTable {
+		newXK, _ := InstantiateChecked(nil, nil, nil)
+		newXK.Absorb([]byte("hash mode"))
+		before := newXK.Instance.State
+		gotErr := tt.op(newXK)
+		assert.EqualError(t, gotErr, tt.name+" only available in keyed mode")
+		assert.True(t, errors.Is(gotErr, ErrNotKeyed), tt.name)
+		assert.Equal(t, before, newXK.Instance.State, tt.name)
+	}
+}
+
+func TestDownUpChecked(t *testing.T) {
+	newXK, _ := InstantiateChecked(nil, nil, nil)
+	before := newXK.Instance.State
+
+	gotErr := newXK.DownChecked(make([]byte, xoodoo.StateSizeBytes), 0x00)
+	assert.EqualError(t, gotErr, "block size exceeds Xoodoo state size: input slice size [48], max [47]")
+	assert.True(t, errors.Is(gotErr, ErrBlockTooLarge))
+
+	gotErr = newXK.AbsorbBlockChecked(make([]byte, 200), 0x00)
+	assert.True(t, errors.Is(gotErr, ErrBlockTooLarge))
+
+	gotOut, gotErr := newXK.CryptBlockChecked(make([]byte, xoodyakRkOut+1), CryptCuInit, Encrypting)
+	assert.EqualError(t, gotErr, "block size exceeds Xoodoo state size: input size [25], max encryption block size [24]")
+	assert.True(t, errors.Is(gotErr, ErrBlockTooLarge))
+	assert.Nil(t, gotOut)
+
+	gotOut, gotErr = newXK.UpChecked(0x80, xoodoo.StateSizeBytes+1)
+	assert.EqualError(t, gotErr, "block size exceeds Xoodoo state size: requested number of bytes [49], max [48]")
+	assert.True(t, errors.Is(gotErr, ErrBlockTooLarge))
+	assert.Nil(t, gotOut)
+	assert.Equal(t, before, newXK.Instance.State)
+
+	gotErr = newXK.DownChecked(make([]byte, xoodoo.StateSizeBytes-1), 0x00)
+	assert.NoError(t, gotErr)
+	gotOut, gotErr = newXK.UpChecked(0x00, xoodoo.StateSizeBytes)
+	assert.NoError(t, gotErr)
+	assert.Len(t, gotOut, xoodoo.StateSizeBytes)
+}
+
+func TestCheckedInvalidRates(t *testing.T) {
+	newXK, _ := InstantiateChecked(nil, nil, nil)
+	before := newXK.Instance.State
+
+	gotErr := newXK.AbsorbAnyChecked([]byte("rate zero would never finish"), 0, AbsorbCdInit)
+	assert.EqualError(t, gotErr, "invalid rate: absorb rate [0] with [28] input bytes")
+	assert.True(t, errors.Is(gotErr, ErrInvalidRate))
+
+	gotErr = newXK.AbsorbAnyChecked(make([]byte, 100), xoodoo.StateSizeBytes, AbsorbCdInit)
+	assert.EqualError(t, gotErr, "block size exceeds Xoodoo state size: input slice size [48], max [47]")
+	assert.True(t, errors.Is(gotErr, ErrBlockTooLarge))
+	gotErr = newXK.AbsorbAnyChecked(make([]byte, xoodoo.StateSizeBytes), 200, AbsorbCdInit)
+	assert.True(t, errors.Is(gotErr, ErrBlockTooLarge))
+	assert.Equal(t, before, newXK.Instance.State)
+
+	newXK.AbsorbSize = 0
+	gotErr = newXK.AbsorbChecked([]byte("absorb"))
+	assert.True(t, errors.Is(gotErr, ErrInvalidRate))
+
+	newXK.SqueezeSize = 0
+	gotOut, gotErr := newXK.SqueezeChecked(32)
+	assert.EqualError(t, gotErr, "invalid rate: squeeze rate [0] with [32] output bytes")
+	assert.True(t, errors.Is(gotErr, ErrInvalidRate))
+	assert.Nil(t, gotOut)
+	assert.Equal(t, before, newXK.Instance.State)
+
+	// A rate of zero is harmless when there is nothing to absorb or squeeze, as it always was
+	assert.NoError(t, newXK.AbsorbAnyChecked(nil, 0, AbsorbCdInit))
+	gotOut, gotErr = newXK.SqueezeChecked(0)
+	assert.NoError(t, gotErr)
+	assert.Empty(t, gotOut)
+
+	// Rates wider than the keyed rates keep working up to the size of the state
+	wide, _ := InstantiateChecked(nil, nil, nil)
+	assert.NoError(t, wide.AbsorbAnyChecked(make([]byte, 100), xoodoo.StateSizeBytes-1, AbsorbCdInit))
+	wide.SqueezeSize = xoodoo.StateSizeBytes
+	gotOut, gotErr = wide.SqueezeChecked(100)
+	assert.NoError(t, gotErr)
+	assert.Len(t, gotOut, 100)
+}
+
+func TestCheckedMatchesCyclist(t *testing.T) {
+	key := []byte{0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88, 0x99, 0xAA, 0xBB, 0xCC, 0xDD, 0xEE, 0xFF}
+	nonce := []byte{0xF0, 0xE1, 0xD2, 0xC3, 0xB4, 0xA5, 0x96, 0x87}
+	counter := []byte{0x01, 0x02}
+	msg := []byte("a message spanning more than one block of the keyed rates")
+
+	want := Instantiate(key, nonce, counter)
+	want.Absorb(msg)
+	wantCT := want.Encrypt(msg)
+	wantPT := want.Decrypt(msg)
+	want.Ratchet()
+	wantKey := want.SqueezeKey(32)
+	wantOut := want.Squeeze(50)
+
+	got, err := InstantiateChecked(key, nonce, counter)
+	assert.NoError(t, err)
+	assert.NoError(t, got.AbsorbChecked(msg))
+	gotCT, err := got.EncryptChecked(msg)
+	assert.NoError(t, err)
+	assert.Equal(t, wantCT, gotCT)
+	gotPT, err := got.DecryptChecked(msg)
+	assert.NoError(t, err)
+	assert.Equal(t, wantPT, gotPT)
+	assert.NoError(t, got.RatchetChecked())
+	gotKey, err := got.SqueezeKeyChecked(32)
+	assert.NoError(t, err)
+	assert.Equal(t, wantKey, gotKey)
+	gotOut, err := got.SqueezeChecked(50)
+	assert.NoError(t, err)
+	assert.Equal(t, wantOut, gotOut)
+	assert.Equal(t, want.Instance.State, got.Instance.State)
+}
+
+func TestCheckedPanickingCounterparts(t *testing.T) {
+	newXK, _ := InstantiateChecked(nil, nil, nil)
+	assert.PanicsWithError(t, "invalid rate: absorb rate [0] with [1] input bytes", func() {
+		newXK.AbsorbAny([]byte{0x01}, 0, AbsorbCdInit)
+	})
+	assert.PanicsWithError(t, "block size exceeds Xoodoo state size: input slice size [48], max [47]", func() {
+		newXK.AbsorbBlock(make([]byte, 48), AbsorbCdInit)
+	})
+	assert.NotPanics(t, func() {
+		newXK.AbsorbBlock(make([]byte, 47), AbsorbCdInit)
+	})
+	assert.PanicsWithError(t, "key and nonce lengths too large - key:44 nonce:0 combined:44 max:43", func() {
+		Instantiate(make([]byte, 44), nil, nil)
+	})
+}
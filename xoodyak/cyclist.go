@@ -0,0 +1,209 @@
+package xoodyak
+
+import (
+	"errors"
+	"fmt"
+)
+
+/* Sealed Cyclist Interface */
+
+var (
+	// ErrNotInstantiated is returned by the methods of a Cyclist that was not created by NewCyclist
+	ErrNotInstantiated = errors.New("cyclist not instantiated")
+
+	// ErrIllegalSequence is returned by the methods of a Cyclist when the specification does not
+	// allow the operation after those already applied, such as absorbing a key after any other
+	// operation
+	ErrIllegalSequence = errors.New("not allowed at this point of the Cyclist sequence")
+)
+
+// Operation identifies a Cyclist operation
+type Operation uint8
+
+const (
+	OpAbsorb Operation = iota + 1
+	OpEncrypt
+	OpDecrypt
+	OpSqueeze
+	OpSqueezeKey
+	OpRatchet
+	OpAbsorbKey
+)
+
+var operationNames = map[Operation]string{
+	OpAbsorb:     "absorb",
+	OpEncrypt:    "encrypt",
+	OpDecrypt:    "decrypt",
+	OpSqueeze:    "squeeze",
+	OpSqueezeKey: "squeeze key",
+	OpRatchet:    "ratchet",
+	OpAbsorbKey:  "absorb key",
+}
+
+func (op Operation) String() string {
+	if name, ok := operationNames[op]; ok {
+		return name
+	}
+	return fmt.Sprintf("Operation(%d)", uint8(op))
+}
+
+// keyedOperations lists the operations the Cyclist specification only allows in keyed mode
+var keyedOperations = map[Operation]bool{
+	OpEncrypt:    true,
+	OpDecrypt:    true,
+	OpSqueezeKey: true,
+	OpRatchet:    true,
+}
+
+// legal returns an error if the Cyclist specification does not allow op in the given mode after
+// the last operation applied, which is zero if there was none. The key can only be absorbed as the
+// first operation, which is what puts a Cyclist in keyed mode for good.
+func legal(mode CyclistMode, last, op Operation) error {
+	switch {
+	case op == OpAbsorbKey:
+		if last != 0 {
+			return fmt.Errorf("%s after %s: %w", op, last, ErrIllegalSequence)
+		}
+	case keyedOperations[op]:
+		if mode != Keyed {
+			return fmt.Errorf("%s %w", op, ErrNotKeyed)
+		}
+	case op != OpAbsorb && op != OpSqueeze:
+		return fmt.Errorf("%s: %w", op, ErrIllegalSequence)
+	}
+	return nil
+}
+
+// Cyclist is a sealed instance of the Cyclist mode of operation on the Xoodoo permutation, following
+// the Xoodyak specification. Unlike Xoodyak, it exposes only the operations of the specification,
+// keeps its mode, phase and rates private and tracks the operations applied to it, rejecting any
+// operation the specification does not allow at that point. The key, nonce and counter can only be
+// absorbed at instantiation.
+//
+// The zero value is not usable; create instances with NewCyclist.
+type Cyclist struct {
+	xk *Xoodyak
+	// mode is the mode set by the operations applied so far, and last the latest of them. Unlike
+	// the fields of xk, they only change through the validated operations.
+	mode CyclistMode
+	last Operation
+}
+
+// NewCyclist returns a Cyclist in keyed mode that has absorbed the key, id (nonce) and counter, or
+// in hash mode if the key is empty. An id or counter given without a key is rejected, as hash mode
+// has no use for them.
+func NewCyclist(key, id, counter []byte) (*Cyclist, error) {
+	if len(key) == 0 && (len(id) != 0 || len(counter) != 0) {
+		return nil, fmt.Errorf("nonce and counter %w", ErrNotKeyed)
+	}
+	xk, _ := InstantiateChecked(nil, nil, nil)
+	c := &Cyclist{xk: xk, mode: Hash}
+	if len(key) != 0 {
+		if err := c.begin(OpAbsorbKey); err != nil {
+			return nil, err
+		}
+		if err := c.record(OpAbsorbKey, c.xk.AbsorbKeyChecked(key, id, counter)); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// Mode returns the mode the Cyclist was instantiated in
+func (c *Cyclist) Mode() CyclistMode {
+	return c.mode
+}
+
+// Last returns the last operation applied to the Cyclist, which is OpAbsorbKey right after a keyed
+// instantiation and zero right after a hash instantiation. Operations that were rejected are not
+// taken into account.
+func (c *Cyclist) Last() Operation {
+	return c.last
+}
+
+// Clone returns an independent copy of the Cyclist, including its state, so that a transcript can
+// be forked after absorbing a common prefix
+func (c *Cyclist) Clone() *Cyclist {
+	clone := *c
+	if c.xk != nil {
+		clone.xk = c.xk.Clone()
+	}
+	return &clone
+}
+
+// begin validates an operation against the operations already applied before it is applied
+func (c *Cyclist) begin(op Operation) error {
+	if c.xk == nil {
+		return fmt.Errorf("%s: %w", op, ErrNotInstantiated)
+	}
+	return legal(c.mode, c.last, op)
+}
+
+// record notes a successfully applied operation
+func (c *Cyclist) record(op Operation, err error) error {
+	if err != nil {
+		return err
+	}
+	if op == OpAbsorbKey {
+		c.mode = Keyed
+	}
+	c.last = op
+	return nil
+}
+
+// Absorb ingests a message of any length
+func (c *Cyclist) Absorb(x []byte) error {
+	if err := c.begin(OpAbsorb); err != nil {
+		return err
+	}
+	return c.record(OpAbsorb, c.xk.AbsorbChecked(x))
+}
+
+// Encrypt returns the encryption of a plaintext message of any length. It is only available in
+// keyed mode.
+func (c *Cyclist) Encrypt(pt []byte) ([]byte, error) {
+	if err := c.begin(OpEncrypt); err != nil {
+		return nil, err
+	}
+	ct, err := c.xk.EncryptChecked(pt)
+	return ct, c.record(OpEncrypt, err)
+}
+
+// Decrypt returns the decryption of a ciphertext message of any length. It is only available in
+// keyed mode. The plaintext must not be released before it has been authenticated, typically by
+// comparing a tag obtained with Squeeze.
+func (c *Cyclist) Decrypt(ct []byte) ([]byte, error) {
+	if err := c.begin(OpDecrypt); err != nil {
+		return nil, err
+	}
+	pt, err := c.xk.DecryptChecked(ct)
+	return pt, c.record(OpDecrypt, err)
+}
+
+// Squeeze returns outLen bytes of output depending on everything absorbed so far
+func (c *Cyclist) Squeeze(outLen uint) ([]byte, error) {
+	if err := c.begin(OpSqueeze); err != nil {
+		return nil, err
+	}
+	out, err := c.xk.SqueezeChecked(outLen)
+	return out, c.record(OpSqueeze, err)
+}
+
+// SqueezeKey returns keyLen bytes of output suitable as a new key, domain separated from Squeeze.
+// It is only available in keyed mode.
+func (c *Cyclist) SqueezeKey(keyLen uint) ([]byte, error) {
+	if err := c.begin(OpSqueezeKey); err != nil {
+		return nil, err
+	}
+	key, err := c.xk.SqueezeKeyChecked(keyLen)
+	return key, c.record(OpSqueezeKey, err)
+}
+
+// Ratchet irreversibly transforms the state, so that a later compromise of the state does not
+// reveal earlier outputs or the key. It is only available in keyed mode.
+func (c *Cyclist) Ratchet() error {
+	if err := c.begin(OpRatchet); err != nil {
+		return err
+	}
+	return c.record(OpRatchet, c.xk.RatchetChecked())
+}
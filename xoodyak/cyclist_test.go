@@ -0,0 +1,283 @@
+package xoodyak
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/inmcm/xoodoo/xoodoo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCyclistAEAD(t *testing.T) {
+	for _, tt := range cryptoAEADTestTable {
+		enc, err := NewCyclist(tt.key, tt.nonce, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, Keyed, enc.Mode())
+		assert.NoError(t, enc.Absorb(tt.ad))
+		ct, err := enc.Encrypt(tt.plaintext)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.ciphertext, ct)
+		tag, err := enc.Squeeze(TagLen)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.tag, tag)
+
+		dec, err := NewCyclist(tt.key, tt.nonce, nil)
+		assert.NoError(t, err)
+		assert.NoError(t, dec.Absorb(tt.ad))
+		pt, err := dec.Decrypt(tt.ciphertext)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.plaintext, pt)
+		tag, err = dec.Squeeze(TagLen)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.tag, tag)
+	}
+}
+
+func TestCyclistHash(t *testing.T) {
+	msg := []byte("The quick brown fox jumps over the lazy dog")
+	c, err := NewCyclist(nil, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, Hash, c.Mode())
+	assert.NoError(t, c.Absorb(msg))
+	got, err := c.Squeeze(cryptoHashBytes)
+	assert.NoError(t, err)
+	assert.Equal(t, HashXoodyak(msg), got)
+}
+
+func TestCyclistHashModeRejects(t *testing.T) {
+	var cyclistKeyedOnlyTestTable = []struct {
+		op      Operation
+		call    func(c *Cyclist) error
+		wantErr string
+	}{
+		{
+			op: OpEncrypt,
+			call: func(c *Cyclist) error {
+				_, err := c.Encrypt([]byte("pt"))
+				return err
+			},
+			wantErr: "encrypt only available in keyed mode",
+		},
+		{
+			op: OpDecrypt,
+			call: func(c *Cyclist) error {
+				_, err := c.Decrypt([]byte("ct"))
+				return err
+			},
+			wantErr: "decrypt only available in keyed mode",
+		},
+		{
+			op: OpSqueezeKey,
+			call: func(c *Cyclist) error {
+				_, err := c.SqueezeKey(16)
+				return err
+			},
+			wantErr: "squeeze key only available in keyed mode",
+		},
+		{
+			op: OpRatchet,
+			call: func(c *Cyclist) error {
+				return c.Ratchet()
+			},
+			wantErr: "ratchet only available in keyed mode",
+		},
+	}
+	for _, tt := range cyclistKeyedOnlyTestTable {
+		c, _ := NewCyclist(nil, nil, nil)
+		assert.NoError(t, c.Absorb([]byte("msg")))
+		before := c.xk.Instance.State
+		gotErr := tt.call(c)
+		assert.EqualError(t, gotErr, tt.wantErr)
+		assert.True(t, errors.Is(gotErr, ErrNotKeyed), tt.op.String())
+		assert.Equal(t, before, c.xk.Instance.State, tt.op.String())
+		assert.Equal(t, OpAbsorb, c.Last(), tt.op.String())
+	}
+}
+
+func TestCyclistValidatesSequence(t *testing.T) {
+	c, _ := NewCyclist(nil, nil, nil)
+	assert.Equal(t, Operation(0), c.Last())
+	// The mode is tracked by the Cyclist, not taken from the mode of the underlying instance
+	c.xk.Mode = Keyed
+	assert.Equal(t, Hash, c.Mode())
+	_, gotErr := c.Encrypt([]byte("pt"))
+	assert.True(t, errors.Is(gotErr, ErrNotKeyed))
+	assert.Equal(t, Operation(0), c.Last())
+
+	key := []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0A, 0x0B, 0x0C, 0x0D, 0x0E, 0x0F, 0x10}
+	c, _ = NewCyclist(key, nil, nil)
+	assert.Equal(t, OpAbsorbKey, c.Last())
+	assert.Equal(t, Keyed, c.Mode())
+
+	var cyclistLegalTestTable = []struct {
+		mode    CyclistMode
+		last    Operation
+		op      Operation
+		wantErr string
+		is      error
+	}{
+		{mode: Hash, last: 0, op: OpAbsorbKey},
+		{mode: Hash, last: 0, op: OpAbsorb},
+		{mode: Hash, last: 0, op: OpRatchet, wantErr: "ratchet only available in keyed mode", is: ErrNotKeyed},
+		{mode: Hash, last: OpAbsorb, op: OpAbsorbKey, wantErr: "absorb key after absorb: not allowed at this point of the Cyclist sequence", is: ErrIllegalSequence},
+		{mode: Hash, last: OpSqueeze, op: OpEncrypt, wantErr: "encrypt only available in keyed mode", is: ErrNotKeyed},
+		{mode: Keyed, last: OpAbsorbKey, op: OpAbsorbKey, wantErr: "absorb key after absorb key: not allowed at this point of the Cyclist sequence", is: ErrIllegalSequence},
+		{mode: Keyed, last: OpSqueeze, op: OpDecrypt},
+		{mode: Keyed, last: OpRatchet, op: OpSqueezeKey},
+		{mode: Keyed, last: OpAbsorbKey, op: Operation(42), wantErr: "Operation(42): not allowed at this point of the Cyclist sequence", is: ErrIllegalSequence},
+	}
+	for _, tt := range cyclistLegalTestTable {
+		gotErr := legal(tt.mode, tt.last, tt.op)
+		if tt.wantErr == "" {
+			assert.NoError(t, gotErr, tt.op.String())
+			continue
+		}
+		assert.EqualError(t, gotErr, tt.wantErr)
+		assert.True(t, errors.Is(gotErr, tt.is), tt.op.String())
+	}
+}
+
+func TestCyclistNotInstantiated(t *testing.T) {
+	var c Cyclist
+	assert.Equal(t, CyclistMode(0), c.Mode())
+	gotErr := c.Absorb([]byte("msg"))
+	assert.EqualError(t, gotErr, "absorb: cyclist not instantiated")
+	assert.True(t, errors.Is(gotErr, ErrNotInstantiated))
+	_, gotErr = c.Encrypt(nil)
+	assert.True(t, errors.Is(gotErr, ErrNotInstantiated))
+	_, gotErr = c.Decrypt(nil)
+	assert.True(t, errors.Is(gotErr, ErrNotInstantiated))
+	_, gotErr = c.Squeeze(16)
+	assert.True(t, errors.Is(gotErr, ErrNotInstantiated))
+	_, gotErr = c.SqueezeKey(16)
+	assert.True(t, errors.Is(gotErr, ErrNotInstantiated))
+	gotErr = c.Ratchet()
+	assert.True(t, errors.Is(gotErr, ErrNotInstantiated))
+	assert.Equal(t, Operation(0), c.Last())
+}
+
+func TestNewCyclistErrors(t *testing.T) {
+	c, gotErr := NewCyclist(nil, []byte{0x01}, nil)
+	assert.EqualError(t, gotErr, "nonce and counter only available in keyed mode")
+	assert.True(t, errors.Is(gotErr, ErrNotKeyed))
+	assert.Nil(t, c)
+
+	_, gotErr = NewCyclist(nil, nil, []byte{0x01})
+	assert.True(t, errors.Is(gotErr, ErrNotKeyed))
+
+	c, gotErr = NewCyclist(make([]byte, 24), make([]byte, 20), nil)
+	assert.EqualError(t, gotErr, "key and nonce lengths too large - key:24 nonce:20 combined:44 max:43")
+	assert.True(t, errors.Is(gotErr, ErrKeyNonceTooLong))
+	assert.Nil(t, c)
+}
+
+func TestCyclistSession(t *testing.T) {
+	key := []byte{0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88, 0x99, 0xAA, 0xBB, 0xCC, 0xDD, 0xEE, 0xFF}
+	nonce := []byte{0xF0, 0xE1, 0xD2, 0xC3}
+	counter := []byte{0x05, 0x06}
+	msg := []byte("a message spanning more than one block of the keyed rates")
+
+	want := Instantiate(key, nonce, counter)
+	c, err := NewCyclist(key, nonce, counter)
+	assert.NoError(t, err)
+
+	want.Absorb(msg)
+	assert.NoError(t, c.Absorb(msg))
+	ct, err := c.Encrypt(msg)
+	assert.NoError(t, err)
+	assert.Equal(t, want.Encrypt(msg), ct)
+	assert.NoError(t, c.Ratchet())
+	want.Ratchet()
+	got, err := c.SqueezeKey(32)
+	assert.NoError(t, err)
+	assert.Equal(t, want.SqueezeKey(32), got)
+	pt, err := c.Decrypt(ct)
+	assert.NoError(t, err)
+	assert.Equal(t, want.Decrypt(ct), pt)
+	got, err = c.Squeeze(50)
+	assert.NoError(t, err)
+	assert.Equal(t, want.Squeeze(50), got)
+	assert.Equal(t, want.Instance.State, c.xk.Instance.State)
+
+	assert.Equal(t, OpSqueeze, c.Last())
+	assert.Equal(t, Keyed, c.Mode())
+}
+
+func TestCyclistPhaseSequence(t *testing.T) {
+	// Per the specification, absorbing after a squeeze or a ratchet runs a single Up before each
+	// Down, as the squeeze left the Cyclist in the Up phase
+	var cyclistPhaseTestTable = []struct {
+		key  []byte
+		ops  func(c *Cyclist)
+		want []CyclistPhase
+	}{
+		{
+			key: nil,
+			ops: func(c *Cyclist) {
+				c.Squeeze(8)
+				c.Absorb([]byte("msg"))
+				c.Squeeze(8)
+			},
+			want: []CyclistPhase{Up, Down, Up},
+		},
+		{
+			key: []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0A, 0x0B, 0x0C, 0x0D, 0x0E, 0x0F, 0x10},
+			ops: func(c *Cyclist) {
+				c.Ratchet()
+			},
+			want: []CyclistPhase{Up, Down},
+		},
+		{
+			key: []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0A, 0x0B, 0x0C, 0x0D, 0x0E, 0x0F, 0x10},
+			ops: func(c *Cyclist) {
+				c.Absorb([]byte("ad"))
+				c.Squeeze(16)
+				c.Absorb([]byte("ad"))
+			},
+			want: []CyclistPhase{Up, Down, Up, Down},
+		},
+	}
+	for _, tt := range cyclistPhaseTestTable {
+		c, _ := NewCyclist(tt.key, nil, nil)
+		var phases []CyclistPhase
+		c.xk.SetTracer(func(phase CyclistPhase, control byte, block []byte, state xoodoo.State) {
+			phases = append(phases, phase)
+		})
+		tt.ops(c)
+		assert.Equal(t, tt.want, phases)
+	}
+}
+
+func TestOperationString(t *testing.T) {
+	assert.Equal(t, "absorb", OpAbsorb.String())
+	assert.Equal(t, "squeeze key", OpSqueezeKey.String())
+	assert.Equal(t, "absorb key", OpAbsorbKey.String())
+	assert.Equal(t, "Operation(42)", Operation(42).String())
+}
+
+func TestCyclistClone(t *testing.T) {
+	key := []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0A, 0x0B, 0x0C, 0x0D, 0x0E, 0x0F, 0x10}
+	base, _ := NewCyclist(key, []byte{0x01}, nil)
+	assert.NoError(t, base.Absorb([]byte("session header")))
+	fork := base.Clone()
+
+	ctBase, err := base.Encrypt([]byte("message one"))
+	assert.NoError(t, err)
+	ctFork, err := fork.Encrypt([]byte("message two"))
+	assert.NoError(t, err)
+
+	want, _ := NewCyclist(key, []byte{0x01}, nil)
+	want.Absorb([]byte("session header"))
+	wantCT, _ := want.Encrypt([]byte("message two"))
+	assert.Equal(t, wantCT, ctFork)
+	assert.NotEqual(t, ctBase[:len(ctFork)], ctFork)
+
+	assert.NoError(t, fork.Ratchet())
+	assert.Equal(t, OpEncrypt, base.Last())
+	assert.Equal(t, OpRatchet, fork.Last())
+	assert.Equal(t, Keyed, fork.Mode())
+
+	var empty Cyclist
+	_, err = empty.Clone().Squeeze(16)
+	assert.True(t, errors.Is(err, ErrNotInstantiated))
+}
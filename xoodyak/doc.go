@@ -4,7 +4,12 @@
 // https://eprint.iacr.org/2018/767.pdf
 // Xoodyak can operate in one of two modes: hashing or keyed mode  which is configured as part of the Xoodyak
 // object. Some functions are only available in one particular mode and will panic if invoked while
-// Xoodyak is configured incorrectly.
+// Xoodyak is configured incorrectly. Each panicking method has a Checked counterpart (EncryptChecked,
+// RatchetChecked, DownChecked, ...) that returns an error wrapping one of ErrNotKeyed,
+// ErrKeyNonceTooLong, ErrBlockTooLarge or ErrInvalidRate instead.
+// The Xoodyak type is the low-level interface whose raw Down and Up methods can be called in any
+// order; the Cyclist type is a sealed instance that only accepts the operations of the
+// specification in the sequences it allows.
 // Using the Cyclist functions, Xoodyak can be configured into a variety of more standard cryptographic
 // primitives such as:
 //    - Hashing
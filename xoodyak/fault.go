@@ -0,0 +1,192 @@
+package xoodyak
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"io"
+
+	"github.com/inmcm/xoodoo/xoodoo"
+)
+
+/* Fault-Resistant AEAD Support */
+
+// instantiator creates the Xoodyak instances of the fault-resistant functions. Outside of tests it
+// is always InstantiateChecked; the fault-injection tests pass one that corrupts the permutation.
+type instantiator func(key, id, counter []byte) (*Xoodyak, error)
+
+// shadowed runs a shadow Xoodyak instance in lock-step with the primary one, so that every
+// permutation is computed twice. The outputs and states of both instances are compared before any
+// output is released. A fault in one of the computations is detected unless the same fault hits
+// both.
+type shadowed struct {
+	xk, shadow *Xoodyak
+}
+
+func newShadowed(instantiate instantiator, key, id, ad []byte) (*shadowed, error) {
+	xk, err := instantiate(key, id, nil)
+	if err != nil {
+		return nil, err
+	}
+	shadow, err := instantiate(key, id, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := xk.AbsorbChecked(ad); err != nil {
+		return nil, err
+	}
+	if err := shadow.AbsorbChecked(ad); err != nil {
+		return nil, err
+	}
+	return &shadowed{xk: xk, shadow: shadow}, nil
+}
+
+// statesEqual compares two states in constant time
+func statesEqual(a, b xoodoo.State) bool {
+	var diff uint32
+	for i := range a {
+		diff |= a[i] ^ b[i]
+	}
+	return diff == 0
+}
+
+// check returns ErrFault unless both instances gave the same output and hold the same state
+func (s *shadowed) check(out, shadowOut []byte) error {
+	if subtle.ConstantTimeCompare(out, shadowOut) != 1 || !statesEqual(s.xk.Instance.State, s.shadow.Instance.State) {
+		return ErrFault
+	}
+	return nil
+}
+
+func (s *shadowed) crypt(msg []byte, cm CryptMode) ([]byte, error) {
+	out, err := s.xk.CryptChecked(msg, cm)
+	if err != nil {
+		return nil, err
+	}
+	shadowOut, err := s.shadow.CryptChecked(msg, cm)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.check(out, shadowOut); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *shadowed) cryptBlock(msg []byte, cu uint8, cm CryptMode) ([]byte, error) {
+	out, err := s.xk.CryptBlockChecked(msg, cu, cm)
+	if err != nil {
+		return nil, err
+	}
+	shadowOut, err := s.shadow.CryptBlockChecked(msg, cu, cm)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.check(out, shadowOut); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *shadowed) squeeze(outLen uint) ([]byte, error) {
+	out, err := s.xk.SqueezeChecked(outLen)
+	if err != nil {
+		return nil, err
+	}
+	shadowOut, err := s.shadow.SqueezeChecked(outLen)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.check(out, shadowOut); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CryptoEncryptAEADFaultResistant encrypts a plaintext message as done by CryptoEncryptAEAD,
+// computing it twice and comparing the results before releasing the ciphertext and tag. It returns
+// ErrFault and no output when the computations disagree.
+func CryptoEncryptAEADFaultResistant(in, key, id, ad []byte) (ct, tag []byte, err error) {
+	return encryptAEADFaultResistant(InstantiateChecked, in, key, id, ad)
+}
+
+func encryptAEADFaultResistant(instantiate instantiator, in, key, id, ad []byte) (ct, tag []byte, err error) {
+	if err := checkKeyNonce(key, id); err != nil {
+		return []byte{}, []byte{}, err
+	}
+	s, err := newShadowed(instantiate, key, id, ad)
+	if err != nil {
+		return []byte{}, []byte{}, err
+	}
+	if ct, err = s.crypt(in, Encrypting); err != nil {
+		return []byte{}, []byte{}, err
+	}
+	if tag, err = s.squeeze(TagLen); err != nil {
+		return []byte{}, []byte{}, err
+	}
+	return ct, tag, nil
+}
+
+// CryptoDecryptAEADFaultResistant decrypts and authenticates a ciphertext message as done by
+// CryptoDecryptAEAD, computing it twice and comparing the results before releasing the plaintext.
+// It returns ErrFault and no plaintext when the computations disagree.
+func CryptoDecryptAEADFaultResistant(in, key, id, ad, tag []byte) (pt []byte, valid bool, err error) {
+	return decryptAEADFaultResistant(InstantiateChecked, in, key, id, ad, tag)
+}
+
+func decryptAEADFaultResistant(instantiate instantiator, in, key, id, ad, tag []byte) (pt []byte, valid bool, err error) {
+	if err := checkKeyNonce(key, id); err != nil {
+		return []byte{}, false, err
+	}
+	s, err := newShadowed(instantiate, key, id, ad)
+	if err != nil {
+		return []byte{}, false, err
+	}
+	if pt, err = s.crypt(in, Decrypting); err != nil {
+		return []byte{}, false, err
+	}
+	calculatedTag, err := s.squeeze(TagLen)
+	if err != nil {
+		return []byte{}, false, err
+	}
+	if subtle.ConstantTimeCompare(calculatedTag, tag) != 1 {
+		return []byte{}, false, nil
+	}
+	return pt, true, nil
+}
+
+// NewXoodyakAEADFaultResistant returns a crypto/cipher AEAD object like NewXoodyakAEAD whose Seal
+// and Open compute twice as done by CryptoEncryptAEADFaultResistant and
+// CryptoDecryptAEADFaultResistant. On a detected fault, Seal panics with ErrFault, as the
+// cipher.AEAD interface leaves it no other way to withhold its output, and Open returns ErrFault.
+func NewXoodyakAEADFaultResistant(key []byte) (cipher.AEAD, error) {
+	a, err := NewXoodyakAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	a.(*xoodyakAEAD).faultResistant = true
+	a.(*xoodyakAEAD).instantiate = InstantiateChecked
+	return a, nil
+}
+
+// NewEncryptStreamFaultResistant returns an EncryptStream like NewEncryptStream that computes every
+// block twice and compares the results before writing ciphertext or tag. Once a fault is detected,
+// Write and Close return ErrFault without writing anything further.
+func NewEncryptStreamFaultResistant(target io.Writer, key, id, ad []byte) (*EncryptStream, error) {
+	return newEncryptStreamFaultResistant(InstantiateChecked, target, key, id, ad)
+}
+
+func newEncryptStreamFaultResistant(instantiate instantiator, target io.Writer, key, id, ad []byte) (*EncryptStream, error) {
+	es, err := newEncryptStream(instantiate, target, key, id, ad)
+	if err != nil {
+		return nil, err
+	}
+	shadow, err := instantiate(key, id, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := shadow.AbsorbChecked(ad); err != nil {
+		return nil, err
+	}
+	es.redundant = &shadowed{xk: es.xk, shadow: shadow}
+	return es, nil
+}
@@ -0,0 +1,246 @@
+package xoodyak
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/inmcm/xoodoo/xoodoo"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	faultTestKey   = []byte{0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88, 0x99, 0xAA, 0xBB, 0xCC, 0xDD, 0xEE, 0xFF}
+	faultTestNonce = []byte{0xF0, 0xE1, 0xD2, 0xC3, 0xB4, 0xA5, 0x96, 0x87, 0x78, 0x69, 0x5A, 0x4B, 0x3C, 0x2D, 0x1E, 0x0F}
+	faultTestAD    = []byte("fault injection associated data")
+	faultTestMsg   = []byte("a message spanning more than one block of the keyed squeeze rate")
+)
+
+// fault describes a single bit flip injected into the state of one Xoodyak instance created by
+// instantiate, after a given step of a given round of its n-th permutation call
+type fault struct {
+	instance int
+	perm     int
+	round    int
+	step     xoodoo.Step
+	bit      int
+}
+
+func (f fault) String() string {
+	return fmt.Sprintf("instance:%d perm:%d round:%d step:%s bit:%d", f.instance, f.perm, f.round, f.step, f.bit)
+}
+
+// inject returns an instantiator installing the fault, along with a function reporting whether
+// the fault was applied
+func inject(f fault) (instantiate instantiator, fired func() bool) {
+	applied := false
+	calls := 0
+	instantiate = func(key, id, counter []byte) (*Xoodyak, error) {
+		xk, err := InstantiateChecked(key, id, counter)
+		if err == nil && calls == f.instance {
+			perm := -1
+			xk.Instance.SetTracer(func(round int, step xoodoo.Step, state xoodoo.State) {
+				if step == xoodoo.StepInput {
+					perm++
+				}
+				if perm == f.perm && round == f.round && step == f.step {
+					xk.Instance.State[f.bit/32] ^= 1 << (f.bit % 32)
+					applied = true
+				}
+			})
+		}
+		calls++
+		return xk, err
+	}
+	return instantiate, func() bool { return applied }
+}
+
+// faultTable lists bit flips in every step of every round of the permutation calls of an AEAD
+// encryption or decryption of faultTestMsg, on both the primary and the shadow instance
+func faultTable() []fault {
+	var faults []fault
+	steps := []xoodoo.Step{xoodoo.StepInput, xoodoo.StepTheta, xoodoo.StepRhoWest, xoodoo.StepIota, xoodoo.StepChi, xoodoo.StepRhoEast}
+	for instance := 0; instance < 2; instance++ {
+		for perm := 0; perm < 6; perm++ {
+			for round := 0; round < xoodoo.MaxRounds; round++ {
+				for i, step := range steps {
+					faults = append(faults, fault{instance: instance, perm: perm, round: round, step: step, bit: (perm*97 + round*31 + i*7) % (8 * xoodoo.StateSizeBytes)})
+				}
+			}
+		}
+	}
+	return faults
+}
+
+func TestCryptoAEADFaultResistant(t *testing.T) {
+	for _, tt := range cryptoAEADTestTable {
+		ct, tag, err := CryptoEncryptAEADFaultResistant(tt.plaintext, tt.key, tt.nonce, tt.ad)
+		assert.Equal(t, tt.encryptErr, err)
+		assert.Equal(t, tt.ciphertext, ct)
+		assert.Equal(t, tt.tag, tag)
+
+		pt, valid, err := CryptoDecryptAEADFaultResistant(tt.ciphertext, tt.key, tt.nonce, tt.ad, tt.tag)
+		assert.Equal(t, tt.decryptErr, err)
+		assert.Equal(t, tt.valid, valid)
+		assert.Equal(t, tt.plaintext, pt)
+	}
+
+	ct, tag, _ := CryptoEncryptAEAD(faultTestMsg, faultTestKey, faultTestNonce, faultTestAD)
+	badTag := append([]byte{}, tag...)
+	badTag[0] ^= 0x01
+	pt, valid, err := CryptoDecryptAEADFaultResistant(ct, faultTestKey, faultTestNonce, faultTestAD, badTag)
+	assert.NoError(t, err)
+	assert.False(t, valid)
+	assert.Equal(t, []byte{}, pt)
+}
+
+func TestCryptoAEADFaultResistantErrors(t *testing.T) {
+	_, _, err := CryptoEncryptAEADFaultResistant(faultTestMsg, faultTestKey[:7], faultTestNonce, nil)
+	assert.EqualError(t, err, "xoodyak/aead: given key length (7 bytes) incorrect (16 bytes)")
+	_, _, err = CryptoEncryptAEADFaultResistant(faultTestMsg, faultTestKey, faultTestNonce[:7], nil)
+	assert.EqualError(t, err, "xoodyak/aead: given nonce length (7 bytes) incorrect (16 bytes)")
+	_, _, err = CryptoDecryptAEADFaultResistant(faultTestMsg, faultTestKey[:7], faultTestNonce, nil, nil)
+	assert.EqualError(t, err, "xoodyak/aead: given key length (7 bytes) incorrect (16 bytes)")
+	_, _, err = CryptoDecryptAEADFaultResistant(faultTestMsg, faultTestKey, faultTestNonce[:7], nil, nil)
+	assert.EqualError(t, err, "xoodyak/aead: given nonce length (7 bytes) incorrect (16 bytes)")
+	_, err = NewXoodyakAEADFaultResistant(faultTestKey[:7])
+	assert.EqualError(t, err, "xoodyak/aead: given key length (7 bytes) incorrect (16 bytes)")
+	_, err = NewEncryptStreamFaultResistant(bytes.NewBuffer(nil), faultTestKey, faultTestNonce[:7], nil)
+	assert.EqualError(t, err, "xoodyak/aead: given nonce length (7 bytes) incorrect (16 bytes)")
+}
+
+func TestFaultInjectionEncrypt(t *testing.T) {
+	wantCT, wantTag, _ := CryptoEncryptAEAD(faultTestMsg, faultTestKey, faultTestNonce, faultTestAD)
+	injected, escaped := 0, 0
+	for _, f := range faultTable() {
+		newXK, fired := inject(f)
+		ct, tag, err := encryptAEADFaultResistant(newXK, faultTestMsg, faultTestKey, faultTestNonce, faultTestAD)
+		if !fired() {
+			assert.NoError(t, err, f.String())
+			assert.Equal(t, wantCT, ct, f.String())
+			assert.Equal(t, wantTag, tag, f.String())
+			continue
+		}
+		injected++
+		assert.Equal(t, ErrFault, err, f.String())
+		assert.Equal(t, []byte{}, ct, f.String())
+		assert.Equal(t, []byte{}, tag, f.String())
+
+		// Without the redundant computation, the same fault on the single instance goes unnoticed
+		if f.instance == 0 {
+			newXK, fired = inject(f)
+			xk, err := newXK(faultTestKey, faultTestNonce, nil)
+			assert.NoError(t, err, f.String())
+			xk.Absorb(faultTestAD)
+			ct = xk.Encrypt(faultTestMsg)
+			tag = xk.Squeeze(TagLen)
+			assert.True(t, fired(), f.String())
+			if !bytes.Equal(wantCT, ct) || !bytes.Equal(wantTag, tag) {
+				escaped++
+			}
+		}
+	}
+	assert.Greater(t, injected, 0)
+	assert.Greater(t, escaped, 0)
+}
+
+func TestFaultInjectionDecrypt(t *testing.T) {
+	ct, tag, _ := CryptoEncryptAEAD(faultTestMsg, faultTestKey, faultTestNonce, faultTestAD)
+	injected := 0
+	for _, f := range faultTable() {
+		newXK, fired := inject(f)
+		pt, valid, err := decryptAEADFaultResistant(newXK, ct, faultTestKey, faultTestNonce, faultTestAD, tag)
+		if !fired() {
+			assert.NoError(t, err, f.String())
+			assert.True(t, valid, f.String())
+			assert.Equal(t, faultTestMsg, pt, f.String())
+			continue
+		}
+		injected++
+		assert.Equal(t, ErrFault, err, f.String())
+		assert.False(t, valid, f.String())
+		assert.Equal(t, []byte{}, pt, f.String())
+	}
+	assert.Greater(t, injected, 0)
+}
+
+func TestFaultInjectionAEADInterface(t *testing.T) {
+	plain, _ := NewXoodyakAEAD(faultTestKey)
+	want := plain.Seal(nil, faultTestNonce, faultTestMsg, faultTestAD)
+
+	aead, err := NewXoodyakAEADFaultResistant(faultTestKey)
+	assert.NoError(t, err)
+	assert.Equal(t, want, aead.Seal(nil, faultTestNonce, faultTestMsg, faultTestAD))
+	pt, err := aead.Open(nil, faultTestNonce, want, faultTestAD)
+	assert.NoError(t, err)
+	assert.Equal(t, faultTestMsg, pt)
+
+	for _, f := range []fault{
+		{instance: 0, perm: 1, round: 5, step: xoodoo.StepChi, bit: 17},
+		{instance: 1, perm: 3, round: 11, step: xoodoo.StepRhoEast, bit: 3},
+	} {
+		newXK, _ := inject(f)
+		faulty := &xoodyakAEAD{key: faultTestKey, faultResistant: true, instantiate: newXK}
+		assert.PanicsWithError(t, ErrFault.Error(), func() {
+			faulty.Seal(nil, faultTestNonce, faultTestMsg, faultTestAD)
+		}, f.String())
+
+		newXK, _ = inject(f)
+		faulty.instantiate = newXK
+		pt, err = faulty.Open(nil, faultTestNonce, want, faultTestAD)
+		assert.Equal(t, ErrFault, err, f.String())
+		assert.Equal(t, []byte{}, pt, f.String())
+	}
+}
+
+func TestFaultInjectionEncryptStream(t *testing.T) {
+	wantCT, wantTag, _ := CryptoEncryptAEAD(faultTestMsg, faultTestKey, faultTestNonce, faultTestAD)
+	want := append(append([]byte{}, wantCT...), wantTag...)
+
+	gotOut := bytes.NewBuffer(nil)
+	es, err := NewEncryptStreamFaultResistant(gotOut, faultTestKey, faultTestNonce, faultTestAD)
+	assert.NoError(t, err)
+	_, err = es.Write(faultTestMsg)
+	assert.NoError(t, err)
+	assert.NoError(t, es.Close())
+	assert.Equal(t, want, gotOut.Bytes())
+
+	injected := 0
+	for _, f := range faultTable() {
+		newXK, fired := inject(f)
+		gotOut := bytes.NewBuffer(nil)
+		es, err := newEncryptStreamFaultResistant(newXK, gotOut, faultTestKey, faultTestNonce, faultTestAD)
+		assert.NoError(t, err)
+		var writeErr error
+		for i := 0; i < len(faultTestMsg); i += 5 {
+			end := i + 5
+			if end > len(faultTestMsg) {
+				end = len(faultTestMsg)
+			}
+			_, err = es.Write(faultTestMsg[i:end])
+			if writeErr != nil {
+				// Once detected, a fault sticks to the stream
+				assert.Equal(t, ErrFault, err, f.String())
+			}
+			if err != nil {
+				writeErr = err
+			}
+		}
+		closeErr := es.Close()
+		if !fired() {
+			assert.NoError(t, writeErr, f.String())
+			assert.NoError(t, closeErr, f.String())
+			assert.Equal(t, want, gotOut.Bytes(), f.String())
+			continue
+		}
+		injected++
+		if writeErr != nil {
+			assert.Equal(t, ErrFault, writeErr, f.String())
+		}
+		assert.Equal(t, ErrFault, closeErr, f.String())
+		// Only the blocks computed before the fault may have been written, and they are correct
+		assert.Less(t, gotOut.Len(), len(want), f.String())
+		assert.True(t, bytes.Equal(want[:gotOut.Len()], gotOut.Bytes()), f.String())
+	}
+	assert.Greater(t, injected, 0)
+}
@@ -8,10 +8,13 @@ const (
 	cryptoHashBytes = 32
 )
 
+// The hashing functions below never give the Cyclist methods a block larger than the hash absorb
+// rate, so their errors are ignored
 func cryptoHash(in []byte, hLen uint) []byte {
-	newXd := Instantiate([]byte{}, []byte{}, []byte{})
-	newXd.Absorb(in)
-	return newXd.Squeeze(hLen)
+	newXd, _ := InstantiateChecked([]byte{}, []byte{}, []byte{})
+	newXd.AbsorbChecked(in)
+	out, _ := newXd.SqueezeChecked(hLen)
+	return out
 }
 
 // HashXoodyak calculates a 32-byte hash on a provided slice of bytes.
@@ -36,10 +39,11 @@ type digest struct {
 }
 
 // NewXoodyakHash returns a initialized Xoodyak digest object compatible
-// with the stdlib Hash interface
+// with the stdlib Hash interface. The digest also has a Clone() hash.Hash method to fork the
+// running hash.
 func NewXoodyakHash() hash.Hash {
 	d := &digest{absorbCd: AbsorbCdInit}
-	xk := Instantiate([]byte{}, []byte{}, []byte{})
+	xk, _ := InstantiateChecked([]byte{}, []byte{}, []byte{})
 	d.xk = xk
 	d.x = make([]byte, d.xk.AbsorbSize)
 	return d
@@ -54,7 +58,8 @@ func (d *digest) Write(p []byte) (n int, err error) {
 		nn := copy(d.x[d.nx:], p)
 		d.nx += nn
 		if d.nx == absorbSize {
-			d.xk.AbsorbBlock(d.x, d.absorbCd)
+			d.xk.AbsorbBlockChecked(d.x, d.absorbCd)
+			d.absorbCd = AbsorbCdMain
 			d.nx = 0
 		}
 		p = p[nn:]
@@ -62,7 +67,7 @@ func (d *digest) Write(p []byte) (n int, err error) {
 	if len(p) >= absorbSize {
 		nn := len(p) - (len(p) % absorbSize)
 		for i := 0; i < nn; i += absorbSize {
-			d.xk.AbsorbBlock(p[:absorbSize], d.absorbCd)
+			d.xk.AbsorbBlockChecked(p[:absorbSize], d.absorbCd)
 			p = p[absorbSize:]
 			d.absorbCd = AbsorbCdMain
 		}
@@ -74,26 +79,37 @@ func (d *digest) Write(p []byte) (n int, err error) {
 }
 
 // Sum appends the current hash to b and returns the resulting slice.
-// Sum will finalize the absorb sequence and switch to squeezing bytes from the
-// embedded Xoodoo state
+// Sum finalizes the absorb sequence and squeezes the hash from a copy of the embedded Xoodyak
+// instance, so it does not change the running hash and more data may still be written.
 func (d *digest) Sum(b []byte) []byte {
+	xk := d.xk.Clone()
+	absorbCd := d.absorbCd
 
 	if d.nx > 0 {
-		d.xk.AbsorbBlock(d.x[:d.nx], d.absorbCd)
-		d.absorbCd = AbsorbCdMain
+		xk.AbsorbBlockChecked(d.x[:d.nx], absorbCd)
+		absorbCd = AbsorbCdMain
 	}
 
-	if d.absorbCd == AbsorbCdInit {
-		d.xk.AbsorbBlock([]byte{}, d.absorbCd)
+	if absorbCd == AbsorbCdInit {
+		xk.AbsorbBlockChecked([]byte{}, absorbCd)
 	}
 
-	hash := d.xk.Squeeze(cryptoHashBytes)
+	hash, _ := xk.SqueezeChecked(cryptoHashBytes)
 	return append(b, hash[:]...)
 }
 
+// Clone returns an independent copy of the running hash, so that the hash of a common prefix
+// can be continued with different data
+func (d *digest) Clone() hash.Hash {
+	clone := *d
+	clone.xk = d.xk.Clone()
+	clone.x = append([]byte{}, d.x...)
+	return &clone
+}
+
 // Reset resets the Hash to its initial state.
 func (d *digest) Reset() {
-	xk := Instantiate([]byte{}, []byte{}, []byte{})
+	xk, _ := InstantiateChecked([]byte{}, []byte{}, []byte{})
 	d.xk = xk
 	d.nx = 0
 	d.absorbCd = AbsorbCdInit
@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"encoding/hex"
 	"fmt"
+	"hash"
 	"io"
 	"os"
 	"testing"
@@ -164,6 +165,32 @@ func TestXoodyakHashWrite(t *testing.T) {
 	assert.Equal(t, calculatedHash, gotHash)
 }
 
+// Writes of any size must give the hash of their concatenation, including a first write that only
+// partially fills the buffer, which used to absorb every later block with the initial Cd
+func TestXoodyakHashWriteChunks(t *testing.T) {
+	key := make([]byte, 16)
+	for _, msgLen := range []int{15, 16, 17, 40, 47, 48, 49, 100, 200} {
+		msg := make([]byte, msgLen)
+		for i := range msg {
+			msg[i] = byte(i)
+		}
+		for _, chunk := range []int{1, 3, 7, 15, 16, 17, 43, 44, 45} {
+			d := NewXoodyakHash()
+			mac := NewXoodyakMac(key)
+			for i := 0; i < len(msg); i += chunk {
+				end := i + chunk
+				if end > len(msg) {
+					end = len(msg)
+				}
+				d.Write(msg[i:end])
+				mac.Write(msg[i:end])
+			}
+			assert.Equal(t, HashXoodyak(msg), d.Sum(nil), "len:%d chunk:%d", msgLen, chunk)
+			assert.Equal(t, MACXoodyak(key, msg, cryptoHashBytes), mac.Sum(nil), "len:%d chunk:%d", msgLen, chunk)
+		}
+	}
+}
+
 func TestXoodyakReset(t *testing.T) {
 	emptyXooDyak := Instantiate([]byte{}, []byte{}, []byte{})
 	newXk := NewXoodyakHash()
@@ -171,6 +198,7 @@ func TestXoodyakReset(t *testing.T) {
 	newXk.Sum(nil)
 	dirtyDigest := newXk.(*digest)
 	dirtyDigest.xk.Up(0x00, 10)
+	dirtyDigest.xk.Down([]byte{}, 0x00)
 	assert.NotEqual(t, [16]byte{}, dirtyDigest.x)
 	assert.NotEqual(t, emptyXooDyak.Instance.Bytes(), dirtyDigest.xk.Instance.Bytes())
 	assert.NotEqual(t, emptyXooDyak.Phase, dirtyDigest.xk.Phase)
@@ -277,3 +305,39 @@ func TestHashXoodyakLen(t *testing.T) {
 		assert.Equal(t, tt.hash, gotHash)
 	}
 }
+
+func TestXoodyakHashIntermediateSum(t *testing.T) {
+	msg := make([]byte, 100)
+	for i := range msg {
+		msg[i] = byte(i)
+	}
+	for _, split := range []int{0, 1, 15, 16, 17, 48, 99, 100} {
+		d := NewXoodyakHash()
+		d.Write(msg[:split])
+		assert.Equal(t, HashXoodyak(msg[:split]), d.Sum(nil), split)
+		assert.Equal(t, HashXoodyak(msg[:split]), d.Sum(nil), split)
+		d.Write(msg[split:])
+		assert.Equal(t, HashXoodyak(msg), d.Sum(nil), split)
+	}
+}
+
+func TestXoodyakHashClone(t *testing.T) {
+	prefix := []byte("common prefix spanning a couple of hash blocks")
+	d := NewXoodyakHash()
+	d.Write(prefix)
+	fork := d.(interface{ Clone() hash.Hash }).Clone()
+
+	d.Write([]byte(" then branch A"))
+	fork.Write([]byte(" then branch B"))
+	assert.Equal(t, HashXoodyak([]byte("common prefix spanning a couple of hash blocks then branch A")), d.Sum(nil))
+	assert.Equal(t, HashXoodyak([]byte("common prefix spanning a couple of hash blocks then branch B")), fork.Sum(nil))
+
+	key := make([]byte, 16)
+	mac := NewXoodyakMac(key)
+	mac.Write(prefix)
+	macFork := mac.(interface{ Clone() hash.Hash }).Clone()
+	mac.Write([]byte("A"))
+	macFork.Write([]byte("B"))
+	assert.Equal(t, MACXoodyak(key, append(append([]byte{}, prefix...), 'A'), 32), mac.Sum(nil))
+	assert.Equal(t, MACXoodyak(key, append(append([]byte{}, prefix...), 'B'), 32), macFork.Sum(nil))
+}
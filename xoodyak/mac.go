@@ -7,19 +7,50 @@ import (
 // NewXoodyakMac generates a new hashing object with the provided key data already baked in. Writing
 // Any data then written to the hash object is part of the MAC check. Note that the length of the
 // resulting MAC matches that of the official Xoodyak hash output: 32 bytes
+// The MAC object has a Clone() hash.Hash method like the digest of NewXoodyakHash.
+// It panics if the key is too long; see NewXoodyakMacChecked.
 func NewXoodyakMac(key []byte) hash.Hash {
-	d := &digest{absorbCd: AbsorbCdInit}
-	xk := Instantiate(key, []byte{}, []byte{})
-	d.xk = xk
-	d.x = make([]byte, xk.AbsorbSize)
+	d, err := NewXoodyakMacChecked(key)
+	if err != nil {
+		panic(err)
+	}
 	return d
 }
 
 // MACXoodyak generates a message authentication code of the desired length in bytes for the provided
 // message based on the provided key data.
 // This implements the MAC behavior described in section 1.3.2 of the Xoodyak specification.
+// It panics if the key is too long; see MACXoodyakChecked.
 func MACXoodyak(key, msg []byte, macLen uint) []byte {
-	xkMAC := Instantiate(key, nil, nil)
-	xkMAC.Absorb(msg)
-	return xkMAC.Squeeze(macLen)
+	mac, err := MACXoodyakChecked(key, msg, macLen)
+	if err != nil {
+		panic(err)
+	}
+	return mac
+}
+
+// NewXoodyakMacChecked generates a new hashing object with the provided key data already baked in,
+// as done by NewXoodyakMac, returning an error instead of panicking when the key is too long
+func NewXoodyakMacChecked(key []byte) (hash.Hash, error) {
+	xk, err := InstantiateChecked(key, []byte{}, []byte{})
+	if err != nil {
+		return nil, err
+	}
+	d := &digest{absorbCd: AbsorbCdInit}
+	d.xk = xk
+	d.x = make([]byte, xk.AbsorbSize)
+	return d, nil
+}
+
+// MACXoodyakChecked generates a message authentication code of the desired length as done by
+// MACXoodyak, returning an error instead of panicking when the key is too long
+func MACXoodyakChecked(key, msg []byte, macLen uint) ([]byte, error) {
+	xkMAC, err := InstantiateChecked(key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := xkMAC.AbsorbChecked(msg); err != nil {
+		return nil, err
+	}
+	return xkMAC.SqueezeChecked(macLen)
 }
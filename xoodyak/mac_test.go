@@ -1,6 +1,7 @@
 package xoodyak
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -112,3 +113,26 @@ func TestXoodyakMAC(t *testing.T) {
 	assert.Equal(t, mac, gotMAC)
 
 }
+
+func TestXoodyakMACChecked(t *testing.T) {
+	for _, tt := range xoodyakMACTestTable {
+		xkMAC, err := NewXoodyakMacChecked(tt.key)
+		assert.NoError(t, err)
+		xkMAC.Write(tt.msg)
+		assert.Equal(t, tt.mac, xkMAC.Sum(nil))
+		gotMAC, err := MACXoodyakChecked(tt.key, tt.msg, uint(len(tt.mac)))
+		assert.NoError(t, err)
+		assert.Equal(t, tt.mac, gotMAC)
+	}
+
+	longKey := make([]byte, 44)
+	xkMAC, err := NewXoodyakMacChecked(longKey)
+	assert.EqualError(t, err, "key and nonce lengths too large - key:44 nonce:0 combined:44 max:43")
+	assert.True(t, errors.Is(err, ErrKeyNonceTooLong))
+	assert.Nil(t, xkMAC)
+	gotMAC, err := MACXoodyakChecked(longKey, []byte("msg"), 32)
+	assert.True(t, errors.Is(err, ErrKeyNonceTooLong))
+	assert.Nil(t, gotMAC)
+	assert.Panics(t, func() { NewXoodyakMac(longKey) })
+	assert.Panics(t, func() { MACXoodyak(longKey, []byte("msg"), 32) })
+}
@@ -1,7 +1,6 @@
 package xoodyak
 
 import (
-	"errors"
 	"fmt"
 
 	"github.com/inmcm/xoodoo/xoodoo"
@@ -51,29 +50,52 @@ const (
 // Xoodyak is a cryptographic object that allows execution of the Cyclist operating mode on the
 // Xoodoo permutation primitive. Xoodyak allows for construction of a variety of hashing, encryption
 // and authentication schemes through assembly of its various operating methods
+//
+// Xoodyak is the low-level Cyclist interface: its mode, phase and rates are mutable fields and its
+// Down, Up, AbsorbBlock and CryptBlock methods can be called in any sequence, which is needed to
+// build streaming or instrumented constructions but also allows sequences the specification does
+// not. Use Cyclist for a sealed instance that only accepts the operations of the specification.
 type Xoodyak struct {
 	Instance    *xoodoo.Xoodoo
 	Mode        CyclistMode
 	Phase       CyclistPhase
 	AbsorbSize  uint
 	SqueezeSize uint
+	tracer      CyclistTracer
+}
+
+// CyclistTracer observes the Down and Up calls of a Xoodyak instance. It receives the phase that was
+// just executed, the Cd or Cu byte actually added to the state (which is masked or omitted in hash
+// mode), the block absorbed by Down or returned by Up, and a copy of the resulting state.
+type CyclistTracer func(phase CyclistPhase, control byte, block []byte, state xoodoo.State)
+
+// SetTracer installs a CyclistTracer that is called after every subsequent Down and Up call. A nil
+// CyclistTracer stops tracing.
+func (xk *Xoodyak) SetTracer(fn CyclistTracer) {
+	xk.tracer = fn
+}
+
+// Clone returns an independent deep copy of the Xoodyak instance, including its Xoodoo state, so
+// that a transcript can be forked: absorb a common prefix once, then continue each copy separately.
+// The installed CyclistTracer and Xoodoo Tracer, if any, are shared with the copy.
+func (xk *Xoodyak) Clone() *Xoodyak {
+	clone := *xk
+	if xk.Instance != nil {
+		clone.Instance = xk.Instance.Clone()
+	}
+	return &clone
 }
 
 // Standard Xoodyak Interfaces
 
 // Instantiate generate a new Xoodoo object initialized for hashing or
-// keyed operations
+// keyed operations. It panics if the key and nonce are too long; see InstantiateChecked.
 func Instantiate(key, id, counter []byte) *Xoodyak {
-	newXK := Xoodyak{}
-	newXK.Instance, _ = xoodoo.NewXoodoo(xoodoo.MaxRounds, [48]byte{})
-	newXK.Mode = Hash
-	newXK.Phase = Up
-	newXK.AbsorbSize = xoodyakHashIn
-	newXK.SqueezeSize = xoodyakHashIn
-	if len(key) != 0 {
-		newXK.AbsorbKey(key, id, counter)
+	newXK, err := InstantiateChecked(key, id, counter)
+	if err != nil {
+		panic(err)
 	}
-	return &newXK
+	return newXK
 }
 
 // Absorb ingests a provided message at the rate of the Xoodyak instance's absorption size
@@ -84,19 +106,21 @@ func (xk *Xoodyak) Absorb(x []byte) {
 // Encrypt transforms the provided plaintext message into a ciphertext message of equal size
 // based on the Xoodyak instance provided (key, nonce, counter have already been processed)
 func (xk *Xoodyak) Encrypt(pt []byte) []byte {
-	if xk.Mode != Keyed {
-		panic(errors.New("encrypt only available in keyed mode"))
+	ct, err := xk.EncryptChecked(pt)
+	if err != nil {
+		panic(err)
 	}
-	return xk.Crypt(pt, Encrypting)
+	return ct
 }
 
 // Decrypt transforms the provided ciphertext message into a plainext message of equal size
 // based on the Xoodyak instance provided (key, nonce, counter have already been processed)
 func (xk *Xoodyak) Decrypt(ct []byte) []byte {
-	if xk.Mode != Keyed {
-		panic(errors.New("decrypt only available in keyed mode"))
+	pt, err := xk.DecryptChecked(ct)
+	if err != nil {
+		panic(err)
 	}
-	return xk.Crypt(ct, Decrypting)
+	return pt
 }
 
 // Squeeze outputs a provided stream of pseudo-random bytes at the rate of the Xoodyak instance's squeeze
@@ -107,92 +131,51 @@ func (xk *Xoodyak) Squeeze(outLen uint) []byte {
 
 // SqueezeKey can generate a new encryption key from the existing Xoodyak state
 func (xk *Xoodyak) SqueezeKey(keyLen uint) []byte {
-	if xk.Mode != Keyed {
-		panic(errors.New("squeeze key only available in keyed mode"))
+	key, err := xk.SqueezeKeyChecked(keyLen)
+	if err != nil {
+		panic(err)
 	}
-	return xk.SqueezeAny(keyLen, 0x20)
+	return key
 }
 
 // Ratchet performs a irreversible transformation of the underlying Xoodoo state to prevent key
 // recovery
 func (xk *Xoodyak) Ratchet() {
-	if xk.Mode != Keyed {
-		panic(errors.New("ratchet only available in keyed mode"))
+	if err := xk.RatchetChecked(); err != nil {
+		panic(err)
 	}
-	ratchetSqueeze := xk.SqueezeAny(xoodyakRatchet, RatchetCu)
-	xk.AbsorbAny(ratchetSqueeze, xk.AbsorbSize, AbsorbCdMain)
 }
 
 // AbsorbBlock ingests a single block of bytes encompassing a single iteration
 // of the Cyclist sequence
 func (xk *Xoodyak) AbsorbBlock(x []byte, cd uint8) {
-	if xk.Phase != Up {
-		xk.Up(0, 0)
+	if err := xk.AbsorbBlockChecked(x, cd); err != nil {
+		panic(err)
 	}
-	xk.Down(x, cd)
 }
 
 // AbsorbAny allow input of any size number of bytes into the
 // Xoodoo state
 func (xk *Xoodyak) AbsorbAny(x []byte, r uint, cd uint8) {
-	var cdTmp uint8 = cd
-	var processed uint = 0
-	var remaining uint = uint(len(x))
-	absorbLen := r
-	for {
-		if xk.Phase != Up {
-			xk.Up(0, 0)
-		}
-		if remaining < absorbLen {
-			absorbLen = remaining
-		}
-		xk.Down(x[processed:processed+absorbLen], cdTmp)
-		cdTmp = AbsorbCdMain
-		remaining -= absorbLen
-		processed += absorbLen
-		if remaining <= 0 {
-			break
-		}
+	if err := xk.AbsorbAnyChecked(x, r, cd); err != nil {
+		panic(err)
 	}
-	return
 }
 
 // AbsorbKey is special Xoodyak method that ingests provided key, id (nonce), and counter messages
 // into the Xoodoo state enabling the keyed mode of operation typically used for authenticated encryption
 func (xk *Xoodyak) AbsorbKey(key, id, counter []byte) {
-	if len(key)+len(id) >= xoodyakRkIn {
-		panic(fmt.Errorf("key and nonce lengths too large - key:%d nonce:%d combined:%d max:%d", len(key), len(id), len(key)+len(id), xoodyakRkIn-1))
-	}
-	xk.Mode = Keyed
-	xk.AbsorbSize = xoodyakRkIn
-	xk.SqueezeSize = xoodyakRkOut
-	if len(key) > 0 {
-		keyIDBuf := append(key, id...)
-		keyIDBuf = append(keyIDBuf, byte(len(id)))
-		xk.AbsorbAny(keyIDBuf, xk.AbsorbSize, 0x02)
-		if len(counter) > 0 {
-			xk.AbsorbAny(counter, 1, 0x00)
-		}
+	if err := xk.AbsorbKeyChecked(key, id, counter); err != nil {
+		panic(err)
 	}
 }
 
 // SqueezeAny allow generation of a message of pseudo-random bytes of any size based on permutating
 // the underlying Xoodoo state
 func (xk *Xoodyak) SqueezeAny(YLen uint, Cu uint8) []byte {
-	squeezeLen := xk.SqueezeSize
-	if YLen < squeezeLen {
-		squeezeLen = YLen
-	}
-	output := xk.Up(Cu, squeezeLen)
-	var remaining uint = YLen - squeezeLen
-
-	for remaining > 0 {
-		xk.Down([]byte{}, 0)
-		if remaining < squeezeLen {
-			squeezeLen = remaining
-		}
-		output = append(output, xk.Up(0, squeezeLen)...)
-		remaining -= squeezeLen
+	output, err := xk.SqueezeAnyChecked(YLen, Cu)
+	if err != nil {
+		panic(err)
 	}
 	return output
 }
@@ -200,65 +183,28 @@ func (xk *Xoodyak) SqueezeAny(YLen uint, Cu uint8) []byte {
 // Down injects the provided slice of bytes into the provided Xoodoo
 // state via xor with the existing state
 func (xk *Xoodyak) Down(Xi []byte, Cd byte) {
-	if len(Xi) > xoodoo.StateSizeBytes {
-		panic(fmt.Errorf("input slice size [%d] exceeds Xoodoo state size [%d]", len(Xi), xoodoo.StateSizeBytes))
+	if err := xk.DownChecked(Xi, Cd); err != nil {
+		panic(err)
 	}
-	cd1 := Cd
-	if xk.Mode == Hash {
-		cd1 &= 0x01
-	}
-	fill := make([]byte, xoodoo.StateSizeBytes)
-	copy(fill, Xi)
-	fill[len(Xi)] = 0x01
-	fill[len(fill)-1] = cd1
-	xk.Instance.State.XorStateBytes(fill)
-	xk.Phase = Down
 }
 
 // Up applies the Xoodoo permutation to the Xoodoo state and returns
 // the requested number of bytes
 func (xk *Xoodyak) Up(Cu byte, Yilen uint) []byte {
-	if Yilen > xoodoo.StateSizeBytes {
-		panic(fmt.Errorf("requested number of bytes [%d] larger than Xoodoo state size [%d]", Yilen, xoodoo.StateSizeBytes))
-	}
-	if xk.Mode != Hash {
-		xk.Instance.State.XorByte(Cu, xoodoo.StateSizeBytes-1)
+	out, err := xk.UpChecked(Cu, Yilen)
+	if err != nil {
+		panic(err)
 	}
-	xk.Instance.Permutation()
-	if Yilen == 0 {
-		return []byte{}
-	}
-	return xk.Instance.Bytes()[:Yilen]
-
+	return out
 }
 
 // Crypt is core encryption function of Xoodyak/Cyclist. It accepts a byte message of arbitrary
 // length and generates either a ciphertext or plaintext based on the mode provided. Encryption or
 // decryption is accomplished via XOR against a keystream generated from the Xoodoo primitive
 func (xk *Xoodyak) Crypt(msg []byte, cm CryptMode) []byte {
-	cuTmp := CryptCuInit
-	processed := 0
-	remaining := len(msg)
-	cryptLen := xoodyakRkOut
-	out := make([]byte, remaining)
-	for {
-		if remaining < cryptLen {
-			cryptLen = remaining
-		}
-		xk.Up(cuTmp, 0)
-		xorBytes, _ := xk.Instance.XorExtractBytes(msg[processed : processed+cryptLen])
-		if cm == Encrypting {
-			xk.Down(msg[processed:processed+cryptLen], CryptCd)
-		} else {
-			xk.Down(xorBytes, CryptCd)
-		}
-		copy(out[processed:], xorBytes)
-		cuTmp = CryptCuMain
-		remaining -= cryptLen
-		processed += cryptLen
-		if remaining <= 0 {
-			break
-		}
+	out, err := xk.CryptChecked(msg, cm)
+	if err != nil {
+		panic(err)
 	}
 	return out
 }
@@ -269,12 +215,9 @@ func (xk *Xoodyak) CryptBlock(msg []byte, cu uint8, cm CryptMode) ([]byte, error
 	if len(msg) > xoodyakRkOut {
 		return nil, fmt.Errorf("input size [%d] exceeds Xoodoo max encryption block size [%d]", len(msg), xoodyakRkOut)
 	}
-	xk.Up(cu, 0)
-	xorBytes, _ := xk.Instance.XorExtractBytes(msg)
-	if cm == Encrypting {
-		xk.Down(msg, CryptCd)
-	} else {
-		xk.Down(xorBytes, CryptCd)
+	out, err := xk.CryptBlockChecked(msg, cu, cm)
+	if err != nil {
+		panic(err)
 	}
-	return xorBytes, nil
+	return out, nil
 }
@@ -1108,6 +1108,158 @@ func TestNonStandardXoodyakEncryption(t *testing.T) {
 	}
 
 }
+func TestCyclistTracer(t *testing.T) {
+	type call struct {
+		phase   CyclistPhase
+		control byte
+		block   []byte
+		state   xoodoo.State
+	}
+	var calls []call
+	record := func(phase CyclistPhase, control byte, block []byte, state xoodoo.State) {
+		calls = append(calls, call{phase, control, block, state})
+	}
+
+	// Hash mode masks Cd and omits Cu
+	xk := Instantiate(nil, nil, nil)
+	xk.SetTracer(record)
+	xk.Absorb([]byte{0xAA})
+	digest := xk.Squeeze(4)
+	assert.Len(t, calls, 2)
+	assert.Equal(t, call{Down, 0x01, []byte{0xAA}, xoodoo.State{0x01AA, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x01000000}}, calls[0])
+	assert.Equal(t, Up, calls[1].phase)
+	assert.Equal(t, byte(0), calls[1].control)
+	assert.Equal(t, digest, calls[1].block)
+	assert.Equal(t, xk.Instance.State, calls[1].state)
+
+	// Keyed mode reports the full Cu byte, and encryption squeezes nothing through Up
+	calls = nil
+	xk = Instantiate(nil, nil, nil)
+	xk.SetTracer(record)
+	xk.AbsorbKey(make([]byte, 16), make([]byte, 16), nil)
+	xk.Encrypt([]byte{1, 2, 3})
+	assert.Len(t, calls, 3)
+	assert.Equal(t, []CyclistPhase{Down, Up, Down}, []CyclistPhase{calls[0].phase, calls[1].phase, calls[2].phase})
+	assert.Equal(t, []byte{0x02, CryptCuInit, CryptCd}, []byte{calls[0].control, calls[1].control, calls[2].control})
+	assert.Equal(t, []byte{}, calls[1].block)
+	assert.Equal(t, []byte{1, 2, 3}, calls[2].block)
+
+	xk.SetTracer(nil)
+	xk.Squeeze(16)
+	assert.Len(t, calls, 3)
+}
+
+// specCyclist is a transcription of the Cyclist pseudocode of the Xoodyak specification for
+// single-block inputs and outputs, built directly on the Xoodoo permutation
+type specCyclist struct {
+	xd    *xoodoo.Xoodoo
+	keyed bool
+	up    bool
+}
+
+func (c *specCyclist) down(x []byte, cd byte) {
+	var block [xoodoo.StateSizeBytes]byte
+	copy(block[:], x)
+	block[len(x)] = 0x01
+	if !c.keyed {
+		cd &= 0x01
+	}
+	block[xoodoo.StateSizeBytes-1] ^= cd
+	c.xd.State.XorStateBytes(block[:])
+	c.up = false
+}
+
+func (c *specCyclist) upOut(cu byte, n int) []byte {
+	if c.keyed {
+		c.xd.State.XorByte(cu, xoodoo.StateSizeBytes-1)
+	}
+	c.xd.Permutation()
+	c.up = true
+	return c.xd.Bytes()[:n]
+}
+
+func (c *specCyclist) absorb(x []byte, cd byte) {
+	if !c.up {
+		c.upOut(0x00, 0)
+	}
+	c.down(x, cd)
+}
+
+var xoodyakAbsorbAfterSqueezeTestTable = []struct {
+	name string
+	key  []byte
+	spec func(c *specCyclist) []byte
+	xk   func(xk *Xoodyak) []byte
+	want []byte
+}{
+	{
+		name: "keyed absorb after squeeze",
+		key:  make([]byte, 16),
+		spec: func(c *specCyclist) []byte {
+			c.absorb([]byte("a"), AbsorbCdInit)
+			c.upOut(SqueezeCuInit, 16)
+			c.absorb([]byte("b"), AbsorbCdInit)
+			return c.upOut(SqueezeCuInit, 16)
+		},
+		xk: func(xk *Xoodyak) []byte {
+			xk.Absorb([]byte("a"))
+			xk.Squeeze(16)
+			xk.Absorb([]byte("b"))
+			return xk.Squeeze(16)
+		},
+		want: []byte{0xAA, 0x37, 0xE9, 0x8E, 0xF6, 0x2F, 0x23, 0xBC, 0xF7, 0x57, 0xA7, 0xAF, 0x9C, 0x7C, 0x6B, 0x7E},
+	},
+	{
+		name: "ratchet",
+		key:  make([]byte, 16),
+		spec: func(c *specCyclist) []byte {
+			c.absorb([]byte("a"), AbsorbCdInit)
+			r := c.upOut(RatchetCu, xoodyakRatchet)
+			c.absorb(r, AbsorbCdMain)
+			return c.upOut(SqueezeCuInit, 16)
+		},
+		xk: func(xk *Xoodyak) []byte {
+			xk.Absorb([]byte("a"))
+			xk.Ratchet()
+			return xk.Squeeze(16)
+		},
+		want: []byte{0x0E, 0x23, 0x9D, 0x1B, 0xA0, 0x38, 0xF9, 0x6F, 0xB1, 0xEB, 0x6C, 0x69, 0xC1, 0x45, 0x48, 0xD6},
+	},
+	{
+		name: "hash absorb after squeeze",
+		key:  nil,
+		spec: func(c *specCyclist) []byte {
+			c.absorb([]byte("a"), AbsorbCdInit)
+			c.upOut(SqueezeCuInit, 16)
+			c.absorb([]byte("b"), AbsorbCdInit)
+			return c.upOut(SqueezeCuInit, 16)
+		},
+		xk: func(xk *Xoodyak) []byte {
+			xk.Absorb([]byte("a"))
+			xk.Squeeze(16)
+			xk.Absorb([]byte("b"))
+			return xk.Squeeze(16)
+		},
+		want: []byte{0xF6, 0xFC, 0x91, 0xBE, 0x78, 0xAD, 0xAC, 0x3C, 0x3B, 0xA7, 0x88, 0x0F, 0xAD, 0x6F, 0x51, 0xF6},
+	},
+}
+
+// Up leaves the Cyclist in the Up phase, so an absorb following a squeeze or the squeeze of a
+// ratchet goes straight to Down without permuting again
+func TestXoodyakAbsorbAfterSqueeze(t *testing.T) {
+	for _, tt := range xoodyakAbsorbAfterSqueezeTestTable {
+		xd, _ := xoodoo.NewXoodoo(xoodoo.MaxRounds, [xoodoo.StateSizeBytes]byte{})
+		spec := &specCyclist{xd: xd, keyed: len(tt.key) != 0, up: true}
+		if spec.keyed {
+			spec.absorb(append(append([]byte{}, tt.key...), 0x00), 0x02)
+		}
+		assert.Equal(t, tt.want, tt.spec(spec), tt.name)
+		xk := Instantiate(tt.key, nil, nil)
+		assert.Equal(t, tt.want, tt.xk(xk), tt.name)
+		assert.Equal(t, spec.xd.State, xk.Instance.State, tt.name)
+	}
+}
+
 func BenchmarkEncrypt(b *testing.B) {
 	key := make([]byte, 16)
 	nonce := make([]byte, 16)
@@ -1131,3 +1283,52 @@ func BenchmarkDecrypt(b *testing.B) {
 		newXd.Decrypt(pt)
 	}
 }
+
+func TestXoodyakClone(t *testing.T) {
+	key := []byte{0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88, 0x99, 0xAA, 0xBB, 0xCC, 0xDD, 0xEE, 0xFF}
+	nonce := []byte{0xF0, 0xE1, 0xD2, 0xC3, 0xB4, 0xA5, 0x96, 0x87, 0x78, 0x69, 0x5A, 0x4B, 0x3C, 0x2D, 0x1E, 0x0F}
+	prefix := []byte("common prefix absorbed once")
+
+	var cloneTestTable = []struct {
+		key   []byte
+		nonce []byte
+	}{
+		{key: nil, nonce: nil},
+		{key: key, nonce: nonce},
+	}
+	for _, tt := range cloneTestTable {
+		base := Instantiate(tt.key, tt.nonce, nil)
+		base.Absorb(prefix)
+		fork := base.Clone()
+		assert.Equal(t, base.Instance.State, fork.Instance.State)
+		assert.False(t, base.Instance == fork.Instance)
+
+		// Each branch matches a fresh instance that absorbed the prefix and its own message
+		for _, pair := range []struct {
+			xk  *Xoodyak
+			msg []byte
+		}{
+			{xk: base, msg: []byte("recipient A")},
+			{xk: fork, msg: []byte("recipient B")},
+		} {
+			want := Instantiate(tt.key, tt.nonce, nil)
+			want.Absorb(prefix)
+			want.Absorb(pair.msg)
+			pair.xk.Absorb(pair.msg)
+			assert.Equal(t, want.Squeeze(32), pair.xk.Squeeze(32))
+		}
+		assert.NotEqual(t, base.Instance.State, fork.Instance.State)
+	}
+
+	var phases []CyclistPhase
+	traced := Instantiate(key, nonce, nil)
+	traced.SetTracer(func(phase CyclistPhase, control byte, block []byte, state xoodoo.State) {
+		phases = append(phases, phase)
+	})
+	traced.Clone().Squeeze(16)
+	assert.Equal(t, []CyclistPhase{Up}, phases)
+
+	empty := (&Xoodyak{Mode: Hash}).Clone()
+	assert.Nil(t, empty.Instance)
+	assert.Equal(t, Hash, empty.Mode)
+}
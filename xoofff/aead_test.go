@@ -0,0 +1,68 @@
+package xoofff
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSANEAEAD(t *testing.T) {
+	key := []byte("0123456789abcdef")
+	nonce := make([]byte, SANENonceLen)
+	aead, err := NewSANEAEAD(key)
+	assert.NoError(t, err)
+	assert.Equal(t, SANENonceLen, aead.NonceSize())
+	assert.Equal(t, SANETagLen, aead.Overhead())
+
+	plaintext, ad := []byte("single message"), []byte("header")
+	sealed := aead.Seal([]byte("prefix"), nonce, plaintext, ad)
+	session, _, _ := NewSANE(key, nonce)
+	ct, tag := session.Wrap(ad, plaintext)
+	assert.Equal(t, append(append([]byte("prefix"), ct...), tag...), sealed)
+
+	opened, err := aead.Open(nil, nonce, sealed[6:], ad)
+	assert.NoError(t, err)
+	assert.Equal(t, plaintext, opened)
+
+	_, err = aead.Open(nil, nonce, sealed[6:], []byte("other"))
+	assert.Equal(t, ErrAuthFailed, err)
+	_, err = aead.Open(nil, nonce, sealed[:SANETagLen-1], ad)
+	assert.Equal(t, errors.New("xoofff: given ciphertext (15 bytes) less than minimum length (16 bytes)"), err)
+	_, err = aead.Open(nil, nonce[:4], sealed[6:], ad)
+	assert.Equal(t, errors.New("xoofff: given nonce length (4 bytes) incorrect (16 bytes)"), err)
+	assert.Panics(t, func() { aead.Seal(nil, nonce[:4], plaintext, ad) })
+
+	_, err = NewSANEAEAD(make([]byte, 48))
+	assert.Equal(t, errors.New("xoofff: given key length (48 bytes) exceeds maximum (47 bytes)"), err)
+}
+
+func TestSANSEAEAD(t *testing.T) {
+	key := []byte("0123456789abcdef")
+	aead, err := NewSANSEAEAD(key)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, aead.NonceSize())
+	assert.Equal(t, SANSETagLen, aead.Overhead())
+
+	plaintext, ad := []byte("single message"), []byte("header")
+	sealed := aead.Seal(nil, nil, plaintext, ad)
+	session, _ := NewSANSE(key)
+	ct, tag := session.Wrap(ad, plaintext)
+	assert.Equal(t, append(ct, tag...), sealed)
+
+	opened, err := aead.Open([]byte("prefix"), nil, sealed, ad)
+	assert.NoError(t, err)
+	assert.Equal(t, append([]byte("prefix"), plaintext...), opened)
+
+	sealed[len(sealed)-1] ^= 0x01
+	_, err = aead.Open(nil, nil, sealed, ad)
+	assert.Equal(t, ErrAuthFailed, err)
+	_, err = aead.Open(nil, nil, sealed[:SANSETagLen-1], ad)
+	assert.Equal(t, errors.New("xoofff: given ciphertext (31 bytes) less than minimum length (32 bytes)"), err)
+	_, err = aead.Open(nil, []byte{0x00}, sealed, ad)
+	assert.Equal(t, errors.New("xoofff: given nonce length (1 bytes) incorrect (0 bytes)"), err)
+	assert.Panics(t, func() { aead.Seal(nil, []byte{0x00}, plaintext, ad) })
+
+	_, err = NewSANSEAEAD(make([]byte, 48))
+	assert.Equal(t, errors.New("xoofff: given key length (48 bytes) exceeds maximum (47 bytes)"), err)
+}
@@ -0,0 +1,30 @@
+// Package xoofff implements the Xoofff deck function: the Farfalle construction instantiated with
+// the 6-round Xoodoo permutation and the Xoodoo rolling functions, as described in the Xoodoo
+// cookbook: https://eprint.iacr.org/2018/767.pdf
+//
+// A deck function takes a secret key and a sequence of input strings and produces an output of
+// arbitrary length. Xoofff is incremental: strings can be appended to the sequence after output has
+// been requested, and output can be taken from any offset without computing what precedes it.
+// Farfalle computes
+//
+//	k  ← p_b(K||1||0*)
+//	x  ← Σ p_c(M_i + roll_c^I(k))  over the padded blocks of every string, with one extra roll between strings
+//	k' ← roll_c^I(k)
+//	z_j ← p_e(roll_e^j(p_d(x))) + k'
+//
+// where every permutation is Xoodoo[6]. Independent blocks are processed with the multi-state Xoodoo
+// permutation where possible.
+//
+// The package also provides the session authenticated encryption modes built on the deck function:
+// Xoofff-SANE, which is nonce-based, and Xoofff-SANSE, which is SIV-based and resists nonce misuse.
+// Both wrap a sequence of messages with associated data, with each tag authenticating the entire
+// session so far, and both are available as a cipher.AEAD for single messages. Xoofff-WBC is a
+// length-preserving tweakable cipher for blocks of any size, and Xoofff-WBC-AE adds authentication
+// to it at the cost of a fixed expansion.
+//
+// Only byte-oriented input and output is supported. The tests check the buffered and parallel code
+// paths against a direct transcription of the Farfalle algorithm; the output has not yet been
+// cross-checked against the XKCP Xoofff test vectors. The same holds for the modes, and in
+// particular the Xoofff-WBC split of a block into its halves and the order in which G compresses
+// the tweak and the half have not been checked against the reference implementation.
+package xoofff
@@ -0,0 +1,19 @@
+package xoofff_test
+
+import (
+	"fmt"
+
+	"github.com/inmcm/xoodoo/xoofff"
+)
+
+func ExampleXoofff() {
+	key := []byte("an example xoofff key")
+	xf, _ := xoofff.NewXoofff(key)
+	xf.Compress([]byte("first string, "), false)
+	xf.Compress([]byte("supplied in two parts"), true)
+	xf.Compress([]byte("second string"), true)
+	out := make([]byte, 16)
+	xf.Expand(out, 0)
+	fmt.Printf("%x\n", out)
+	// Output: 89e8efcc12b0a1d47086e6586ba368ef
+}
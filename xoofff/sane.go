@@ -0,0 +1,87 @@
+package xoofff
+
+import (
+	"crypto/subtle"
+	"errors"
+)
+
+const (
+	// SANETagLen is the number of bytes in each Xoofff-SANE authentication tag
+	SANETagLen = 16
+	// SANSETagLen is the number of bytes in each Xoofff-SANSE authentication tag
+	SANSETagLen = 32
+)
+
+// ErrAuthFailed is returned when a tag fails to authenticate unwrapped data
+var ErrAuthFailed = errors.New("xoofff: message authentication failed")
+
+// SANE is a Xoofff-SANE session: nonce-based authenticated encryption of a sequence of messages.
+// Each tag authenticates the message it accompanies together with every message that preceded it
+// in the session, so sender and receiver must wrap and unwrap the same messages in the same order.
+// The nonce must never be reused with the same key.
+type SANE struct {
+	xf *Xoofff
+	e  byte
+}
+
+// NewSANE starts a Xoofff-SANE session for the given key and nonce. Along with the session, a tag
+// authenticating the nonce is returned; a receiver starting the session with the same key and
+// nonce obtains the same tag.
+func NewSANE(key, nonce []byte) (*SANE, []byte, error) {
+	xf, err := NewXoofff(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	xf.Compress(nonce, true)
+	tag := make([]byte, SANETagLen)
+	xf.Expand(tag, 0)
+	return &SANE{xf: xf}, tag, nil
+}
+
+// Wrap encrypts the plaintext and returns the ciphertext along with a tag authenticating the
+// ciphertext, the associated data and the session history
+func (s *SANE) Wrap(ad, plaintext []byte) (ciphertext, tag []byte) {
+	ciphertext = s.keystream(plaintext)
+	tag = make([]byte, SANETagLen)
+	s.update(ad, ciphertext, tag)
+	return ciphertext, tag
+}
+
+// Unwrap decrypts the ciphertext and checks the tag against the ciphertext, associated data and
+// session history. The plaintext is only returned if the tag is valid, otherwise ErrAuthFailed is
+// returned. The session history is updated in either case.
+func (s *SANE) Unwrap(ad, ciphertext, tag []byte) ([]byte, error) {
+	plaintext := s.keystream(ciphertext)
+	expected := make([]byte, SANETagLen)
+	s.update(ad, ciphertext, expected)
+	if subtle.ConstantTimeCompare(expected, tag) != 1 {
+		for i := range plaintext {
+			plaintext[i] = 0
+		}
+		return nil, ErrAuthFailed
+	}
+	return plaintext, nil
+}
+
+// keystream adds the output of the current history to the input, skipping the bytes already used
+// for the previous tag
+func (s *SANE) keystream(in []byte) []byte {
+	out := make([]byte, len(in))
+	s.xf.Expand(out, SANETagLen)
+	for i := range out {
+		out[i] ^= in[i]
+	}
+	return out
+}
+
+// update appends A||0||e and C||1||e to the history as required and computes the next tag
+func (s *SANE) update(ad, ciphertext, tag []byte) {
+	if len(ad) > 0 || len(ciphertext) == 0 {
+		s.xf.compressWithSuffix(ad, 0|s.e<<1, 2)
+	}
+	if len(ciphertext) > 0 {
+		s.xf.compressWithSuffix(ciphertext, 1|s.e<<1, 2)
+	}
+	s.xf.Expand(tag, 0)
+	s.e ^= 1
+}
@@ -0,0 +1,190 @@
+package xoofff
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func xorBytes(a, b []byte) []byte {
+	out := make([]byte, len(a))
+	for i := range a {
+		out[i] = a[i] ^ b[i]
+	}
+	return out
+}
+
+var sessionTestTable = []struct {
+	adLen        int
+	plaintextLen int
+}{
+	{adLen: 0, plaintextLen: 0},
+	{adLen: 16, plaintextLen: 0},
+	{adLen: 0, plaintextLen: 1},
+	{adLen: 5, plaintextLen: 47},
+	{adLen: 100, plaintextLen: 1000},
+	{adLen: 0, plaintextLen: 48},
+}
+
+// TestSANEMatchesFarfalle recomputes the session with farfalleSuffixed, rebuilding the Xoofff-SANE
+// suffixes apart from the buffered code of sane.go. Both follow the same reading of the
+// specification, so compatibility with other implementations is not checked.
+func TestSANEMatchesFarfalle(t *testing.T) {
+	rng := rand.New(rand.NewSource(10))
+	key, nonce := randomBytes(rng, 16), randomBytes(rng, 16)
+
+	session, initTag, err := NewSANE(key, nonce)
+	assert.NoError(t, err)
+	history := [][]byte{nonce}
+	finals := []byte{0x01}
+	assert.Equal(t, farfalleSuffixed(key, history, finals, SANETagLen, 0), initTag)
+
+	e := byte(0)
+	for _, tt := range sessionTestTable {
+		ad, plaintext := randomBytes(rng, tt.adLen), randomBytes(rng, tt.plaintextLen)
+		// C ← P + F(history) ≪ t
+		expectedCt := xorBytes(plaintext, farfalleSuffixed(key, history, finals, len(plaintext), SANETagLen))
+		if len(ad) > 0 || len(plaintext) == 0 {
+			history = append(history, ad)
+			finals = append(finals, 0|e<<1|1<<2)
+		}
+		if len(plaintext) > 0 {
+			history = append(history, expectedCt)
+			finals = append(finals, 1|e<<1|1<<2)
+		}
+		expectedTag := farfalleSuffixed(key, history, finals, SANETagLen, 0)
+		e ^= 1
+
+		gotCt, gotTag := session.Wrap(ad, plaintext)
+		assert.Equal(t, expectedCt, gotCt)
+		assert.Equal(t, expectedTag, gotTag)
+	}
+}
+
+func TestSANESession(t *testing.T) {
+	rng := rand.New(rand.NewSource(11))
+	key, nonce := randomBytes(rng, 32), randomBytes(rng, 16)
+	sender, senderTag, _ := NewSANE(key, nonce)
+	receiver, receiverTag, _ := NewSANE(key, nonce)
+	assert.Equal(t, senderTag, receiverTag)
+
+	for _, tt := range sessionTestTable {
+		ad, plaintext := randomBytes(rng, tt.adLen), randomBytes(rng, tt.plaintextLen)
+		ct, tag := sender.Wrap(ad, plaintext)
+		gotPt, err := receiver.Unwrap(ad, ct, tag)
+		assert.NoError(t, err)
+		assert.Equal(t, plaintext, gotPt)
+	}
+
+	// A forged tag is rejected
+	ct, tag := sender.Wrap([]byte("ad"), []byte("message"))
+	tag[0] ^= 0x80
+	gotPt, err := receiver.Unwrap([]byte("ad"), ct, tag)
+	assert.Equal(t, ErrAuthFailed, err)
+	assert.Nil(t, gotPt)
+
+	// The failed message still entered the history, so the session stays in step
+	ct, tag = sender.Wrap(nil, []byte("next"))
+	gotPt, err = receiver.Unwrap(nil, ct, tag)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("next"), gotPt)
+
+	// Skipping a message fails as the history differs
+	sender.Wrap(nil, []byte("skipped"))
+	ct, tag = sender.Wrap(nil, []byte("last"))
+	_, err = receiver.Unwrap(nil, ct, tag)
+	assert.Equal(t, ErrAuthFailed, err)
+
+	_, _, err = NewSANE(make([]byte, 48), nonce)
+	assert.Error(t, err)
+}
+
+// TestSANSEMatchesFarfalle is TestSANEMatchesFarfalle for Xoofff-SANSE
+func TestSANSEMatchesFarfalle(t *testing.T) {
+	rng := rand.New(rand.NewSource(12))
+	key := randomBytes(rng, 16)
+
+	session, err := NewSANSE(key)
+	assert.NoError(t, err)
+	var history [][]byte
+	var finals []byte
+
+	e := byte(0)
+	for _, tt := range sessionTestTable {
+		ad, plaintext := randomBytes(rng, tt.adLen), randomBytes(rng, tt.plaintextLen)
+		if len(ad) > 0 || len(plaintext) == 0 {
+			history = append(history, ad)
+			finals = append(finals, 0|e<<1|1<<2)
+		}
+		expectedCt := []byte{}
+		var expectedTag []byte
+		if len(plaintext) > 0 {
+			withP := append(append([][]byte{}, history...), plaintext)
+			withPFinals := append(append([]byte{}, finals...), 0|1<<1|e<<2|1<<3)
+			expectedTag = farfalleSuffixed(key, withP, withPFinals, SANSETagLen, 0)
+			withT := append(append([][]byte{}, history...), expectedTag)
+			withTFinals := append(append([]byte{}, finals...), 1|1<<1|e<<2|1<<3)
+			expectedCt = xorBytes(plaintext, farfalleSuffixed(key, withT, withTFinals, len(plaintext), 0))
+			history, finals = withP, withPFinals
+		} else {
+			expectedTag = farfalleSuffixed(key, history, finals, SANSETagLen, 0)
+		}
+		e ^= 1
+
+		gotCt, gotTag := session.Wrap(ad, plaintext)
+		assert.Equal(t, expectedCt, gotCt)
+		assert.Equal(t, expectedTag, gotTag)
+	}
+}
+
+func TestSANSESession(t *testing.T) {
+	rng := rand.New(rand.NewSource(13))
+	key := randomBytes(rng, 32)
+	sender, _ := NewSANSE(key)
+	receiver, _ := NewSANSE(key)
+
+	for _, tt := range sessionTestTable {
+		ad, plaintext := randomBytes(rng, tt.adLen), randomBytes(rng, tt.plaintextLen)
+		ct, tag := sender.Wrap(ad, plaintext)
+		gotPt, err := receiver.Unwrap(ad, ct, tag)
+		assert.NoError(t, err)
+		assert.Equal(t, plaintext, gotPt)
+	}
+
+	// Sessions are deterministic
+	first, _ := NewSANSE(key)
+	second, _ := NewSANSE(key)
+	ct1, tag1 := first.Wrap([]byte("ad"), []byte("message"))
+	ct2, tag2 := second.Wrap([]byte("ad"), []byte("message"))
+	assert.Equal(t, ct1, ct2)
+	assert.Equal(t, tag1, tag2)
+
+	// A modified ciphertext is rejected
+	ct, tag := sender.Wrap([]byte("ad"), []byte("message"))
+	ct[0] ^= 0x01
+	gotPt, err := receiver.Unwrap([]byte("ad"), ct, tag)
+	assert.Equal(t, ErrAuthFailed, err)
+	assert.Nil(t, gotPt)
+
+	_, err = NewSANSE(make([]byte, 48))
+	assert.Error(t, err)
+}
+
+func BenchmarkSANEWrap(b *testing.B) {
+	session, _, _ := NewSANE(make([]byte, 16), make([]byte, 16))
+	plaintext := make([]byte, 1024)
+	ad := make([]byte, 64)
+	for n := 0; n < b.N; n++ {
+		session.Wrap(ad, plaintext)
+	}
+}
+
+func BenchmarkSANSEWrap(b *testing.B) {
+	session, _ := NewSANSE(make([]byte, 16))
+	plaintext := make([]byte, 1024)
+	ad := make([]byte, 64)
+	for n := 0; n < b.N; n++ {
+		session.Wrap(ad, plaintext)
+	}
+}
@@ -0,0 +1,87 @@
+package xoofff
+
+import "crypto/subtle"
+
+// SANSE is a Xoofff-SANSE session: SIV-based authenticated encryption of a sequence of messages
+// that does not take a nonce. Identical messages wrapped at the same point of two sessions with the
+// same key produce identical ciphertexts, but no other information leaks and authenticity is kept.
+// As with SANE, each tag authenticates the whole session history.
+type SANSE struct {
+	xf *Xoofff
+	e  byte
+}
+
+// NewSANSE starts a Xoofff-SANSE session for the given key
+func NewSANSE(key []byte) (*SANSE, error) {
+	xf, err := NewXoofff(key)
+	if err != nil {
+		return nil, err
+	}
+	return &SANSE{xf: xf}, nil
+}
+
+// Wrap encrypts the plaintext and returns the ciphertext along with a tag authenticating the
+// plaintext, the associated data and the session history. The tag doubles as the synthetic
+// initialization vector of the encryption.
+func (s *SANSE) Wrap(ad, plaintext []byte) (ciphertext, tag []byte) {
+	s.addAD(ad, len(plaintext))
+	tag = make([]byte, SANSETagLen)
+	ciphertext = make([]byte, len(plaintext))
+	if len(plaintext) > 0 {
+		// The tag is computed over the history with P||01||e appended, but the encryption
+		// keystream branches from the history before the plaintext
+		withPlaintext := s.xf.Clone()
+		withPlaintext.compressWithSuffix(plaintext, s.plaintextSuffix(), 3)
+		withPlaintext.Expand(tag, 0)
+		s.keystream(tag, plaintext, ciphertext)
+		s.xf = withPlaintext
+	} else {
+		s.xf.Expand(tag, 0)
+	}
+	s.e ^= 1
+	return ciphertext, tag
+}
+
+// Unwrap decrypts the ciphertext and checks the tag against the recovered plaintext, associated
+// data and session history. The plaintext is only returned if the tag is valid, otherwise
+// ErrAuthFailed is returned. The session history is updated in either case.
+func (s *SANSE) Unwrap(ad, ciphertext, tag []byte) ([]byte, error) {
+	s.addAD(ad, len(ciphertext))
+	expected := make([]byte, SANSETagLen)
+	plaintext := make([]byte, len(ciphertext))
+	if len(ciphertext) > 0 {
+		s.keystream(tag, ciphertext, plaintext)
+		s.xf.compressWithSuffix(plaintext, s.plaintextSuffix(), 3)
+	}
+	s.xf.Expand(expected, 0)
+	s.e ^= 1
+	if subtle.ConstantTimeCompare(expected, tag) != 1 {
+		for i := range plaintext {
+			plaintext[i] = 0
+		}
+		return nil, ErrAuthFailed
+	}
+	return plaintext, nil
+}
+
+// addAD appends A||0||e to the history when there is associated data or no message
+func (s *SANSE) addAD(ad []byte, messageLen int) {
+	if len(ad) > 0 || messageLen == 0 {
+		s.xf.compressWithSuffix(ad, 0|s.e<<1, 2)
+	}
+}
+
+// plaintextSuffix returns the domain separation bits 01||e
+func (s *SANSE) plaintextSuffix() byte {
+	return 0 | 1<<1 | s.e<<2
+}
+
+// keystream adds the output of T||11||e appended to the history to the input
+func (s *SANSE) keystream(tag, in, out []byte) {
+	branch := s.xf.Clone()
+	branch.compressWithSuffix(tag, 1|1<<1|s.e<<2, 3)
+	branch.Expand(out, 0)
+	for i := range out {
+		out[i] ^= in[i]
+	}
+}
@@ -0,0 +1,153 @@
+package xoofff
+
+import "fmt"
+
+const (
+	// WBCMinBlockLen is the smallest block in bytes that the wide block cipher can process
+	WBCMinBlockLen = 2
+	// WBCAEExpansion is the number of zero bytes of redundancy Xoofff-WBC-AE appends to each
+	// plaintext before enciphering it
+	WBCAEExpansion = 16
+)
+
+// WBC is the Xoofff-WBC tweakable wide block cipher: the Farfalle-WBC four round Feistel network
+// built from the Xoofff deck function. It enciphers blocks of any length of at least
+// WBCMinBlockLen bytes without expansion, under a tweak of any length, making it suitable for
+// encrypting fixed size units such as disk sectors or database pages with the unit number as tweak.
+//
+// Enciphering a block P split into L||R computes
+//
+//	R_0 ← R_0 + H(L||0)
+//	L ← L + G(W ◦ R||1)
+//	R ← R + G(W ◦ L||0)
+//	L_0 ← L_0 + H(R||1)
+//
+// where W is the tweak, X_0 is the first block of X, and both H and G are evaluations of Xoofff.
+// A WBC object is not safe for concurrent use.
+type WBC struct {
+	xf  *Xoofff
+	buf []byte
+}
+
+// NewWBC returns a Xoofff-WBC cipher for the given key
+func NewWBC(key []byte) (*WBC, error) {
+	xf, err := NewXoofff(key)
+	if err != nil {
+		return nil, err
+	}
+	return &WBC{xf: xf}, nil
+}
+
+// wbcSplit returns the length of the left half of an n byte block. Short blocks are split
+// evenly; longer blocks give the left half the whole number of Xoofff blocks nearest below half
+// the length, so that both halves span at least one block.
+func wbcSplit(n int) int {
+	if n < 2*BlockSize {
+		return (n + 1) / 2
+	}
+	return n / 2 / BlockSize * BlockSize
+}
+
+// Encrypt enciphers the block under the tweak and returns the result, which has the same length
+// as the block
+func (w *WBC) Encrypt(tweak, block []byte) ([]byte, error) {
+	if len(block) < WBCMinBlockLen {
+		return nil, fmt.Errorf("xoofff/wbc: given block length (%d bytes) less than minimum length (%d bytes)", len(block), WBCMinBlockLen)
+	}
+	out := append([]byte{}, block...)
+	l, r := out[:wbcSplit(len(out))], out[wbcSplit(len(out)):]
+	w.h(l, 0, r)
+	w.g(tweak, r, 1, l)
+	w.g(tweak, l, 0, r)
+	w.h(r, 1, l)
+	return out, nil
+}
+
+// Decrypt deciphers the block under the tweak and returns the result, which has the same length
+// as the block
+func (w *WBC) Decrypt(tweak, block []byte) ([]byte, error) {
+	if len(block) < WBCMinBlockLen {
+		return nil, fmt.Errorf("xoofff/wbc: given block length (%d bytes) less than minimum length (%d bytes)", len(block), WBCMinBlockLen)
+	}
+	out := append([]byte{}, block...)
+	l, r := out[:wbcSplit(len(out))], out[wbcSplit(len(out)):]
+	w.h(r, 1, l)
+	w.g(tweak, l, 0, r)
+	w.g(tweak, r, 1, l)
+	w.h(l, 0, r)
+	return out, nil
+}
+
+// h adds H(in||bit) to the first block of out
+func (w *WBC) h(in []byte, bit byte, out []byte) {
+	if len(out) > BlockSize {
+		out = out[:BlockSize]
+	}
+	w.xf.Reset()
+	w.xf.compressWithSuffix(in, bit, 1)
+	w.addOutput(out)
+}
+
+// g adds G(tweak ◦ in||bit) to all of out
+func (w *WBC) g(tweak, in []byte, bit byte, out []byte) {
+	w.xf.Reset()
+	w.xf.Compress(tweak, true)
+	w.xf.compressWithSuffix(in, bit, 1)
+	w.addOutput(out)
+}
+
+// addOutput adds the deck function output for the compressed strings to out
+func (w *WBC) addOutput(out []byte) {
+	if cap(w.buf) < len(out) {
+		w.buf = make([]byte, len(out))
+	}
+	stream := w.buf[:len(out)]
+	w.xf.Expand(stream, 0)
+	for i := range out {
+		out[i] ^= stream[i]
+	}
+}
+
+// WBCAE is Xoofff-WBC-AE: authenticated encryption obtained by enciphering the plaintext extended
+// with WBCAEExpansion zero bytes using Xoofff-WBC, with the associated data as the tweak. Any
+// modification of the ciphertext or associated data scrambles the whole deciphered block, so the
+// redundancy is lost and decryption fails. A WBCAE object is not safe for concurrent use.
+type WBCAE struct {
+	wbc *WBC
+}
+
+// NewWBCAE returns a Xoofff-WBC-AE cipher for the given key
+func NewWBCAE(key []byte) (*WBCAE, error) {
+	wbc, err := NewWBC(key)
+	if err != nil {
+		return nil, err
+	}
+	return &WBCAE{wbc: wbc}, nil
+}
+
+// Encrypt returns the ciphertext for the plaintext and associated data, which is WBCAEExpansion
+// bytes longer than the plaintext
+func (w *WBCAE) Encrypt(ad, plaintext []byte) []byte {
+	padded := make([]byte, len(plaintext)+WBCAEExpansion)
+	copy(padded, plaintext)
+	ciphertext, _ := w.wbc.Encrypt(ad, padded)
+	return ciphertext
+}
+
+// Decrypt returns the plaintext for the ciphertext and associated data. ErrAuthFailed is returned
+// if the redundancy added at encryption is not found.
+func (w *WBCAE) Decrypt(ad, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < WBCAEExpansion {
+		return nil, fmt.Errorf("xoofff/wbc: given ciphertext (%d bytes) less than minimum length (%d bytes)", len(ciphertext), WBCAEExpansion)
+	}
+	padded, _ := w.wbc.Decrypt(ad, ciphertext)
+	plaintext, redundancy := padded[:len(padded)-WBCAEExpansion], padded[len(padded)-WBCAEExpansion:]
+	var check byte
+	for _, b := range redundancy {
+		check |= b
+	}
+	if check != 0 {
+		return nil, ErrAuthFailed
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,155 @@
+package xoofff
+
+import (
+	"encoding/hex"
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func countingBytes(n int) []byte {
+	out := make([]byte, n)
+	for i := range out {
+		out[i] = byte(i)
+	}
+	return out
+}
+
+func hexBytes(s string) []byte {
+	out, _ := hex.DecodeString(s)
+	return out
+}
+
+// Regression vectors produced by this implementation under the key "0123456789abcdef". They guard
+// against unintended changes but are not XKCP Xoofff-WBC vectors, so they do not establish
+// compatibility with other implementations.
+var wbcRegressionTestTable = []struct {
+	tweak      []byte
+	plaintext  []byte
+	ciphertext []byte
+}{
+	{
+		tweak:      []byte("tweak"),
+		plaintext:  countingBytes(2),
+		ciphertext: hexBytes("d938"),
+	},
+	{
+		tweak:      []byte("tweak"),
+		plaintext:  countingBytes(17),
+		ciphertext: hexBytes("c378136bc0043c0888a65a08f1e6bcec70"),
+	},
+	{
+		tweak:      []byte("tweak"),
+		plaintext:  countingBytes(48),
+		ciphertext: hexBytes("72bba0c72c12e16075aaa649ab28193f9fe48bf4a7f88e7a330012ad28693732a60ad838c6a63d63a1f08f326a4716f3"),
+	},
+	{
+		tweak:      []byte("tweak"),
+		plaintext:  countingBytes(100),
+		ciphertext: hexBytes("ffc450a674ba2110106c7e3ecb9135457b7b2ca27f3b79ff7b8f7602ad368f9475c61bd9f360af5a91b027bc32ac34d82659eda6c22ce1e4964fb1282038dcb9e35221d8c257e5ecd7f04d951af218a11efc1abfa58a68fa4df7ddc5fe0fb1c923c5c96a"),
+	},
+}
+
+func TestWBCRegression(t *testing.T) {
+	wbc, _ := NewWBC([]byte("0123456789abcdef"))
+	for _, tt := range wbcRegressionTestTable {
+		gotCt, err := wbc.Encrypt(tt.tweak, tt.plaintext)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.ciphertext, gotCt)
+		gotPt, err := wbc.Decrypt(tt.tweak, tt.ciphertext)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.plaintext, gotPt)
+	}
+}
+
+func TestWBCSplit(t *testing.T) {
+	for n := WBCMinBlockLen; n < 1000; n++ {
+		l := wbcSplit(n)
+		assert.True(t, l > 0 && l < n)
+		if n >= 2*BlockSize {
+			assert.Equal(t, 0, l%BlockSize)
+			assert.True(t, l >= BlockSize && n-l >= BlockSize)
+		}
+	}
+}
+
+func TestWBCRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(20))
+	wbc, _ := NewWBC(randomBytes(rng, 32))
+	for _, n := range []int{2, 3, 47, 48, 49, 95, 96, 97, 143, 144, 512, 4096} {
+		tweak := randomBytes(rng, 8)
+		plaintext := randomBytes(rng, n)
+		ciphertext, err := wbc.Encrypt(tweak, plaintext)
+		assert.NoError(t, err)
+		assert.Len(t, ciphertext, n)
+		assert.NotEqual(t, plaintext, ciphertext)
+
+		got, err := wbc.Decrypt(tweak, ciphertext)
+		assert.NoError(t, err)
+		assert.Equal(t, plaintext, got)
+
+		// A different tweak gives an unrelated result
+		otherTweak := append([]byte{}, tweak...)
+		otherTweak[0] ^= 0x01
+		other, _ := wbc.Encrypt(otherTweak, plaintext)
+		assert.NotEqual(t, ciphertext, other)
+
+		// Changing the last byte of the plaintext changes both halves of the ciphertext
+		modified := append([]byte{}, plaintext...)
+		modified[n-1] ^= 0x01
+		changed, _ := wbc.Encrypt(tweak, modified)
+		split := wbcSplit(n)
+		assert.NotEqual(t, ciphertext[:split], changed[:split])
+		assert.NotEqual(t, ciphertext[split:], changed[split:])
+	}
+}
+
+func TestWBCErrors(t *testing.T) {
+	wbc, _ := NewWBC(nil)
+	_, err := wbc.Encrypt(nil, []byte{0x00})
+	assert.Equal(t, errors.New("xoofff/wbc: given block length (1 bytes) less than minimum length (2 bytes)"), err)
+	_, err = wbc.Decrypt(nil, nil)
+	assert.Equal(t, errors.New("xoofff/wbc: given block length (0 bytes) less than minimum length (2 bytes)"), err)
+	_, err = NewWBC(make([]byte, 48))
+	assert.Error(t, err)
+	_, err = NewWBCAE(make([]byte, 48))
+	assert.Error(t, err)
+}
+
+func TestWBCAE(t *testing.T) {
+	ae, _ := NewWBCAE([]byte("0123456789abcdef"))
+	ciphertext := ae.Encrypt([]byte("ad"), []byte("hello"))
+	// Regression vector, like those of wbcRegressionTestTable
+	assert.Equal(t, hexBytes("e7f5a341f5e09ec251fa0703bbc7b722195606ba1a"), ciphertext)
+
+	plaintext, err := ae.Decrypt([]byte("ad"), ciphertext)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("hello"), plaintext)
+
+	_, err = ae.Decrypt([]byte("other ad"), ciphertext)
+	assert.Equal(t, ErrAuthFailed, err)
+	ciphertext[0] ^= 0x01
+	_, err = ae.Decrypt([]byte("ad"), ciphertext)
+	assert.Equal(t, ErrAuthFailed, err)
+
+	empty := ae.Encrypt(nil, nil)
+	assert.Len(t, empty, WBCAEExpansion)
+	plaintext, err = ae.Decrypt(nil, empty)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{}, plaintext)
+
+	_, err = ae.Decrypt(nil, make([]byte, 15))
+	assert.Equal(t, errors.New("xoofff/wbc: given ciphertext (15 bytes) less than minimum length (16 bytes)"), err)
+}
+
+func BenchmarkWBCEncrypt4096(b *testing.B) {
+	wbc, _ := NewWBC(make([]byte, 16))
+	block := make([]byte, 4096)
+	tweak := make([]byte, 8)
+	b.SetBytes(int64(len(block)))
+	for n := 0; n < b.N; n++ {
+		wbc.Encrypt(tweak, block)
+	}
+}
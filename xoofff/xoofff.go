@@ -0,0 +1,261 @@
+package xoofff
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+
+	"github.com/inmcm/xoodoo/xoodoo"
+)
+
+const (
+	// Rounds is the number of Xoodoo rounds applied by every permutation in Xoofff
+	Rounds = 6
+	// BlockSize is the number of bytes in each input and output block processed by Xoofff
+	BlockSize = xoodoo.StateSizeBytes
+	// MaxKeyLen is the longest key in bytes that fits in a single block along with its padding
+	MaxKeyLen = BlockSize - 1
+
+	// parallelism is the number of independent blocks handed to the multi-state permutation at once
+	parallelism = xoodoo.Times8
+)
+
+var (
+	// ErrPartialString is returned when output is requested while an input string has only been
+	// partially compressed
+	ErrPartialString = errors.New("xoofff: input string not finished")
+
+	// ErrNoInput is returned when output is requested before any input string has been compressed
+	ErrNoInput = errors.New("xoofff: no input string compressed")
+)
+
+// Xoofff holds the state of the Xoofff deck function for a single key. Input strings are
+// compressed into an accumulator which can be expanded into output at any point between strings.
+type Xoofff struct {
+	k        xoodoo.State // derived key mask
+	kRoll    xoodoo.State // mask for the next compressed block
+	x        xoodoo.State // compression accumulator
+	buf      [BlockSize]byte
+	bufLen   int
+	inString bool
+	strings  int
+	perm     *xoodoo.Xoodoo
+	times    *xoodoo.XoodooTimes
+}
+
+// NewXoofff derives the Xoofff mask from the provided key, which can be up to MaxKeyLen bytes,
+// and returns an instance ready to compress input strings
+func NewXoofff(key []byte) (*Xoofff, error) {
+	if len(key) > MaxKeyLen {
+		return nil, fmt.Errorf("xoofff: given key length (%d bytes) exceeds maximum (%d bytes)", len(key), MaxKeyLen)
+	}
+	perm, _ := xoodoo.NewXoodoo(Rounds, [xoodoo.StateSizeBytes]byte{})
+	times, _ := xoodoo.NewXoodooTimes(parallelism, Rounds)
+	xf := &Xoofff{perm: perm, times: times}
+
+	// k ← p_b(K||1||0*)
+	xf.perm.State.AddBytes(key, 0)
+	xf.perm.State.AddBytes([]byte{0x01}, len(key))
+	xf.perm.Permutation()
+	xf.k = xf.perm.State
+	xf.Reset()
+	return xf, nil
+}
+
+// Reset discards all compressed input, returning the instance to the state it had just after the
+// key was set up
+func (xf *Xoofff) Reset() {
+	xf.kRoll = xf.k
+	xf.x = xoodoo.State{}
+	xf.buf = [BlockSize]byte{}
+	xf.bufLen = 0
+	xf.inString = false
+	xf.strings = 0
+}
+
+// Clone returns an independent copy of the instance, including any partially compressed input
+func (xf *Xoofff) Clone() *Xoofff {
+	perm, _ := xoodoo.NewXoodoo(Rounds, [xoodoo.StateSizeBytes]byte{})
+	times, _ := xoodoo.NewXoodooTimes(parallelism, Rounds)
+	clone := *xf
+	clone.perm = perm
+	clone.times = times
+	return &clone
+}
+
+// Compress adds the provided bytes to the current input string. A string may be supplied over
+// any number of calls; setting last on the final call closes the string so that the next call
+// starts a new string of the sequence. An empty string is compressed by a single call with no
+// data and last set.
+func (xf *Xoofff) Compress(in []byte, last bool) {
+	xf.absorb(in)
+	if last {
+		xf.finish(0, 0)
+	}
+}
+
+// compressWithSuffix compresses in as a complete string followed by the low suffixBits bits of
+// suffix (least significant bit first), as used by the modes for domain separation
+func (xf *Xoofff) compressWithSuffix(in []byte, suffix byte, suffixBits uint) {
+	xf.absorb(in)
+	xf.finish(suffix, suffixBits)
+}
+
+// absorb adds bytes to the current string, compressing every full block that is known not to be
+// the last block of the string
+func (xf *Xoofff) absorb(in []byte) {
+	xf.inString = true
+	if xf.bufLen > 0 {
+		n := copy(xf.buf[xf.bufLen:], in)
+		xf.bufLen += n
+		in = in[n:]
+		if xf.bufLen == BlockSize && len(in) > 0 {
+			xf.compressBlocks(xf.buf[:])
+			xf.bufLen = 0
+		}
+	}
+	// Keep the final full block buffered; it may be the last block of the string, in which
+	// case padding must follow it
+	if full := (len(in) - 1) / BlockSize * BlockSize; full > 0 {
+		xf.compressBlocks(in[:full])
+		in = in[full:]
+	}
+	xf.bufLen += copy(xf.buf[xf.bufLen:], in)
+}
+
+// finish closes the current string by appending the suffix bits and the padding M||1||0*
+func (xf *Xoofff) finish(suffix byte, suffixBits uint) {
+	if xf.bufLen == BlockSize {
+		xf.compressBlocks(xf.buf[:])
+		xf.bufLen = 0
+	}
+	for i := xf.bufLen; i < BlockSize; i++ {
+		xf.buf[i] = 0
+	}
+	xf.buf[xf.bufLen] = suffix&(1<<suffixBits-1) | 1<<suffixBits
+	xf.compressBlocks(xf.buf[:])
+	// Strings are separated by an extra roll of the mask
+	rollc(&xf.kRoll)
+	xf.buf = [BlockSize]byte{}
+	xf.bufLen = 0
+	xf.inString = false
+	xf.strings++
+}
+
+// compressBlocks masks each full block with successive rolls of the key mask, permutes it and
+// accumulates the result
+func (xf *Xoofff) compressBlocks(blocks []byte) {
+	for len(blocks) >= parallelism*BlockSize {
+		for i := 0; i < parallelism; i++ {
+			xf.times.SetInstance(i, xf.kRoll)
+			xf.times.XorBytes(i, blocks[i*BlockSize:(i+1)*BlockSize])
+			rollc(&xf.kRoll)
+		}
+		xf.times.Permutation()
+		for i := 0; i < parallelism; i++ {
+			s, _ := xf.times.Instance(i)
+			xf.x = xoodoo.XorState(xf.x, s)
+		}
+		blocks = blocks[parallelism*BlockSize:]
+	}
+	for ; len(blocks) > 0; blocks = blocks[BlockSize:] {
+		xf.perm.State = xf.kRoll
+		xf.perm.State.XorStateBytes(blocks[:BlockSize])
+		xf.perm.Permutation()
+		xf.x = xoodoo.XorState(xf.x, xf.perm.State)
+		rollc(&xf.kRoll)
+	}
+}
+
+// Expand fills the output buffer with the Xoofff output for the sequence of strings compressed so
+// far, starting at the given byte offset into the output stream. Expanding does not change the
+// instance, so more strings can be compressed afterwards. An error is returned if no string has
+// been compressed yet or if the current string has not been finished.
+func (xf *Xoofff) Expand(out []byte, offset uint64) error {
+	if xf.inString {
+		return ErrPartialString
+	}
+	if xf.strings == 0 {
+		return ErrNoInput
+	}
+	// y ← p_d(x)
+	xf.perm.State = xf.x
+	xf.perm.Permutation()
+	y := xf.perm.State
+	for j := offset / BlockSize; j > 0; j-- {
+		rolle(&y)
+	}
+	skip := int(offset % BlockSize)
+
+	var block [BlockSize]byte
+	for len(out) > 0 {
+		count := (skip + len(out) + BlockSize - 1) / BlockSize
+		if count >= parallelism {
+			for i := 0; i < parallelism; i++ {
+				xf.times.SetInstance(i, y)
+				rolle(&y)
+			}
+			xf.times.Permutation()
+			for i := 0; i < parallelism && len(out) > 0; i++ {
+				s, _ := xf.times.Instance(i)
+				out = xf.output(xoodoo.XorState(s, xf.kRoll), &block, &skip, out)
+			}
+			continue
+		}
+		xf.perm.State = y
+		xf.perm.Permutation()
+		rolle(&y)
+		out = xf.output(xoodoo.XorState(xf.perm.State, xf.kRoll), &block, &skip, out)
+	}
+	return nil
+}
+
+// output copies an output block, less any bytes still to be skipped, to the front of out and
+// returns the part of out left to fill
+func (xf *Xoofff) output(z xoodoo.State, block *[BlockSize]byte, skip *int, out []byte) []byte {
+	z.ExtractBytes(block[:], 0)
+	n := copy(out, block[*skip:])
+	*skip = 0
+	return out[n:]
+}
+
+// Evaluate is a convenience function that computes length bytes of Xoofff output, starting at the
+// given offset, for the key and sequence of input strings
+func Evaluate(key []byte, length int, offset uint64, strings ...[]byte) ([]byte, error) {
+	xf, err := NewXoofff(key)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range strings {
+		xf.Compress(s, true)
+	}
+	out := make([]byte, length)
+	if err := xf.Expand(out, offset); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// rollc is the Xoofff input mask rolling function roll_c. It updates a single lane as a linear
+// feedback shift register and then cycles the planes:
+//
+//	A_0,0 ← A_0,0 + (A_0,0 ≪ 13) + (A_1,0 ⋘ 3)
+//	B ← A_0 ⋘ (3,0), A_0 ← A_1, A_1 ← A_2, A_2 ← B
+func rollc(s *xoodoo.State) {
+	a := s[0] ^ s[0]<<13 ^ bits.RotateLeft32(s[4], 3)
+	b0, b1, b2, b3 := s[1], s[2], s[3], a
+	copy(s[0:8], s[4:12])
+	s[8], s[9], s[10], s[11] = b0, b1, b2, b3
+}
+
+// rolle is the Xoofff output state rolling function roll_e. It updates a single lane with a
+// non-linear feedback function and then cycles the planes:
+//
+//	A_0,0 ← A_1,0·A_2,0 + (A_0,0 ⋘ 5) + (A_1,0 ⋘ 13) + 7
+//	B ← A_0 ⋘ (3,0), A_0 ← A_1, A_1 ← A_2, A_2 ← B
+func rolle(s *xoodoo.State) {
+	a := s[4]&s[8] ^ bits.RotateLeft32(s[0], 5) ^ bits.RotateLeft32(s[4], 13) ^ 7
+	b0, b1, b2, b3 := s[1], s[2], s[3], a
+	copy(s[0:8], s[4:12])
+	s[8], s[9], s[10], s[11] = b0, b1, b2, b3
+}
@@ -0,0 +1,233 @@
+package xoofff
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/inmcm/xoodoo/xoodoo"
+	"github.com/stretchr/testify/assert"
+)
+
+// farfalle is a direct, block-at-a-time transcription of the Farfalle algorithm used to check the
+// buffered and parallel code paths
+func farfalle(key []byte, strings [][]byte, n int, q int) []byte {
+	return farfalleSuffixed(key, strings, nil, n, q)
+}
+
+// farfalleSuffixed is farfalle with a final byte, holding any suffix bits and the first padding
+// bit, given per string. A nil list of final bytes pads every string with 0x01.
+func farfalleSuffixed(key []byte, strings [][]byte, finals []byte, n int, q int) []byte {
+	permute := func(s xoodoo.State) xoodoo.State {
+		xd, _ := xoodoo.NewXoodoo(Rounds, [xoodoo.StateSizeBytes]byte{})
+		xd.State = s
+		xd.Permutation()
+		return xd.State
+	}
+	var padded [BlockSize]byte
+	copy(padded[:], key)
+	padded[len(key)] = 0x01
+	var k xoodoo.State
+	k.UnmarshalBinary(padded[:])
+	k = permute(k)
+
+	var x xoodoo.State
+	I := 0
+	for j, m := range strings {
+		final := byte(0x01)
+		if finals != nil {
+			final = finals[j]
+		}
+		m = append(append([]byte{}, m...), final)
+		for len(m)%BlockSize != 0 {
+			m = append(m, 0x00)
+		}
+		mu := len(m) / BlockSize
+		for i := 0; i < mu; i++ {
+			mask := k
+			for r := 0; r < I+i; r++ {
+				rollc(&mask)
+			}
+			var block xoodoo.State
+			block.UnmarshalBinary(m[i*BlockSize : (i+1)*BlockSize])
+			x = xoodoo.XorState(x, permute(xoodoo.XorState(block, mask)))
+		}
+		I += mu + 1
+	}
+	kPrime := k
+	for r := 0; r < I; r++ {
+		rollc(&kPrime)
+	}
+	y := permute(x)
+	z := []byte{}
+	for j := 0; len(z) < n+q; j++ {
+		s := xoodoo.XorState(permute(y), kPrime)
+		out, _ := s.MarshalBinary()
+		z = append(z, out...)
+		rolle(&y)
+	}
+	return z[q : n+q]
+}
+
+func randomBytes(rng *rand.Rand, n int) []byte {
+	out := make([]byte, n)
+	rng.Read(out)
+	return out
+}
+
+func TestRollFunctions(t *testing.T) {
+	s := xoodoo.State{0x00000001, 0x00000002, 0x00000003, 0x00000004,
+		0x00000005, 0x00000006, 0x00000007, 0x00000008,
+		0x00000009, 0x0000000A, 0x0000000B, 0x0000000C}
+	c := s
+	rollc(&c)
+	assert.Equal(t, xoodoo.State{0x00000005, 0x00000006, 0x00000007, 0x00000008,
+		0x00000009, 0x0000000A, 0x0000000B, 0x0000000C,
+		0x00000002, 0x00000003, 0x00000004, 0x00000001 ^ 0x00002000 ^ 0x00000028}, c)
+
+	e := s
+	rolle(&e)
+	assert.Equal(t, xoodoo.State{0x00000005, 0x00000006, 0x00000007, 0x00000008,
+		0x00000009, 0x0000000A, 0x0000000B, 0x0000000C,
+		0x00000002, 0x00000003, 0x00000004, (0x00000005 & 0x00000009) ^ 0x00000020 ^ 0x0000A000 ^ 7}, e)
+
+	// roll_c is linear
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		var a, b xoodoo.State
+		a.UnmarshalBinary(randomBytes(rng, BlockSize))
+		b.UnmarshalBinary(randomBytes(rng, BlockSize))
+		sum := xoodoo.XorState(a, b)
+		rollc(&a)
+		rollc(&b)
+		rollc(&sum)
+		assert.Equal(t, xoodoo.XorState(a, b), sum)
+	}
+}
+
+func TestXoofffMatchesFarfalle(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	stringLens := [][]int{
+		{0},
+		{1},
+		{47},
+		{48},
+		{49},
+		{96},
+		{8*BlockSize - 1, 8 * BlockSize, 8*BlockSize + 1},
+		{0, 0, 0},
+		{1000, 3, 0, 500},
+	}
+	for _, lens := range stringLens {
+		key := randomBytes(rng, 16)
+		strings := make([][]byte, len(lens))
+		for i, l := range lens {
+			strings[i] = randomBytes(rng, l)
+		}
+		for _, outLen := range []int{0, 1, 48, 100, 9 * BlockSize} {
+			for _, offset := range []int{0, 1, 47, 48, 500} {
+				got, err := Evaluate(key, outLen, uint64(offset), strings...)
+				assert.NoError(t, err)
+				assert.Equal(t, farfalle(key, strings, outLen, offset), got)
+			}
+		}
+	}
+}
+
+func TestXoofffIncrementalCompress(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	key := randomBytes(rng, MaxKeyLen)
+	strings := [][]byte{randomBytes(rng, 1234), randomBytes(rng, 0), randomBytes(rng, 480)}
+	expected, _ := Evaluate(key, 200, 0, strings...)
+
+	for _, chunk := range []int{1, 7, 48, 50, 400} {
+		xf, _ := NewXoofff(key)
+		for _, s := range strings {
+			for len(s) > chunk {
+				xf.Compress(s[:chunk], false)
+				s = s[chunk:]
+			}
+			xf.Compress(s, true)
+		}
+		got := make([]byte, 200)
+		assert.NoError(t, xf.Expand(got, 0))
+		assert.Equal(t, expected, got)
+	}
+}
+
+func TestXoofffIncrementalSequence(t *testing.T) {
+	rng := rand.New(rand.NewSource(4))
+	key := randomBytes(rng, 32)
+	first, second := randomBytes(rng, 60), randomBytes(rng, 5)
+
+	xf, _ := NewXoofff(key)
+	xf.Compress(first, true)
+	gotFirst := make([]byte, 64)
+	assert.NoError(t, xf.Expand(gotFirst, 0))
+	clone := xf.Clone()
+	xf.Compress(second, true)
+	gotBoth := make([]byte, 64)
+	assert.NoError(t, xf.Expand(gotBoth, 0))
+
+	expectedFirst, _ := Evaluate(key, 64, 0, first)
+	expectedBoth, _ := Evaluate(key, 64, 0, first, second)
+	assert.Equal(t, expectedFirst, gotFirst)
+	assert.Equal(t, expectedBoth, gotBoth)
+
+	// The clone is unaffected by the second string
+	gotClone := make([]byte, 64)
+	assert.NoError(t, clone.Expand(gotClone, 0))
+	assert.Equal(t, expectedFirst, gotClone)
+
+	// String boundaries are significant
+	joined, _ := Evaluate(key, 64, 0, append(append([]byte{}, first...), second...))
+	assert.NotEqual(t, expectedBoth, joined)
+
+	xf.Reset()
+	xf.Compress(first, true)
+	assert.NoError(t, xf.Expand(gotFirst, 0))
+	assert.Equal(t, expectedFirst, gotFirst)
+}
+
+func TestXoofffExpandOffset(t *testing.T) {
+	key := []byte("offset key")
+	all, _ := Evaluate(key, 2000, 0, []byte("message"))
+	for _, offset := range []int{0, 1, 47, 48, 49, 383, 384, 385, 1000} {
+		got, err := Evaluate(key, 2000-offset, uint64(offset), []byte("message"))
+		assert.NoError(t, err)
+		assert.Equal(t, all[offset:], got)
+	}
+}
+
+func TestXoofffErrors(t *testing.T) {
+	gotXf, gotErr := NewXoofff(make([]byte, BlockSize))
+	assert.Equal(t, (*Xoofff)(nil), gotXf)
+	assert.Equal(t, errors.New("xoofff: given key length (48 bytes) exceeds maximum (47 bytes)"), gotErr)
+
+	xf, _ := NewXoofff([]byte{})
+	out := make([]byte, 16)
+	assert.Equal(t, ErrNoInput, xf.Expand(out, 0))
+	xf.Compress([]byte{0x01}, false)
+	assert.Equal(t, ErrPartialString, xf.Expand(out, 0))
+	xf.Compress(nil, true)
+	assert.NoError(t, xf.Expand(out, 0))
+}
+
+func BenchmarkXoofffCompress(b *testing.B) {
+	xf, _ := NewXoofff(make([]byte, 32))
+	data := make([]byte, 8192)
+	b.SetBytes(int64(len(data)))
+	for n := 0; n < b.N; n++ {
+		xf.Compress(data, false)
+	}
+}
+
+func BenchmarkXoofffExpand(b *testing.B) {
+	xf, _ := NewXoofff(make([]byte, 32))
+	xf.Compress(nil, true)
+	out := make([]byte, 8192)
+	b.SetBytes(int64(len(out)))
+	for n := 0; n < b.N; n++ {
+		xf.Expand(out, 0)
+	}
+}